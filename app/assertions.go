@@ -0,0 +1,291 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type AssertionSubject string
+
+const (
+	StatusAssertionSubject AssertionSubject = "status"
+	HeaderAssertionSubject AssertionSubject = "header"
+	JsonAssertionSubject   AssertionSubject = "json"
+	BodyAssertionSubject   AssertionSubject = "body"
+	TimeAssertionSubject   AssertionSubject = "time"
+)
+
+type AssertionOperator string
+
+const (
+	EqualsOperator      AssertionOperator = "=="
+	NotEqualsOperator   AssertionOperator = "!="
+	MatchesOperator     AssertionOperator = "~"
+	ContainsOperator    AssertionOperator = "contains"
+	ExistsOperator      AssertionOperator = "exists"
+	LessThanOperator    AssertionOperator = "<"
+	LessOrEqualOperator AssertionOperator = "<="
+)
+
+var assertionOperators = map[AssertionSubject][]AssertionOperator{
+	StatusAssertionSubject: {EqualsOperator, NotEqualsOperator},
+	HeaderAssertionSubject: {EqualsOperator, NotEqualsOperator, MatchesOperator, ContainsOperator, ExistsOperator},
+	JsonAssertionSubject:   {EqualsOperator, NotEqualsOperator, MatchesOperator, ContainsOperator, ExistsOperator},
+	BodyAssertionSubject:   {ContainsOperator, MatchesOperator},
+	TimeAssertionSubject:   {LessThanOperator, LessOrEqualOperator},
+}
+
+// Assertion checks the response of a request, it is written on its own line
+// of the request (e.g. "assert json $.id == 1")
+type Assertion struct {
+	Subject AssertionSubject
+	// Target is the header name or the JSON path
+	Target   string
+	Operator AssertionOperator
+	Expected string
+}
+
+func (a Assertion) String() string {
+	elements := []string{string(ASSERTION), string(a.Subject)}
+	if a.Target != "" {
+		elements = append(elements, a.Target)
+	}
+	elements = append(elements, string(a.Operator))
+	if a.Expected != "" {
+		elements = append(elements, a.Expected)
+	}
+	return strings.Join(elements, " ")
+}
+
+func (a Assertion) hasTarget() bool {
+	return a.Subject == HeaderAssertionSubject || a.Subject == JsonAssertionSubject
+}
+
+// ParseAssertion reads an assertion line: the subject, the header name or
+// JSON path for the header and json subjects, the operator then the expected
+// value, which can hold spaces
+func ParseAssertion(line string) (Assertion, error) {
+	rest, found := strings.CutPrefix(strings.TrimSpace(line), string(ASSERTION)+" ")
+	if !found {
+		return Assertion{}, fmt.Errorf("assertion %q must start with %q", line, ASSERTION)
+	}
+	nextWord := func() string {
+		rest = strings.TrimSpace(rest)
+		word, remaining, _ := strings.Cut(rest, " ")
+		rest = remaining
+		return word
+	}
+
+	assertion := Assertion{Subject: AssertionSubject(nextWord())}
+	operators, ok := assertionOperators[assertion.Subject]
+	if !ok {
+		return Assertion{}, fmt.Errorf("unknown assertion subject %q in %q", assertion.Subject, line)
+	}
+	if assertion.hasTarget() {
+		assertion.Target = nextWord()
+	}
+	assertion.Operator = AssertionOperator(nextWord())
+	assertion.Expected = strings.TrimSpace(rest)
+	if !slices.Contains(operators, assertion.Operator) {
+		return Assertion{}, fmt.Errorf("invalid operator %q for %v assertion %q, expected one of %v", assertion.Operator, assertion.Subject, line, operators)
+	}
+	if (assertion.Operator == ExistsOperator) != (assertion.Expected == "") {
+		return Assertion{}, fmt.Errorf("invalid expected value in %q", line)
+	}
+
+	var err error
+	switch {
+	case assertion.Subject == StatusAssertionSubject:
+		_, err = ParseStatusClass(assertion.Expected)
+	case assertion.Subject == TimeAssertionSubject:
+		_, err = time.ParseDuration(assertion.Expected)
+	case assertion.Subject == JsonAssertionSubject:
+		_, err = parseJsonPath(assertion.Target)
+	case assertion.Subject == HeaderAssertionSubject && assertion.Target == "":
+		err = errors.New("missing header name")
+	}
+	if err == nil && assertion.Operator == MatchesOperator {
+		_, err = regexp.Compile(assertion.Expected)
+	}
+	if err != nil {
+		return Assertion{}, fmt.Errorf("invalid assertion %q: %w", line, err)
+	}
+	return assertion, nil
+}
+
+// extractAssertions removes the assertion lines from the request input
+func extractAssertions(input string) (string, []Assertion, error) {
+	assertions := []Assertion{}
+	lines := []string{}
+	for _, line := range strings.Split(input, "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), string(ASSERTION)+" ") {
+			lines = append(lines, line)
+			continue
+		}
+		assertion, err := ParseAssertion(line)
+		if err != nil {
+			return input, nil, err
+		}
+		assertions = append(assertions, assertion)
+	}
+	return strings.Join(lines, "\n"), assertions, nil
+}
+
+type AssertionResult struct {
+	Assertion
+	Passed bool
+	// Actual describes the checked value when the assertion failed
+	Actual string
+}
+
+func (r AssertionResult) String() string {
+	if r.Passed {
+		return "PASS " + r.Assertion.String()
+	}
+	return fmt.Sprintf("FAIL %v (got %v)", r.Assertion, r.Actual)
+}
+
+// checkedResponse holds what the assertions check and the captures extract,
+// the body being unmarshalled once for all the json ones
+type checkedResponse struct {
+	statusCode int
+	header     http.Header
+	body       []byte
+	duration   time.Duration
+	json       any
+	jsonErr    error
+}
+
+func (r *checkedResponse) unmarshalledJson() (any, error) {
+	if r.json == nil && r.jsonErr == nil {
+		r.jsonErr = json.Unmarshal(r.body, &r.json)
+	}
+	return r.json, r.jsonErr
+}
+
+func compareValue(actual string, operator AssertionOperator, expected string) bool {
+	switch operator {
+	case EqualsOperator:
+		return actual == expected
+	case NotEqualsOperator:
+		return actual != expected
+	case ContainsOperator:
+		return strings.Contains(actual, expected)
+	case MatchesOperator:
+		return regexp.MustCompile(expected).MatchString(actual)
+	}
+	return false
+}
+
+// jsonEquals compares the JSON value with the expected one written in JSON,
+// or as a bare string
+func jsonEquals(actual any, expected string) bool {
+	var expectedValue any
+	if err := json.Unmarshal([]byte(expected), &expectedValue); err != nil {
+		expectedValue = expected
+	}
+	return reflect.DeepEqual(actual, expectedValue)
+}
+
+func jsonString(value any) string {
+	if text, ok := value.(string); ok {
+		return text
+	}
+	encoded, _ := json.Marshal(value)
+	return string(encoded)
+}
+
+func (a Assertion) check(response *checkedResponse) AssertionResult {
+	result := AssertionResult{Assertion: a}
+	switch a.Subject {
+	case StatusAssertionSubject:
+		statusClass, _ := ParseStatusClass(a.Expected)
+		result.Actual = strconv.Itoa(response.statusCode)
+		result.Passed = statusClass.Matches(response.statusCode) == (a.Operator == EqualsOperator)
+	case HeaderAssertionSubject:
+		values, ok := response.header[http.CanonicalHeaderKey(a.Target)]
+		result.Actual = "no header"
+		if ok {
+			result.Actual = strings.Join(values, ", ")
+		}
+		result.Passed = ok && (a.Operator == ExistsOperator || compareValue(result.Actual, a.Operator, a.Expected))
+		if !ok && a.Operator == NotEqualsOperator {
+			result.Passed = true
+		}
+	case JsonAssertionSubject:
+		document, err := response.unmarshalledJson()
+		if err != nil {
+			result.Actual = fmt.Sprintf("invalid JSON body: %v", err)
+			return result
+		}
+		value, err := evaluateJsonPath(document, a.Target)
+		if err != nil {
+			result.Actual = err.Error()
+			result.Passed = a.Operator == NotEqualsOperator
+			return result
+		}
+		result.Actual = jsonString(value)
+		switch a.Operator {
+		case ExistsOperator:
+			result.Passed = true
+		case EqualsOperator:
+			result.Passed = jsonEquals(value, a.Expected)
+		case NotEqualsOperator:
+			result.Passed = !jsonEquals(value, a.Expected)
+		default:
+			result.Passed = compareValue(result.Actual, a.Operator, a.Expected)
+		}
+	case BodyAssertionSubject:
+		result.Actual = fmt.Sprintf("a body of %v bytes", len(response.body))
+		result.Passed = compareValue(string(response.body), a.Operator, a.Expected)
+	case TimeAssertionSubject:
+		maxDuration, _ := time.ParseDuration(a.Expected)
+		result.Actual = response.duration.String()
+		result.Passed = response.duration < maxDuration || (a.Operator == LessOrEqualOperator && response.duration == maxDuration)
+	}
+	return result
+}
+
+// newCheckedResponse reads the response body, the body being readable again
+// afterwards
+func newCheckedResponse(response *http.Response, timing Timing) (*checkedResponse, error) {
+	body := []byte{}
+	if response.Body != nil {
+		var err error
+		body, err = io.ReadAll(response.Body)
+		response.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("response body reading error: %w", err)
+		}
+		response.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	return &checkedResponse{
+		statusCode: response.StatusCode,
+		header:     response.Header,
+		body:       body,
+		duration:   timing.Total,
+	}, nil
+}
+
+func checkAssertions(assertions []Assertion, response *checkedResponse) []AssertionResult {
+	results := []AssertionResult{}
+	for _, assertion := range assertions {
+		results = append(results, assertion.check(response))
+	}
+	return results
+}
+
+// AssertionsPassed is true when all the assertions of the request passed
+func (r RequestAndResponse) AssertionsPassed() bool {
+	return !slices.ContainsFunc(r.Assertions, func(result AssertionResult) bool { return !result.Passed })
+}
@@ -0,0 +1,276 @@
+package app
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type AuthScheme string
+
+const (
+	BasicAuthScheme  AuthScheme = "basic"
+	BearerAuthScheme AuthScheme = "bearer"
+	ApiKeyAuthScheme AuthScheme = "apikey"
+	DigestAuthScheme AuthScheme = "digest"
+	OAuth2AuthScheme AuthScheme = "oauth2"
+)
+
+type ApiKeyLocation string
+
+const (
+	HeaderApiKeyLocation ApiKeyLocation = "header"
+	QueryApiKeyLocation  ApiKeyLocation = "query"
+)
+
+// Auth authenticates the request, it is written on its own line of the
+// request (e.g. "auth basic gopher {{.password}}")
+type Auth struct {
+	Scheme AuthScheme
+	// Username and Password are the basic and digest credentials
+	Username string
+	Password string
+	// Token is the bearer token or the API key
+	Token string
+	// KeyLocation and KeyName locate the API key, in a header or a search param
+	KeyLocation ApiKeyLocation
+	KeyName     string
+	// Configuration names the OAuth2 configuration the token is acquired with
+	Configuration string
+}
+
+// quoteAuthValue quotes the values which would be split otherwise
+func quoteAuthValue(value string) string {
+	if value == "" || strings.ContainsAny(value, " \t\r\n\"") {
+		return strconv.Quote(value)
+	}
+	return value
+}
+
+func (a Auth) String() string {
+	elements := []string{string(AUTH), string(a.Scheme)}
+	switch a.Scheme {
+	case BasicAuthScheme, DigestAuthScheme:
+		elements = append(elements, quoteAuthValue(a.Username), quoteAuthValue(a.Password))
+	case BearerAuthScheme:
+		elements = append(elements, quoteAuthValue(a.Token))
+	case ApiKeyAuthScheme:
+		elements = append(elements, string(a.KeyLocation), quoteAuthValue(a.KeyName), quoteAuthValue(a.Token))
+	case OAuth2AuthScheme:
+		elements = append(elements, quoteAuthValue(a.Configuration))
+	}
+	return strings.Join(elements, " ")
+}
+
+// authValues splits the values of an auth line, the double quoted ones
+// being unquoted
+func authValues(input string) ([]string, error) {
+	values := []string{}
+	for _, element := range splitRequestInput(strings.TrimSpace(input)) {
+		if element == "" {
+			continue
+		}
+		if strings.HasPrefix(element, `"`) {
+			unquoted, err := strconv.Unquote(element)
+			if err != nil {
+				return nil, fmt.Errorf("invalid quoted value %v", element)
+			}
+			element = unquoted
+		}
+		values = append(values, element)
+	}
+	return values, nil
+}
+
+// ParseAuth reads an auth line: the scheme then its credentials, i.e. a
+// username and a password, a bearer token, the location (header or query),
+// name and value of an API key, or the name of an OAuth2 configuration
+func ParseAuth(line string) (Auth, error) {
+	rest, found := strings.CutPrefix(strings.TrimSpace(line), string(AUTH)+" ")
+	if !found {
+		return Auth{}, fmt.Errorf("auth %q must start with %q", line, AUTH)
+	}
+	values, err := authValues(rest)
+	if err != nil {
+		return Auth{}, fmt.Errorf("invalid auth %q: %w", line, err)
+	}
+	if len(values) == 0 {
+		return Auth{}, fmt.Errorf("missing scheme in auth %q", line)
+	}
+	auth := Auth{Scheme: AuthScheme(strings.ToLower(values[0]))}
+	credentials := values[1:]
+	expectedCredentials := map[AuthScheme]int{BasicAuthScheme: 2, DigestAuthScheme: 2, BearerAuthScheme: 1, ApiKeyAuthScheme: 3, OAuth2AuthScheme: 1}[auth.Scheme]
+	if expectedCredentials == 0 {
+		return Auth{}, fmt.Errorf("unknown auth scheme %q in %q, expected basic, bearer, apikey, digest or oauth2", values[0], line)
+	}
+	if len(credentials) != expectedCredentials {
+		return Auth{}, fmt.Errorf("invalid %v auth %q, expected %v values after the scheme", auth.Scheme, line, expectedCredentials)
+	}
+	switch auth.Scheme {
+	case BasicAuthScheme, DigestAuthScheme:
+		auth.Username, auth.Password = credentials[0], credentials[1]
+	case BearerAuthScheme:
+		auth.Token = credentials[0]
+	case OAuth2AuthScheme:
+		auth.Configuration = credentials[0]
+	case ApiKeyAuthScheme:
+		auth.KeyLocation, auth.KeyName, auth.Token = ApiKeyLocation(strings.ToLower(credentials[0])), credentials[1], credentials[2]
+		if auth.KeyLocation != HeaderApiKeyLocation && auth.KeyLocation != QueryApiKeyLocation {
+			return Auth{}, fmt.Errorf("invalid API key location %q in %q, expected header or query", credentials[0], line)
+		}
+	}
+	return auth, nil
+}
+
+// extractAuth removes the auth line from the request input, a request being
+// authenticated once at most
+func extractAuth(input string) (string, *Auth, error) {
+	var auth *Auth
+	lines := []string{}
+	for _, line := range strings.Split(input, "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), string(AUTH)+" ") {
+			lines = append(lines, line)
+			continue
+		}
+		if auth != nil {
+			return input, nil, errors.New("a request can only have one auth line")
+		}
+		parsedAuth, err := ParseAuth(line)
+		if err != nil {
+			return input, nil, err
+		}
+		auth = &parsedAuth
+	}
+	return strings.Join(lines, "\n"), auth, nil
+}
+
+// apply authenticates the request, except for digest auth which needs the
+// challenge of the server and OAuth2 which needs a token
+func (a Auth) apply(req *http.Request) {
+	switch a.Scheme {
+	case BasicAuthScheme:
+		req.SetBasicAuth(a.Username, a.Password)
+	case BearerAuthScheme:
+		req.Header.Set("Authorization", "Bearer "+a.Token)
+	case ApiKeyAuthScheme:
+		if a.KeyLocation == HeaderApiKeyLocation {
+			req.Header.Set(a.KeyName, a.Token)
+			return
+		}
+		query := req.URL.Query()
+		query.Set(a.KeyName, a.Token)
+		req.URL.RawQuery = query.Encode()
+	}
+}
+
+// parseDigestChallenge reads the parameters of the Digest challenge of a
+// WWW-Authenticate header, the quoted values holding commas
+func parseDigestChallenge(header http.Header) (map[string]string, bool) {
+	for _, challenge := range header.Values("WWW-Authenticate") {
+		scheme, rest, _ := strings.Cut(strings.TrimSpace(challenge), " ")
+		if !strings.EqualFold(scheme, "Digest") {
+			continue
+		}
+		parameters := map[string]string{}
+		for rest = strings.TrimSpace(rest); rest != ""; rest = strings.TrimLeft(rest, ", ") {
+			name, value, found := strings.Cut(rest, "=")
+			if !found {
+				break
+			}
+			name = strings.ToLower(strings.TrimSpace(name))
+			value = strings.TrimSpace(value)
+			if strings.HasPrefix(value, `"`) {
+				end := 1
+				for end < len(value) && value[end] != '"' {
+					if value[end] == '\\' {
+						end++
+					}
+					end++
+				}
+				parameters[name] = strings.ReplaceAll(value[1:min(end, len(value))], `\`, "")
+				rest = value[min(end+1, len(value)):]
+				continue
+			}
+			parameters[name], rest, _ = strings.Cut(value, ",")
+			parameters[name] = strings.TrimSpace(parameters[name])
+		}
+		return parameters, true
+	}
+	return nil, false
+}
+
+// digestAuthorization answers the digest challenge (RFC 7616) with the
+// credentials, for the MD5 and SHA-256 algorithms and their session variants
+func (a Auth) digestAuthorization(req *http.Request, challenge map[string]string) (string, error) {
+	algorithm := challenge["algorithm"]
+	if algorithm == "" {
+		algorithm = "MD5"
+	}
+	var newHash func() hash.Hash
+	switch strings.TrimSuffix(strings.ToUpper(algorithm), "-SESS") {
+	case "MD5":
+		newHash = md5.New
+	case "SHA-256":
+		newHash = sha256.New
+	default:
+		return "", fmt.Errorf("unsupported digest algorithm %q", algorithm)
+	}
+	digest := func(values ...string) string {
+		h := newHash()
+		h.Write([]byte(strings.Join(values, ":")))
+		return hex.EncodeToString(h.Sum(nil))
+	}
+
+	cnonceBytes := make([]byte, 16)
+	if _, err := rand.Read(cnonceBytes); err != nil {
+		return "", fmt.Errorf("digest cnonce generation error: %w", err)
+	}
+	cnonce := hex.EncodeToString(cnonceBytes)
+	nonce := challenge["nonce"]
+	uri := req.URL.RequestURI()
+	nonceCount := "00000001"
+
+	ha1 := digest(a.Username, challenge["realm"], a.Password)
+	if strings.HasSuffix(strings.ToUpper(algorithm), "-SESS") {
+		ha1 = digest(ha1, nonce, cnonce)
+	}
+	ha2 := digest(req.Method, uri)
+	qop := ""
+	for _, offered := range strings.Split(challenge["qop"], ",") {
+		if strings.TrimSpace(offered) == "auth" {
+			qop = "auth"
+		}
+	}
+	if challenge["qop"] != "" && qop == "" {
+		return "", fmt.Errorf("unsupported digest qop %q", challenge["qop"])
+	}
+
+	parameters := []string{
+		fmt.Sprintf("username=%q", a.Username),
+		fmt.Sprintf("realm=%q", challenge["realm"]),
+		fmt.Sprintf("nonce=%q", nonce),
+		fmt.Sprintf("uri=%q", uri),
+		"algorithm=" + algorithm,
+	}
+	if qop == "" {
+		parameters = append(parameters, fmt.Sprintf("response=%q", digest(ha1, nonce, ha2)))
+	} else {
+		parameters = append(parameters,
+			"qop="+qop,
+			"nc="+nonceCount,
+			fmt.Sprintf("cnonce=%q", cnonce),
+			fmt.Sprintf("response=%q", digest(ha1, nonce, nonceCount, cnonce, qop, ha2)),
+		)
+	}
+	if opaque, ok := challenge["opaque"]; ok {
+		parameters = append(parameters, fmt.Sprintf("opaque=%q", opaque))
+	}
+	return "Digest " + strings.Join(parameters, ", "), nil
+}
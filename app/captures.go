@@ -0,0 +1,168 @@
+package app
+
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var captureVariableName = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// Capture stores a value of the response in a variable available to the
+// next requests, it is written on its own line of the request (e.g.
+// "capture token json $.access_token")
+type Capture struct {
+	Variable string
+	// Subject is one of status, header, json or body
+	Subject AssertionSubject
+	// Target is the header name or the JSON path
+	Target string
+}
+
+func (c Capture) String() string {
+	elements := []string{string(CAPTURE), c.Variable, string(c.Subject)}
+	if c.Target != "" {
+		elements = append(elements, c.Target)
+	}
+	return strings.Join(elements, " ")
+}
+
+// ParseCapture reads a capture line: the variable name, the subject then the
+// header name or JSON path for the header and json subjects
+func ParseCapture(line string) (Capture, error) {
+	rest, found := strings.CutPrefix(strings.TrimSpace(line), string(CAPTURE)+" ")
+	if !found {
+		return Capture{}, fmt.Errorf("capture %q must start with %q", line, CAPTURE)
+	}
+	fields := strings.Fields(rest)
+	if len(fields) < 2 {
+		return Capture{}, fmt.Errorf("capture %q must have a variable name and a subject", line)
+	}
+	capture := Capture{Variable: fields[0], Subject: AssertionSubject(fields[1])}
+	if !captureVariableName.MatchString(capture.Variable) {
+		return Capture{}, fmt.Errorf("invalid variable name %q in capture %q", capture.Variable, line)
+	}
+
+	expectedFields := 2
+	switch capture.Subject {
+	case StatusAssertionSubject, BodyAssertionSubject:
+	case HeaderAssertionSubject, JsonAssertionSubject:
+		expectedFields = 3
+	default:
+		return Capture{}, fmt.Errorf("unknown capture subject %q in %q", capture.Subject, line)
+	}
+	if len(fields) != expectedFields {
+		return Capture{}, fmt.Errorf("invalid capture %q, expected %v elements after %q", line, expectedFields, CAPTURE)
+	}
+	if expectedFields == 3 {
+		capture.Target = fields[2]
+	}
+	if capture.Subject == JsonAssertionSubject {
+		_, err := parseJsonPath(capture.Target)
+		if err != nil {
+			return Capture{}, fmt.Errorf("invalid capture %q: %w", line, err)
+		}
+	}
+	return capture, nil
+}
+
+// extractCaptures removes the capture lines from the request input
+func extractCaptures(input string) (string, []Capture, error) {
+	captures := []Capture{}
+	lines := []string{}
+	for _, line := range strings.Split(input, "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), string(CAPTURE)+" ") {
+			lines = append(lines, line)
+			continue
+		}
+		capture, err := ParseCapture(line)
+		if err != nil {
+			return input, nil, err
+		}
+		captures = append(captures, capture)
+	}
+	return strings.Join(lines, "\n"), captures, nil
+}
+
+type CaptureResult struct {
+	Capture
+	Value string
+	// Err explains why no value was captured
+	Err error
+}
+
+func (r CaptureResult) String() string {
+	if r.Err != nil {
+		return fmt.Sprintf("%v not captured: %v", r.Variable, r.Err)
+	}
+	return fmt.Sprintf("%v captured: %v", r.Variable, r.Value)
+}
+
+func (c Capture) extract(response *checkedResponse) CaptureResult {
+	result := CaptureResult{Capture: c}
+	switch c.Subject {
+	case StatusAssertionSubject:
+		result.Value = strconv.Itoa(response.statusCode)
+	case HeaderAssertionSubject:
+		values, ok := response.header[http.CanonicalHeaderKey(c.Target)]
+		if !ok {
+			result.Err = fmt.Errorf("no %v header", c.Target)
+			return result
+		}
+		result.Value = strings.Join(values, ", ")
+	case JsonAssertionSubject:
+		document, err := response.unmarshalledJson()
+		if err != nil {
+			result.Err = fmt.Errorf("invalid JSON body: %w", err)
+			return result
+		}
+		value, err := evaluateJsonPath(document, c.Target)
+		if err != nil {
+			result.Err = err
+			return result
+		}
+		result.Value = jsonString(value)
+	case BodyAssertionSubject:
+		result.Value = string(response.body)
+	}
+	return result
+}
+
+func captureVariables(captures []Capture, response *checkedResponse) []CaptureResult {
+	results := []CaptureResult{}
+	for _, capture := range captures {
+		results = append(results, capture.extract(response))
+	}
+	return results
+}
+
+// CapturedVariables are the values captured by the requests executed so far,
+// they are merged in the template data
+func (g Gogetter) CapturedVariables() Variables { return g.capturedVariables }
+
+func (g Gogetter) storeCapturedVariables(results []CaptureResult) Gogetter {
+	capturedVariables := Variables{}
+	maps.Copy(capturedVariables, g.capturedVariables)
+	for _, result := range results {
+		if result.Err == nil {
+			capturedVariables[result.Variable] = result.Value
+		}
+	}
+	g.capturedVariables = capturedVariables
+	return g
+}
+
+// ClearCapturedVariables forgets the captured values, e.g. to start a new run
+func (g Gogetter) ClearCapturedVariables() Gogetter {
+	g.capturedVariables = nil
+	return g
+}
+
+// CapturesSucceeded is true when all the captures of the request got a value
+func (r RequestAndResponse) CapturesSucceeded() bool {
+	return !slices.ContainsFunc(r.Captures, func(result CaptureResult) bool { return result.Err != nil })
+}
@@ -0,0 +1,304 @@
+package app
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Redirects is the redirect policy: "follow" (up to 10 redirects), "never"
+// or the maximum number of redirects
+type Redirects string
+
+const (
+	FollowRedirects Redirects = "follow"
+	NeverRedirect   Redirects = "never"
+	// maxRedirects is the number of redirects followed by FollowRedirects,
+	// the limit of the default client
+	maxRedirects = 10
+)
+
+func ParseRedirects(value string) (Redirects, error) {
+	switch Redirects(value) {
+	case FollowRedirects, NeverRedirect:
+		return Redirects(value), nil
+	}
+	if count, err := strconv.Atoi(value); err == nil && count > 0 {
+		return Redirects(value), nil
+	}
+	return "", fmt.Errorf("invalid redirects %q, expected follow, never or a maximum number of redirects", value)
+}
+
+// max is the number of redirects followed, the default policy following them
+func (r Redirects) max() int {
+	if r == NeverRedirect {
+		return 0
+	}
+	if count, err := strconv.Atoi(string(r)); err == nil {
+		return count
+	}
+	return maxRedirects
+}
+
+// NoProxy disables the proxy, including the one of the HTTP_PROXY, HTTPS_PROXY
+// and NO_PROXY environment variables
+const NoProxy = "off"
+
+func validateProxy(proxy string) error {
+	if proxy == "" || proxy == NoProxy {
+		return nil
+	}
+	proxyUrl, err := url.Parse(proxy)
+	if err != nil || proxyUrl.Host == "" {
+		return fmt.Errorf("invalid proxy %q, expected an url or %v", proxy, NoProxy)
+	}
+	switch proxyUrl.Scheme {
+	case "http", "https", "socks5":
+		return nil
+	}
+	return fmt.Errorf("unsupported proxy scheme %q, expected http, https or socks5", proxyUrl.Scheme)
+}
+
+// ClientSettings configure how the requests are sent, the zero value being
+// the behavior of http.DefaultClient
+type ClientSettings struct {
+	// Timeout bounds the time waited for the response headers and body, zero
+	// waiting forever
+	Timeout   time.Duration
+	Redirects Redirects
+	// Proxy is the url of an http, https or socks5 proxy, or NoProxy. The
+	// proxy of the environment variables is used when empty.
+	Proxy string
+	// CaBundle is a PEM file of the certificate authorities trusted along the
+	// ones of the system
+	CaBundle string
+	// ClientCertificate and ClientKey are the PEM files of the certificate
+	// sent for mutual TLS
+	ClientCertificate string
+	ClientKey         string
+	Insecure          bool
+}
+
+func (s ClientSettings) validate() error {
+	if s.Timeout < 0 {
+		return fmt.Errorf("invalid negative timeout %v", s.Timeout)
+	}
+	if s.Redirects != "" {
+		if _, err := ParseRedirects(string(s.Redirects)); err != nil {
+			return err
+		}
+	}
+	if (s.ClientCertificate == "") != (s.ClientKey == "") {
+		return errors.New("a client certificate needs its key")
+	}
+	return validateProxy(s.Proxy)
+}
+
+// override replaces the settings with the ones set in other
+func (s ClientSettings) override(other ClientSettings) ClientSettings {
+	if other.Timeout != 0 {
+		s.Timeout = other.Timeout
+	}
+	if other.Redirects != "" {
+		s.Redirects = other.Redirects
+	}
+	if other.Proxy != "" {
+		s.Proxy = other.Proxy
+	}
+	if other.CaBundle != "" {
+		s.CaBundle = other.CaBundle
+	}
+	if other.ClientCertificate != "" {
+		s.ClientCertificate, s.ClientKey = other.ClientCertificate, other.ClientKey
+	}
+	s.Insecure = s.Insecure || other.Insecure
+	return s
+}
+
+func (g Gogetter) ClientSettings() ClientSettings { return g.clientSettings }
+
+// OverrideClientSettings replaces the client settings with the ones set, e.g.
+// with the flags of the command line
+func (g Gogetter) OverrideClientSettings(settings ClientSettings) (Gogetter, error) {
+	err := settings.validate()
+	if err != nil {
+		return g, err
+	}
+	g.clientSettings = g.clientSettings.override(settings)
+	return g, nil
+}
+
+type clientSettingsKey struct{}
+
+func requestClientSettings(req *http.Request) ClientSettings {
+	settings, _ := req.Context().Value(clientSettingsKey{}).(ClientSettings)
+	return settings
+}
+
+// cancelOnClose releases the timeout of the request once its response body is
+// closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c cancelOnClose) Close() error {
+	defer c.cancel()
+	return c.ReadCloser.Close()
+}
+
+// do sends the request with the client settings of the gogetter overridden by
+// the options of the request, the client built by NewHttpClient reading them
+// from the context of the request
+func (g Gogetter) do(req *http.Request, options RequestOptions) (*http.Response, error) {
+	settings := options.clientSettings(g.clientSettings)
+	ctx := context.WithValue(req.Context(), clientSettingsKey{}, settings)
+	cancel := context.CancelFunc(func() {})
+	if settings.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, settings.Timeout)
+	}
+	response, err := g.client.Do(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, fmt.Errorf("no response within %v: %w", settings.Timeout, err)
+		}
+		return nil, err
+	}
+	response.Body = cancelOnClose{ReadCloser: response.Body, cancel: cancel}
+	return response, nil
+}
+
+// transportKey identifies the TLS settings of a transport
+type transportKey struct {
+	caBundle          string
+	clientCertificate string
+	clientKey         string
+	insecure          bool
+}
+
+// settingsTransport sends the requests with a transport per TLS settings,
+// the connections being reused between the requests sharing them
+type settingsTransport struct {
+	mutex      sync.Mutex
+	transports map[transportKey]*http.Transport
+}
+
+func (t *settingsTransport) transport(settings ClientSettings) (*http.Transport, error) {
+	key := transportKey{settings.CaBundle, settings.ClientCertificate, settings.ClientKey, settings.Insecure}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if transport, ok := t.transports[key]; ok {
+		return transport, nil
+	}
+	tlsConfig := &tls.Config{InsecureSkipVerify: settings.Insecure}
+	if settings.CaBundle != "" {
+		pem, err := os.ReadFile(settings.CaBundle)
+		if err != nil {
+			return nil, fmt.Errorf("ca bundle reading error: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in the ca bundle %v", settings.CaBundle)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if settings.ClientCertificate != "" {
+		certificate, err := tls.LoadX509KeyPair(settings.ClientCertificate, settings.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("client certificate loading error: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	transport.Proxy = proxy
+	t.transports[key] = transport
+	return transport, nil
+}
+
+func (t *settingsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	transport, err := t.transport(requestClientSettings(req))
+	if err != nil {
+		return nil, err
+	}
+	return transport.RoundTrip(req)
+}
+
+// proxy is the proxy of the settings of the request, or the one of the
+// environment variables
+func proxy(req *http.Request) (*url.URL, error) {
+	settings := requestClientSettings(req)
+	switch settings.Proxy {
+	case "":
+		return http.ProxyFromEnvironment(req)
+	case NoProxy:
+		return nil, nil
+	}
+	return url.Parse(settings.Proxy)
+}
+
+// NewHttpClient returns the client applying the client settings of the
+// gogetter and of the requests, with the cookie jar redirect policy
+func NewHttpClient() *http.Client {
+	return &http.Client{
+		Transport:     &settingsTransport{transports: map[transportKey]*http.Transport{}},
+		CheckRedirect: CheckRedirect,
+	}
+}
+
+type clientSettingsDto struct {
+	Timeout           string `json:"timeout"`
+	Redirects         string `json:"redirects"`
+	Proxy             string `json:"proxy"`
+	CaBundle          string `json:"ca_bundle"`
+	ClientCertificate string `json:"client_certificate"`
+	ClientKey         string `json:"client_key"`
+	Insecure          bool   `json:"insecure"`
+}
+
+// WithClientSettings reads the client settings, e.g.
+// {"timeout": "30s", "redirects": "never", "proxy": "socks5://localhost:1080"}
+type WithClientSettings struct {
+	Settings io.Reader
+}
+
+func (w WithClientSettings) Apply(g Gogetter) (Gogetter, error) {
+	dto := clientSettingsDto{}
+	err := readJson(w.Settings, &dto)
+	if err != nil {
+		return g, fmt.Errorf("client settings reading error: %w", err)
+	}
+	settings := ClientSettings{
+		Redirects:         Redirects(dto.Redirects),
+		Proxy:             dto.Proxy,
+		CaBundle:          dto.CaBundle,
+		ClientCertificate: dto.ClientCertificate,
+		ClientKey:         dto.ClientKey,
+		Insecure:          dto.Insecure,
+	}
+	if dto.Timeout != "" {
+		settings.Timeout, err = time.ParseDuration(dto.Timeout)
+		if err != nil {
+			return g, fmt.Errorf("invalid client settings timeout %q: %w", dto.Timeout, err)
+		}
+	}
+	err = settings.validate()
+	if err != nil {
+		return g, fmt.Errorf("invalid client settings: %w", err)
+	}
+	g.clientSettings = settings
+	return g, nil
+}
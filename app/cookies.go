@@ -0,0 +1,336 @@
+package app
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cookie is a cookie stored in the jar
+type Cookie struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Domain string `json:"domain"`
+	Path   string `json:"path"`
+	// HostOnly cookies are only sent to their domain, not to its subdomains
+	HostOnly bool `json:"host_only,omitempty"`
+	Secure   bool `json:"secure,omitempty"`
+	HttpOnly bool `json:"http_only,omitempty"`
+	// Expires is zero for the session cookies, which are kept until deleted
+	Expires time.Time `json:"expires"`
+}
+
+func (c Cookie) String() string {
+	return fmt.Sprintf("%v=%v", c.Name, c.Value)
+}
+
+func (c Cookie) Expired(now time.Time) bool {
+	return !c.Expires.IsZero() && !c.Expires.After(now)
+}
+
+// sameCookie is true when the cookies share their name, domain and path, the
+// new one replacing the other
+func (c Cookie) sameCookie(other Cookie) bool {
+	return c.Name == other.Name && c.Domain == other.Domain && c.Path == other.Path
+}
+
+// domainMatches follows RFC 6265 5.1.3, the host only cookies matching their
+// exact domain
+func (c Cookie) domainMatches(host string) bool {
+	if c.HostOnly || net.ParseIP(host) != nil {
+		return host == c.Domain
+	}
+	return host == c.Domain || strings.HasSuffix(host, "."+c.Domain)
+}
+
+// pathMatches follows RFC 6265 5.1.4
+func (c Cookie) pathMatches(path string) bool {
+	if path == "" {
+		path = "/"
+	}
+	if path == c.Path {
+		return true
+	}
+	return strings.HasPrefix(path, c.Path) && (strings.HasSuffix(c.Path, "/") || path[len(c.Path)] == '/')
+}
+
+// defaultCookiePath is the directory of the request path (RFC 6265 5.1.4)
+func defaultCookiePath(path string) string {
+	if !strings.HasPrefix(path, "/") {
+		return "/"
+	}
+	index := strings.LastIndex(path, "/")
+	if index == 0 {
+		return "/"
+	}
+	return path[:index]
+}
+
+// newCookie stores the Set-Cookie of a response to the url, the cookies for
+// another domain or for a top-level domain being rejected
+func newCookie(u *url.URL, setCookie *http.Cookie, now time.Time) (Cookie, bool) {
+	host := strings.ToLower(u.Hostname())
+	cookie := Cookie{
+		Name:     setCookie.Name,
+		Value:    setCookie.Value,
+		Domain:   strings.TrimPrefix(strings.ToLower(setCookie.Domain), "."),
+		Path:     setCookie.Path,
+		Secure:   setCookie.Secure,
+		HttpOnly: setCookie.HttpOnly,
+	}
+	if cookie.Domain == "" || cookie.Domain == host {
+		cookie.Domain, cookie.HostOnly = host, setCookie.Domain == ""
+	} else if !strings.Contains(cookie.Domain, ".") || !cookie.domainMatches(host) {
+		return Cookie{}, false
+	}
+	if !strings.HasPrefix(cookie.Path, "/") {
+		cookie.Path = defaultCookiePath(u.Path)
+	}
+	switch {
+	case setCookie.MaxAge < 0:
+		cookie.Expires = now
+	case setCookie.MaxAge > 0:
+		cookie.Expires = now.Add(time.Duration(setCookie.MaxAge) * time.Second)
+	case !setCookie.Expires.IsZero():
+		cookie.Expires = setCookie.Expires
+	}
+	return cookie, true
+}
+
+// CookieJar stores the cookies per environment. It is shared by the copies of
+// the gogetter, the cookies being stored while executing requests.
+type CookieJar struct {
+	mutex      sync.Mutex
+	cookies    map[string][]Cookie
+	savingFunc func([]byte) error
+}
+
+// saveLocked writes the cookies, the mutex being held
+func (j *CookieJar) saveLocked() error {
+	if j.savingFunc == nil {
+		return nil
+	}
+	content, err := json.MarshalIndent(j.cookies, "", "  ")
+	if err != nil {
+		return fmt.Errorf("cookies marshal error: %w", err)
+	}
+	err = j.savingFunc(content)
+	if err != nil {
+		return fmt.Errorf("cookies writing error: %w", err)
+	}
+	return nil
+}
+
+// store replaces the cookies sharing their name, domain and path, the
+// expired ones being removed
+func (j *CookieJar) store(environment string, cookies []Cookie, now time.Time) error {
+	if len(cookies) == 0 {
+		return nil
+	}
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	stored := j.cookies[environment]
+	for _, cookie := range cookies {
+		stored = slices.DeleteFunc(stored, func(existing Cookie) bool { return existing.sameCookie(cookie) })
+		if !cookie.Expired(now) {
+			stored = append(stored, cookie)
+		}
+	}
+	stored = slices.DeleteFunc(stored, func(existing Cookie) bool { return existing.Expired(now) })
+	j.cookies[environment] = stored
+	return j.saveLocked()
+}
+
+func (j *CookieJar) setCookies(environment string, u *url.URL, setCookies []*http.Cookie) error {
+	now := time.Now()
+	cookies := []Cookie{}
+	for _, setCookie := range setCookies {
+		if cookie, ok := newCookie(u, setCookie, now); ok {
+			cookies = append(cookies, cookie)
+		}
+	}
+	return j.store(environment, cookies, now)
+}
+
+// cookiesFor returns the cookies to send to the url, the most specific paths
+// first
+func (j *CookieJar) cookiesFor(environment string, u *url.URL) []*http.Cookie {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	now := time.Now()
+	host := strings.ToLower(u.Hostname())
+	matching := []Cookie{}
+	for _, cookie := range j.cookies[environment] {
+		if cookie.Expired(now) || !cookie.domainMatches(host) || !cookie.pathMatches(u.Path) || (cookie.Secure && u.Scheme != "https") {
+			continue
+		}
+		matching = append(matching, cookie)
+	}
+	slices.SortStableFunc(matching, func(a, b Cookie) int { return cmp.Compare(len(b.Path), len(a.Path)) })
+	cookies := []*http.Cookie{}
+	for _, cookie := range matching {
+		cookies = append(cookies, &http.Cookie{Name: cookie.Name, Value: cookie.Value})
+	}
+	return cookies
+}
+
+func (g Gogetter) CookiesEnabled() bool { return g.cookiesEnabled && g.cookieJar != nil }
+
+// EnableCookies sends the cookies of the jar and stores the ones of the
+// responses, for the requests without the "option cookies off" line
+func (g Gogetter) EnableCookies(enabled bool) Gogetter {
+	g.cookiesEnabled = enabled
+	return g
+}
+
+// Cookies lists the cookies of the active environment by domain, path and name
+func (g Gogetter) Cookies() []Cookie {
+	if g.cookieJar == nil {
+		return []Cookie{}
+	}
+	g.cookieJar.mutex.Lock()
+	defer g.cookieJar.mutex.Unlock()
+	now := time.Now()
+	cookies := slices.DeleteFunc(slices.Clone(g.cookieJar.cookies[g.activeEnvironment]), func(cookie Cookie) bool { return cookie.Expired(now) })
+	slices.SortFunc(cookies, func(a, b Cookie) int {
+		return cmp.Or(cmp.Compare(a.Domain, b.Domain), cmp.Compare(a.Path, b.Path), cmp.Compare(a.Name, b.Name))
+	})
+	return cookies
+}
+
+// SaveCookie replaces the previous cookie with the edited one in the active
+// environment
+func (g Gogetter) SaveCookie(previous Cookie, cookie Cookie) (Gogetter, error) {
+	if g.cookieJar == nil {
+		return g, errors.New("no cookie jar")
+	}
+	cookie.Domain = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(cookie.Domain)), ".")
+	if cookie.Name == "" || cookie.Domain == "" {
+		return g, errors.New("a cookie needs a name and a domain")
+	}
+	if !strings.HasPrefix(cookie.Path, "/") {
+		cookie.Path = "/"
+	}
+	g, err := g.RemoveCookie(previous)
+	if err != nil {
+		return g, err
+	}
+	return g, g.cookieJar.store(g.activeEnvironment, []Cookie{cookie}, time.Now())
+}
+
+// RemoveCookie deletes the cookie from the active environment
+func (g Gogetter) RemoveCookie(cookie Cookie) (Gogetter, error) {
+	if g.cookieJar == nil {
+		return g, errors.New("no cookie jar")
+	}
+	g.cookieJar.mutex.Lock()
+	defer g.cookieJar.mutex.Unlock()
+	g.cookieJar.cookies[g.activeEnvironment] = slices.DeleteFunc(g.cookieJar.cookies[g.activeEnvironment], cookie.sameCookie)
+	return g, g.cookieJar.saveLocked()
+}
+
+type cookieScopeKey struct{}
+
+// cookieScope is the jar and environment of a request, carried by its context
+// to its redirections
+type cookieScope struct {
+	jar         *CookieJar
+	environment string
+	// explicitCookies are the ones of the Cookie header of the request, sent
+	// again to the redirect targets unless overridden by the jar
+	explicitCookies []*http.Cookie
+}
+
+// useCookieJar adds the cookies of the jar to the request, unless cookies are
+// disabled or skipped for this request
+func (g Gogetter) useCookieJar(req *http.Request, request Request) *http.Request {
+	if !g.CookiesEnabled() || request.Options.SkipCookies {
+		return req
+	}
+	explicitCookies := req.Cookies()
+	for _, cookie := range g.cookieJar.cookiesFor(g.activeEnvironment, req.URL) {
+		req.AddCookie(cookie)
+	}
+	return req.WithContext(context.WithValue(req.Context(), cookieScopeKey{}, cookieScope{jar: g.cookieJar, environment: g.activeEnvironment, explicitCookies: explicitCookies}))
+}
+
+// storeResponseCookies stores the cookies set by the final response, the
+// redirect responses ones being stored by CheckRedirect
+func storeResponseCookies(req *http.Request, response *http.Response) error {
+	scope, ok := req.Context().Value(cookieScopeKey{}).(cookieScope)
+	if !ok {
+		return nil
+	}
+	responseUrl := req.URL
+	if response.Request != nil {
+		responseUrl = response.Request.URL
+	}
+	return scope.jar.setCookies(scope.environment, responseUrl, response.Cookies())
+}
+
+// CheckRedirect is the redirect policy of the http.Client to use with the
+// cookie jar: the cookies set by the redirect responses are stored and the
+// ones of the jar are sent to the redirect target. It follows the redirects
+// of the client settings, 10 at most by default.
+func CheckRedirect(req *http.Request, via []*http.Request) error {
+	redirects := requestClientSettings(req).Redirects
+	if redirects == NeverRedirect {
+		return http.ErrUseLastResponse
+	}
+	if len(via) >= redirects.max() {
+		return fmt.Errorf("stopped after %v redirects", redirects.max())
+	}
+	scope, ok := req.Context().Value(cookieScopeKey{}).(cookieScope)
+	if !ok {
+		return nil
+	}
+	if req.Response != nil && req.Response.Request != nil {
+		err := scope.jar.setCookies(scope.environment, req.Response.Request.URL, req.Response.Cookies())
+		if err != nil {
+			return err
+		}
+	}
+	// the Cookie header is only forwarded by the client to the same domain
+	forwarded := req.Header.Get("Cookie") != ""
+	req.Header.Del("Cookie")
+	jarCookies := scope.jar.cookiesFor(scope.environment, req.URL)
+	if forwarded {
+		for _, cookie := range scope.explicitCookies {
+			overridden := slices.ContainsFunc(jarCookies, func(jarCookie *http.Cookie) bool { return jarCookie.Name == cookie.Name })
+			if !overridden {
+				req.AddCookie(cookie)
+			}
+		}
+	}
+	for _, cookie := range jarCookies {
+		req.AddCookie(cookie)
+	}
+	return nil
+}
+
+// WithCookieJar reads the cookies stored by the previous sessions, the jar
+// being used once enabled with EnableCookies
+type WithCookieJar struct {
+	Cookies           io.Reader
+	CookiesSavingFunc func([]byte) error
+}
+
+func (w WithCookieJar) Apply(g Gogetter) (Gogetter, error) {
+	cookies := map[string][]Cookie{}
+	err := readJson(w.Cookies, &cookies)
+	if err != nil {
+		return g, fmt.Errorf("cookies reading error: %w", err)
+	}
+	g.cookieJar = &CookieJar{cookies: cookies, savingFunc: w.CookiesSavingFunc}
+	return g, nil
+}
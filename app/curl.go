@@ -0,0 +1,383 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// splitShellWords splits a command line like a POSIX shell would, handling
+// single, double and ANSI-C ($'...') quotes as well as line continuations
+func splitShellWords(command string) ([]string, error) {
+	words := []string{}
+	current := strings.Builder{}
+	inWord := false
+	for position := 0; position < len(command); position++ {
+		char := command[position]
+		switch {
+		case char == '\\' && position+1 < len(command) && (command[position+1] == '\n' || command[position+1] == '\r'):
+			position++
+			if command[position] == '\r' && position+1 < len(command) && command[position+1] == '\n' {
+				position++
+			}
+		case char == '\\' && position+1 < len(command):
+			position++
+			current.WriteByte(command[position])
+			inWord = true
+		case char == '\'':
+			end := strings.IndexByte(command[position+1:], '\'')
+			if end == -1 {
+				return nil, errors.New("unterminated single quote")
+			}
+			current.WriteString(command[position+1 : position+1+end])
+			position += end + 1
+			inWord = true
+		case char == '$' && position+1 < len(command) && command[position+1] == '\'':
+			end, err := readAnsiCQuote(command[position+2:], &current)
+			if err != nil {
+				return nil, err
+			}
+			position += end + 2
+			inWord = true
+		case char == '"':
+			end, err := readDoubleQuote(command[position+1:], &current)
+			if err != nil {
+				return nil, err
+			}
+			position += end + 1
+			inWord = true
+		case char == ' ' || char == '\t' || char == '\n' || char == '\r':
+			if inWord {
+				words = append(words, current.String())
+				current.Reset()
+				inWord = false
+			}
+		default:
+			current.WriteByte(char)
+			inWord = true
+		}
+	}
+	if inWord {
+		words = append(words, current.String())
+	}
+	return words, nil
+}
+
+// readDoubleQuote writes the content of a double quoted string and returns the
+// index of its closing quote
+func readDoubleQuote(input string, builder *strings.Builder) (int, error) {
+	for position := 0; position < len(input); position++ {
+		char := input[position]
+		switch {
+		case char == '"':
+			return position, nil
+		case char == '\\' && position+1 < len(input) && strings.IndexByte("\"\\$`\n", input[position+1]) != -1:
+			position++
+			if input[position] != '\n' {
+				builder.WriteByte(input[position])
+			}
+		default:
+			builder.WriteByte(char)
+		}
+	}
+	return 0, errors.New("unterminated double quote")
+}
+
+var ansiCEscapes = map[byte]byte{'n': '\n', 't': '\t', 'r': '\r', '\\': '\\', '\'': '\'', '"': '"'}
+
+// readAnsiCQuote writes the content of a $'...' string and returns the index
+// of its closing quote
+func readAnsiCQuote(input string, builder *strings.Builder) (int, error) {
+	for position := 0; position < len(input); position++ {
+		char := input[position]
+		switch {
+		case char == '\'':
+			return position, nil
+		case char == '\\' && position+1 < len(input):
+			position++
+			if escaped, ok := ansiCEscapes[input[position]]; ok {
+				builder.WriteByte(escaped)
+			} else {
+				builder.WriteByte('\\')
+				builder.WriteByte(input[position])
+			}
+		default:
+			builder.WriteByte(char)
+		}
+	}
+	return 0, errors.New("unterminated ANSI-C quote")
+}
+
+type curlCommand struct {
+	method  string
+	url     string
+	headers []string
+	data    []string
+	form    []string
+	// formStrings are form fields whose value is never read from a file
+	formStrings []string
+	get         bool
+	head        bool
+	user        string
+	digest      bool
+}
+
+var (
+	curlFlagsWithValue = map[string]string{
+		"-X": "--request", "-H": "--header", "-d": "--data", "-F": "--form", "-u": "--user",
+		"-A": "--user-agent", "-e": "--referer", "-b": "--cookie",
+		"--url": "--url", "--data-raw": "--data-raw", "--data-binary": "--data-binary",
+		"--data-ascii": "--data-ascii", "--data-urlencode": "--data-urlencode", "--json": "--json",
+		"--form-string": "--form-string",
+	}
+	curlFlagsWithoutValue = map[string]string{"-G": "--get", "-I": "--head", "--digest": "--digest", "--basic": "--basic"}
+	// curlIgnoredFlagsWithValue and curlIgnoredFlagsWithoutValue do not
+	// change the request itself, the other flags being rejected
+	curlIgnoredFlagsWithValue    = []string{"-o", "--output", "-m", "--max-time", "--connect-timeout", "--retry", "-w", "--write-out", "-x", "--proxy", "--cacert", "-E", "--cert", "--key"}
+	curlIgnoredFlagsWithoutValue = []string{"-s", "--silent", "-S", "--show-error", "-v", "--verbose", "-L", "--location", "-i", "--include", "-k", "--insecure", "-f", "--fail", "--compressed"}
+)
+
+func curlFlagValue(words []string, position int) (string, error) {
+	if position+1 >= len(words) {
+		return "", fmt.Errorf("missing value for curl option %v", words[position])
+	}
+	return words[position+1], nil
+}
+
+func parseCurlWords(words []string) (curlCommand, error) {
+	command := curlCommand{}
+	for position := 1; position < len(words); position++ {
+		word := words[position]
+		if !strings.HasPrefix(word, "-") || word == "-" {
+			if command.url != "" {
+				return command, fmt.Errorf("unexpected curl argument %q", word)
+			}
+			command.url = word
+			continue
+		}
+
+		flag, attachedValue := word, ""
+		if !strings.HasPrefix(word, "--") && len(word) > 2 {
+			flag, attachedValue = word[:2], word[2:]
+		}
+		if longFlag, ok := curlFlagsWithoutValue[flag]; ok {
+			flag = longFlag
+		}
+		if longFlag, ok := curlFlagsWithValue[flag]; ok {
+			value := attachedValue
+			if value == "" {
+				var err error
+				value, err = curlFlagValue(words, position)
+				if err != nil {
+					return command, err
+				}
+				position++
+			}
+			switch longFlag {
+			case "--request":
+				command.method = value
+			case "--header":
+				command.headers = append(command.headers, value)
+			case "--data", "--data-raw", "--data-binary", "--data-ascii":
+				if longFlag != "--data-raw" && strings.HasPrefix(value, "@") {
+					return command, errors.New("curl data read from a file is not supported")
+				}
+				command.data = append(command.data, value)
+			case "--data-urlencode":
+				command.data = append(command.data, urlEncodeCurlData(value))
+			case "--json":
+				command.data = append(command.data, value)
+				command.headers = append(command.headers, "Content-Type: application/json", "Accept: application/json")
+			case "--form":
+				command.form = append(command.form, value)
+			case "--form-string":
+				command.formStrings = append(command.formStrings, value)
+			case "--user":
+				command.user = value
+			case "--user-agent":
+				command.headers = append(command.headers, "User-Agent: "+value)
+			case "--referer":
+				command.headers = append(command.headers, "Referer: "+value)
+			case "--cookie":
+				command.headers = append(command.headers, "Cookie: "+value)
+			case "--url":
+				command.url = value
+			}
+			continue
+		}
+		switch flag {
+		case "--get":
+			command.get = true
+		case "--head":
+			command.head = true
+		case "--digest":
+			command.digest = true
+		case "--basic":
+			command.digest = false
+		default:
+			switch {
+			case slices.Contains(curlIgnoredFlagsWithValue, flag):
+				if attachedValue == "" {
+					position++
+				}
+			case slices.Contains(curlIgnoredFlagsWithoutValue, flag):
+			default:
+				return command, fmt.Errorf("unsupported curl option %v", word)
+			}
+		}
+	}
+	if command.url == "" {
+		return command, errors.New("invalid curl command, provide an URL")
+	}
+	return command, nil
+}
+
+func urlEncodeCurlData(value string) string {
+	name, content, found := strings.Cut(value, "=")
+	if !found {
+		return url.QueryEscape(value)
+	}
+	return name + "=" + url.QueryEscape(content)
+}
+
+// parseUrlEncodedData reads the key=value pairs of the data, any other
+// payload (e.g. XML or plain text) having no equivalent in the language
+func parseUrlEncodedData(data string) (map[string]string, error) {
+	for _, pair := range strings.Split(data, "&") {
+		if pair == "" {
+			continue
+		}
+		key, _, found := strings.Cut(pair, "=")
+		if !found || key == "" || strings.ContainsAny(pair, "<>\r\n") {
+			return nil, errors.New("unsupported curl body, only JSON and URL encoded form data are supported")
+		}
+	}
+	values, err := url.ParseQuery(data)
+	if err != nil {
+		return nil, fmt.Errorf("curl data parsing error: %w", err)
+	}
+	parsedValues := map[string]string{}
+	for key := range values {
+		parsedValues[key] = values.Get(key)
+	}
+	return parsedValues, nil
+}
+
+func isJsonBody(data string) bool {
+	trimmedData := strings.TrimSpace(data)
+	return (strings.HasPrefix(trimmedData, string(JSON_OBJECT_START)) || strings.HasPrefix(trimmedData, string(JSON_ARRAY_START))) && json.Valid([]byte(trimmedData))
+}
+
+// ParseCurl converts a curl command line into a request and its gogetter
+// language equivalent in Raw. As the language has no URL encoded body, URL
+// encoded data is sent as multipart form data.
+func ParseCurl(input string) (Request, error) {
+	words, err := splitShellWords(strings.TrimSpace(input))
+	if err != nil {
+		return Request{}, fmt.Errorf("curl command parsing error: %w", err)
+	}
+	if len(words) == 0 || words[0] != "curl" {
+		return Request{}, errors.New("invalid curl command, it should start with curl")
+	}
+	command, err := parseCurlWords(words)
+	if err != nil {
+		return Request{}, err
+	}
+
+	request := Request{
+		Url:           command.url,
+		Headers:       Headers{},
+		SearchParams:  SearchParams{},
+		MultipartBody: MultipartBody{},
+	}
+	for _, header := range command.headers {
+		name, value, found := strings.Cut(header, ":")
+		if !found {
+			return Request{}, fmt.Errorf("invalid curl header %q", header)
+		}
+		request.Headers[http.CanonicalHeaderKey(strings.TrimSpace(name))] = strings.TrimSpace(value)
+	}
+	if command.user != "" {
+		username, password, _ := strings.Cut(command.user, ":")
+		request.Auth = &Auth{Scheme: BasicAuthScheme, Username: username, Password: password}
+		if command.digest {
+			request.Auth.Scheme = DigestAuthScheme
+		}
+	}
+
+	data := strings.Join(command.data, "&")
+	switch {
+	case command.get && len(command.data) > 0:
+		values, err := parseUrlEncodedData(data)
+		if err != nil {
+			return Request{}, err
+		}
+		for key, value := range values {
+			request.SearchParams[key] = value
+		}
+	case len(command.data) == 1 && isJsonBody(command.data[0]):
+		request.JsonBody = JsonBody(strings.TrimSpace(command.data[0]))
+	case len(command.data) > 0:
+		values, err := parseUrlEncodedData(data)
+		if err != nil {
+			return Request{}, err
+		}
+		for key, value := range values {
+			request.MultipartBody[key] = value
+		}
+	}
+	for _, field := range command.form {
+		name, value, found := strings.Cut(field, "=")
+		if !found {
+			return Request{}, fmt.Errorf("invalid curl form field %q", field)
+		}
+		if strings.HasPrefix(value, "@") || strings.HasPrefix(value, "<") {
+			return Request{}, errors.New("curl form files are not supported")
+		}
+		request.MultipartBody[name] = value
+	}
+	for _, field := range command.formStrings {
+		name, value, found := strings.Cut(field, "=")
+		if !found {
+			return Request{}, fmt.Errorf("invalid curl form field %q", field)
+		}
+		request.MultipartBody[name] = value
+	}
+	if request.JsonBody != "" && len(request.MultipartBody) > 0 {
+		return Request{}, errors.New("a request cannot have both a JSON and a form body")
+	}
+
+	// The content type is set from the body when executing the request
+	if contentType, ok := request.Headers["Content-Type"]; ok {
+		mediaType, _, _ := mime.ParseMediaType(contentType)
+		if len(request.MultipartBody) > 0 || mediaType == "application/json" {
+			delete(request.Headers, "Content-Type")
+		}
+	}
+
+	request.Method = curlMethod(command, request)
+	raw, err := FormatRequest(request)
+	if err != nil {
+		return Request{}, fmt.Errorf("curl command conversion error: %w", err)
+	}
+	return ParseRequest(raw, ExtensionMethodsOption{})
+}
+
+func curlMethod(command curlCommand, request Request) string {
+	switch {
+	case command.method != "":
+		return strings.ToUpper(command.method)
+	case command.head:
+		return http.MethodHead
+	case command.get:
+		return http.MethodGet
+	case request.JsonBody != "" || len(request.MultipartBody) > 0:
+		return http.MethodPost
+	}
+	return http.MethodGet
+}
@@ -0,0 +1,156 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+)
+
+type Variables map[string]any
+
+type Environment struct {
+	Name      string
+	Variables Variables
+}
+
+type Environments []Environment
+
+type EnvironmentsReadingDto map[string]Variables
+
+func (g Gogetter) Environments() Environments { return g.environments }
+
+func (g Gogetter) ActiveEnvironment() (Environment, bool) {
+	index := slices.IndexFunc(g.environments, func(environment Environment) bool {
+		return environment.Name == g.activeEnvironment
+	})
+	if index == -1 {
+		return Environment{}, false
+	}
+	return g.environments[index], true
+}
+
+// SelectEnvironment activates the named environment, an empty name deactivates
+// the current one
+func (g Gogetter) SelectEnvironment(name string) (Gogetter, error) {
+	if name == "" {
+		g.activeEnvironment = ""
+		return g, nil
+	}
+	if !slices.ContainsFunc(g.environments, func(environment Environment) bool { return environment.Name == name }) {
+		return g, fmt.Errorf("unknown environment %q", name)
+	}
+	g.activeEnvironment = name
+	return g, nil
+}
+
+// NextEnvironment cycles through the environments, going through no
+// environment at all after the last one
+func (g Gogetter) NextEnvironment() Gogetter {
+	index := slices.IndexFunc(g.environments, func(environment Environment) bool {
+		return environment.Name == g.activeEnvironment
+	})
+	if index+1 >= len(g.environments) {
+		g.activeEnvironment = ""
+		return g
+	}
+	g.activeEnvironment = g.environments[index+1].Name
+	return g
+}
+
+// TemplateData merges the ad-hoc variables over the captured ones, themselves
+// merged over the ones of the active environment
+func (g Gogetter) TemplateData(adHocVariables any) (any, error) {
+	environment, ok := g.ActiveEnvironment()
+	if !ok && len(g.capturedVariables) == 0 {
+		return adHocVariables, nil
+	}
+	data := Variables{}
+	maps.Copy(data, environment.Variables)
+	maps.Copy(data, g.capturedVariables)
+	if adHocVariables == nil {
+		return data, nil
+	}
+	adHocVariablesMap, ok := adHocVariables.(map[string]any)
+	if !ok {
+		return nil, errors.New("variables must be a JSON object to be merged with the active environment and the captured variables")
+	}
+	maps.Copy(data, adHocVariablesMap)
+	return data, nil
+}
+
+// WithDefaults completes the variables of the environment with the given ones
+// (e.g. the collection variables)
+func (e Environment) WithDefaults(defaults Variables) Environment {
+	variables := Variables{}
+	maps.Copy(variables, defaults)
+	maps.Copy(variables, e.Variables)
+	e.Variables = variables
+	return e
+}
+
+func extractEnvironments(reader io.Reader) (Environments, error) {
+	readerContent, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("environments reading error: %w", err)
+	}
+	if len(readerContent) == 0 {
+		return Environments{}, nil
+	}
+	var rawEnvironments EnvironmentsReadingDto
+	err = json.Unmarshal(readerContent, &rawEnvironments)
+	if err != nil {
+		return nil, fmt.Errorf("environments unmarshal error: %w", err)
+	}
+	environments := Environments{}
+	for name, variables := range rawEnvironments {
+		environments = append(environments, Environment{Name: name, Variables: variables})
+	}
+	slices.SortFunc(environments, func(a, b Environment) int { return strings.Compare(a.Name, b.Name) })
+	return environments, nil
+}
+
+// SaveEnvironment adds the environment or replaces the one with the same name
+func (g Gogetter) SaveEnvironment(environment Environment) (Gogetter, error) {
+	environments := slices.DeleteFunc(slices.Clone(g.environments), func(existing Environment) bool {
+		return existing.Name == environment.Name
+	})
+	environments = append(environments, environment)
+	slices.SortFunc(environments, func(a, b Environment) int { return strings.Compare(a.Name, b.Name) })
+	g.environments = environments
+	if g.environmentsSavingFunc == nil {
+		return g, nil
+	}
+	toWrite := EnvironmentsReadingDto{}
+	for _, environment := range environments {
+		toWrite[environment.Name] = environment.Variables
+	}
+	content, err := json.MarshalIndent(toWrite, "", "  ")
+	if err != nil {
+		return g, fmt.Errorf("environments marshal error: %w", err)
+	}
+	err = g.environmentsSavingFunc(content)
+	if err != nil {
+		return g, fmt.Errorf("environments writing error: %w", err)
+	}
+	return g, nil
+}
+
+type WithEnvironments struct {
+	Environments           io.Reader
+	ActiveEnvironment      string
+	EnvironmentsSavingFunc func([]byte) error
+}
+
+func (w WithEnvironments) Apply(g Gogetter) (Gogetter, error) {
+	environments, err := extractEnvironments(w.Environments)
+	if err != nil {
+		return Gogetter{}, err
+	}
+	g.environments = environments
+	g.environmentsSavingFunc = w.EnvironmentsSavingFunc
+	return g.SelectEnvironment(w.ActiveEnvironment)
+}
@@ -0,0 +1,211 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go/format"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+type ExportFormat string
+
+const (
+	CurlExportFormat   ExportFormat = "curl"
+	HttpieExportFormat ExportFormat = "HTTPie"
+	GoExportFormat     ExportFormat = "Go"
+)
+
+var ExportFormats = []ExportFormat{CurlExportFormat, HttpieExportFormat, GoExportFormat}
+
+// exportedRequest holds the elements of the request as sent by Execute, the
+// multipart content type being left to the exported tool as it holds a
+// random boundary
+type exportedRequest struct {
+	method        string
+	url           string
+	headers       []headerToExport
+	jsonBody      string
+	multipartBody []formFieldToExport
+	// digestCredentials are the user:password of the digest auth, which the
+	// exported tool answers the challenge with
+	digestCredentials string
+}
+
+type headerToExport struct{ name, value string }
+type formFieldToExport struct{ name, value string }
+
+func newExportedRequest(request Request) (exportedRequest, error) {
+	if request.Auth != nil && request.Auth.Scheme == OAuth2AuthScheme {
+		return exportedRequest{}, errors.New("oauth2 auth cannot be exported, its token being acquired on execution")
+	}
+	req, err := newHttpRequest(context.Background(), request)
+	if err != nil {
+		return exportedRequest{}, err
+	}
+	exported := exportedRequest{
+		method:   req.Method,
+		url:      req.URL.String(),
+		jsonBody: string(request.JsonBody),
+	}
+	if request.Auth != nil && request.Auth.Scheme == DigestAuthScheme {
+		exported.digestCredentials = request.Auth.Username + ":" + request.Auth.Password
+	}
+	headerNames := []string{}
+	for name := range req.Header {
+		headerNames = append(headerNames, name)
+	}
+	slices.Sort(headerNames)
+	for _, name := range headerNames {
+		if name == "Content-Type" && len(request.MultipartBody) != 0 {
+			continue
+		}
+		for _, value := range req.Header[name] {
+			exported.headers = append(exported.headers, headerToExport{name, value})
+		}
+	}
+	fieldNames := []string{}
+	for name := range request.MultipartBody {
+		fieldNames = append(fieldNames, name)
+	}
+	slices.Sort(fieldNames)
+	for _, name := range fieldNames {
+		exported.multipartBody = append(exported.multipartBody, formFieldToExport{name, request.MultipartBody[name]})
+	}
+	return exported, nil
+}
+
+// shellQuote quotes a shell word with single quotes, closing them around
+// the single quotes of the value
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// exportCurl writes one option per line, keeping each flag with its value
+func exportCurl(request exportedRequest) string {
+	words := []string{"curl"}
+	hasBody := request.jsonBody != "" || len(request.multipartBody) != 0
+	switch {
+	// the body options would make curl send a POST otherwise
+	case hasBody || (request.method != http.MethodGet && request.method != http.MethodHead):
+		words = append(words, "-X "+request.method)
+	case request.method == http.MethodHead:
+		// -X HEAD would wait for a body the server never sends
+		words = append(words, "--head")
+	}
+	words = append(words, shellQuote(request.url))
+	if request.digestCredentials != "" {
+		words = append(words, "--digest -u "+shellQuote(request.digestCredentials))
+	}
+	for _, header := range request.headers {
+		words = append(words, "-H "+shellQuote(header.name+": "+header.value))
+	}
+	if request.jsonBody != "" {
+		words = append(words, "--data-raw "+shellQuote(request.jsonBody))
+	}
+	for _, field := range request.multipartBody {
+		words = append(words, "--form-string "+shellQuote(field.name+"="+field.value))
+	}
+	return strings.Join(words, " \\\n  ")
+}
+
+func exportHttpie(request exportedRequest) string {
+	words := []string{"http"}
+	if len(request.multipartBody) != 0 {
+		words = append(words, "--multipart")
+	}
+	if request.jsonBody != "" {
+		words = append(words, "--raw "+shellQuote(request.jsonBody))
+	}
+	if request.digestCredentials != "" {
+		words = append(words, "-A digest -a "+shellQuote(request.digestCredentials))
+	}
+	words = append(words, request.method, shellQuote(request.url))
+	for _, header := range request.headers {
+		words = append(words, shellQuote(header.name+":"+header.value))
+	}
+	for _, field := range request.multipartBody {
+		words = append(words, shellQuote(field.name+"="+httpieFieldValue(field.value)))
+	}
+	return strings.Join(words, " \\\n  ")
+}
+
+// httpieFieldValue escapes the values starting with @, name=@value embedding
+// the content of the value file
+func httpieFieldValue(value string) string {
+	if strings.HasPrefix(value, "@") {
+		return `\` + value
+	}
+	return value
+}
+
+func exportGo(request exportedRequest) (string, error) {
+	if request.digestCredentials != "" {
+		return "", fmt.Errorf("digest auth cannot be exported to %v", GoExportFormat)
+	}
+	imports := []string{"fmt", "io", "net/http", "os"}
+	builder := strings.Builder{}
+	body := "nil"
+	switch {
+	case request.jsonBody != "":
+		imports = append(imports, "strings")
+		body = "body"
+		fmt.Fprintf(&builder, "body := strings.NewReader(%v)\n", strconv.Quote(request.jsonBody))
+	case len(request.multipartBody) != 0:
+		imports = append(imports, "bytes", "mime/multipart")
+		body = "body"
+		builder.WriteString("body := &bytes.Buffer{}\nwriter := multipart.NewWriter(body)\n")
+		for _, field := range request.multipartBody {
+			fmt.Fprintf(&builder, "if err := writer.WriteField(%v, %v); err != nil {\npanic(err)\n}\n", strconv.Quote(field.name), strconv.Quote(field.value))
+		}
+		builder.WriteString("if err := writer.Close(); err != nil {\npanic(err)\n}\n")
+	}
+	fmt.Fprintf(&builder, "req, err := http.NewRequest(%v, %v, %v)\nif err != nil {\npanic(err)\n}\n", strconv.Quote(request.method), strconv.Quote(request.url), body)
+	for _, header := range request.headers {
+		fmt.Fprintf(&builder, "req.Header.Add(%v, %v)\n", strconv.Quote(header.name), strconv.Quote(header.value))
+	}
+	if len(request.multipartBody) != 0 {
+		builder.WriteString("req.Header.Set(\"Content-Type\", writer.FormDataContentType())\n")
+	}
+	builder.WriteString(`resp, err := http.DefaultClient.Do(req)
+if err != nil {
+panic(err)
+}
+defer resp.Body.Close()
+fmt.Println(resp.Status)
+io.Copy(os.Stdout, resp.Body)
+`)
+
+	slices.Sort(imports)
+	quotedImports := []string{}
+	for _, importPath := range imports {
+		quotedImports = append(quotedImports, strconv.Quote(importPath))
+	}
+	source := fmt.Sprintf("package main\n\nimport (\n%v\n)\n\nfunc main() {\n%v}\n", strings.Join(quotedImports, "\n"), builder.String())
+	formatted, err := format.Source([]byte(source))
+	if err != nil {
+		return "", fmt.Errorf("go code formatting error: %w", err)
+	}
+	return string(formatted), nil
+}
+
+// Export generates a runnable command or program sending the request exactly
+// as Execute would
+func Export(request Request, exportFormat ExportFormat) (string, error) {
+	exported, err := newExportedRequest(request)
+	if err != nil {
+		return "", err
+	}
+	switch exportFormat {
+	case CurlExportFormat:
+		return exportCurl(exported), nil
+	case HttpieExportFormat:
+		return exportHttpie(exported), nil
+	case GoExportFormat:
+		return exportGo(exported)
+	}
+	return "", fmt.Errorf("unknown export format %q", exportFormat)
+}
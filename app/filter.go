@@ -0,0 +1,135 @@
+package app
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// StatusClass matches either a status code such as 404 or a whole class of
+// status codes such as 5xx
+type StatusClass string
+
+func ParseStatusClass(input string) (StatusClass, error) {
+	class := strings.ToLower(strings.TrimSpace(input))
+	if len(class) != 3 {
+		return "", fmt.Errorf("invalid status class %q, expected a class like 5xx or a code like 404", input)
+	}
+	if _, err := strconv.Atoi(strings.ReplaceAll(class, "x", "0")); err != nil {
+		return "", fmt.Errorf("invalid status class %q, expected a class like 5xx or a code like 404", input)
+	}
+	return StatusClass(class), nil
+}
+
+func (c StatusClass) Matches(statusCode int) bool {
+	code := strconv.Itoa(statusCode)
+	if len(code) != len(c) {
+		return false
+	}
+	for i := range c {
+		if c[i] != 'x' && c[i] != code[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func sortedKeyValues[M ~map[string]string](values M, separator string) []string {
+	keyValues := []string{}
+	for key, value := range values {
+		keyValues = append(keyValues, key+separator+value)
+	}
+	slices.Sort(keyValues)
+	return keyValues
+}
+
+// FilterValue starts with the method and the URL, followed by the headers,
+// search params and body of the request
+func (r Request) FilterValue() string {
+	elements := []string{r.Method, r.Url}
+	elements = append(elements, sortedKeyValues(r.Headers, string(HEADER))...)
+	elements = append(elements, sortedKeyValues(r.SearchParams, string(SEARCH_PARAM))...)
+	elements = append(elements, sortedKeyValues(r.MultipartBody, string(FORM_DATA))...)
+	if r.JsonBody != "" {
+		elements = append(elements, strings.Join(strings.Fields(string(r.JsonBody)), " "))
+	}
+	return strings.Join(elements, " ")
+}
+
+// FilterValue starts with the response code, followed by the request filter
+// value, as expected by HistoryFilter
+func (r RequestAndResponse) FilterValue() string {
+	return fmt.Sprintf("%v %v", r.ResponseCode, r.Request.FilterValue())
+}
+
+func (s SavedRequest) FilterValue() string {
+	elements := []string{}
+	if s.Name != "" {
+		elements = append(elements, s.Name)
+	}
+	for _, tag := range s.Tags {
+		elements = append(elements, "#"+tag)
+	}
+	if s.Description != "" {
+		elements = append(elements, s.Description)
+	}
+	elements = append(elements, s.Request.FilterValue())
+	return strings.Join(elements, " ")
+}
+
+// HistoryFilter is a history search term made of structured filters such as
+// status:5xx or method:POST, the rest of the term being matched freely
+type HistoryFilter struct {
+	Statuses []StatusClass
+	Methods  []string
+	Text     string
+}
+
+const (
+	statusFilterPrefix = "status:"
+	methodFilterPrefix = "method:"
+)
+
+func ParseHistoryFilter(term string) HistoryFilter {
+	filter := HistoryFilter{}
+	textElements := []string{}
+	for _, element := range strings.Fields(term) {
+		lowerElement := strings.ToLower(element)
+		if strings.HasPrefix(lowerElement, statusFilterPrefix) {
+			status, err := ParseStatusClass(strings.TrimPrefix(lowerElement, statusFilterPrefix))
+			if err == nil {
+				filter.Statuses = append(filter.Statuses, status)
+				continue
+			}
+		}
+		if strings.HasPrefix(lowerElement, methodFilterPrefix) && len(element) > len(methodFilterPrefix) {
+			filter.Methods = append(filter.Methods, strings.ToUpper(element[len(methodFilterPrefix):]))
+			continue
+		}
+		textElements = append(textElements, element)
+	}
+	filter.Text = strings.Join(textElements, " ")
+	return filter
+}
+
+// MatchesStructuredFilters checks the status and method filters against a
+// RequestAndResponse filter value, each kind of filter matching any of its
+// values
+func (f HistoryFilter) MatchesStructuredFilters(filterValue string) bool {
+	fields := strings.Fields(filterValue)
+	if len(fields) < 2 {
+		return len(f.Statuses) == 0 && len(f.Methods) == 0
+	}
+	statusCode, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return false
+	}
+	if len(f.Statuses) > 0 && !slices.ContainsFunc(f.Statuses, func(status StatusClass) bool { return status.Matches(statusCode) }) {
+		return false
+	}
+	if len(f.Methods) > 0 && !slices.Contains(f.Methods, fields[1]) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,70 @@
+package app
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+func formatValue(value string) (string, error) {
+	if !strings.ContainsAny(value, strings.Join(separators, "")) {
+		return value, nil
+	}
+	if strings.Contains(value, "\"") {
+		return "", fmt.Errorf("value %q cannot contain both quotes and separators", value)
+	}
+	return fmt.Sprintf("\"%v\"", value), nil
+}
+
+func formatKeyValuePairs(values map[string]string, separator keyword) ([]string, error) {
+	keys := []string{}
+	for key := range values {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	pairs := []string{}
+	for _, key := range keys {
+		value, err := formatValue(values[key])
+		if err != nil {
+			return nil, err
+		}
+		pairs = append(pairs, key+string(separator)+value)
+	}
+	return pairs, nil
+}
+
+// FormatRequest writes the request in the gogetter language, one line per
+// kind of element
+func FormatRequest(request Request) (string, error) {
+	lines := []string{fmt.Sprintf("%v %v", request.Method, request.Url)}
+	for _, element := range []struct {
+		values    map[string]string
+		separator keyword
+	}{
+		{request.Headers, HEADER},
+		{request.SearchParams, SEARCH_PARAM},
+		{request.MultipartBody, FORM_DATA},
+	} {
+		pairs, err := formatKeyValuePairs(element.values, element.separator)
+		if err != nil {
+			return "", err
+		}
+		if len(pairs) > 0 {
+			lines = append(lines, strings.Join(pairs, " "))
+		}
+	}
+	if request.JsonBody != "" {
+		lines = append(lines, string(request.JsonBody))
+	}
+	if request.Auth != nil {
+		lines = append(lines, request.Auth.String())
+	}
+	lines = append(lines, request.Options.lines()...)
+	for _, assertion := range request.Assertions {
+		lines = append(lines, assertion.String())
+	}
+	for _, capture := range request.Captures {
+		lines = append(lines, capture.String())
+	}
+	return strings.Join(lines, "\n"), nil
+}
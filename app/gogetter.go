@@ -2,11 +2,15 @@ package app
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
 	"net/http"
 	"strings"
+	"text/template"
+	"time"
 )
 
 type HttpClient interface {
@@ -26,27 +30,58 @@ type Request struct {
 	SearchParams  SearchParams
 	MultipartBody MultipartBody
 	JsonBody      JsonBody
+	Assertions    []Assertion
+	Captures      []Capture
+	Auth          *Auth
+	Options       RequestOptions
 }
 
-func (r Request) FilterValue() string { return fmt.Sprintf("%v %v", r.Method, r.Url) }
-func (r Request) String() string      { return fmt.Sprintf("[%v]%v", r.Method, r.Url) }
+func (r Request) String() string { return fmt.Sprintf("[%v]%v", r.Method, r.Url) }
 
 type RequestAndResponse struct {
 	Request
 	ResponseCode int
+	Timing       Timing
+	Response     *RecordedResponse
+	// Assertions are the results of the assertions of the request, they are
+	// not kept in the history
+	Assertions []AssertionResult
+	// Captures are the values captured from the response, they are not kept
+	// in the history either
+	Captures []CaptureResult
+	// Cancelled requests were aborted before their response was received
+	Cancelled bool
 }
 
-func (r RequestAndResponse) FilterValue() string { return fmt.Sprintf("%v %v", r.Method, r.Url) }
 func (r RequestAndResponse) String() string {
+	if r.Cancelled {
+		return fmt.Sprintf("[%v]%v (cancelled)", r.Method, r.Url)
+	}
 	return fmt.Sprintf("[%v]%v (%v)", r.Method, r.Url, r.ResponseCode)
 }
 
 type Gogetter struct {
-	client             HttpClient
-	history            History
-	historyWriter      func([]byte) error
-	savedRequests      SavedRequests
-	requestsSavingFunc func([]byte) error
+	client                  HttpClient
+	history                 History
+	historyWriter           func([]byte) error
+	historyStore            HistoryStore
+	recordResponses         bool
+	responseBodySizeLimit   int64
+	savedRequests           SavedRequests
+	requestsSavingFunc      func([]byte) error
+	savedRequestsCollection SavedRequestsCollection
+	environments            Environments
+	environmentsSavingFunc  func([]byte) error
+	activeEnvironment       string
+	capturedVariables       Variables
+	templateFunctions       template.FuncMap
+	secrets                 *Secrets
+	oauth2Configurations    OAuth2Configurations
+	oauth2Tokens            *OAuth2Tokens
+	oauth2Authorize         func(string) error
+	cookieJar               *CookieJar
+	cookiesEnabled          bool
+	clientSettings          ClientSettings
 }
 
 func (g Gogetter) History() History             { return g.history }
@@ -87,20 +122,25 @@ func getBody(request Request) (bodyReader io.Reader, contentType string, err err
 	return nil, "", nil
 }
 
-func (g Gogetter) Execute(request Request) (Gogetter, RequestAndResponse, *http.Response, error) {
+// newHttpRequest builds the request as sent by Execute
+func newHttpRequest(ctx context.Context, request Request) (*http.Request, error) {
 	body, contentType, err := getBody(request)
 	if err != nil {
-		return g, RequestAndResponse{}, nil, fmt.Errorf("request body error: %w", err)
+		return nil, fmt.Errorf("request body error: %w", err)
 	}
-	req, err := http.NewRequest(request.Method, request.Url, body)
+	req, err := http.NewRequestWithContext(ctx, request.Method, request.Url, body)
 	if err != nil {
-		return g, RequestAndResponse{}, nil, fmt.Errorf("new request error: %w", err)
+		return nil, fmt.Errorf("new request error: %w", err)
 	}
 	for header, value := range request.Headers {
 		req.Header.Add(header, value)
 	}
 
-	req.Header.Add("Content-Type", contentType)
+	// A JSON body keeps the content type provided in the headers (e.g.
+	// application/vnd.api+json), a multipart one needs its boundary
+	if contentType != "" && (len(request.MultipartBody) != 0 || req.Header.Get("Content-Type") == "") {
+		req.Header.Set("Content-Type", contentType)
+	}
 
 	q := req.URL.Query()
 	for key, value := range request.SearchParams {
@@ -109,11 +149,73 @@ func (g Gogetter) Execute(request Request) (Gogetter, RequestAndResponse, *http.
 	if len(request.SearchParams) > 0 {
 		req.URL.RawQuery = q.Encode()
 	}
+	if request.Auth != nil {
+		request.Auth.apply(req)
+	}
+	return req, nil
+}
+
+var ErrRequestCancelled = errors.New("request cancelled")
+
+// Execute sends the request and records it in the history. Cancelling the
+// context aborts the request, which is recorded as cancelled and returns
+// ErrRequestCancelled.
+func (g Gogetter) Execute(ctx context.Context, request Request) (Gogetter, RequestAndResponse, *http.Response, error) {
+	start := time.Now()
+	executed, requestAndResponse, response, err := g.execute(ctx, request)
+	if err == nil || !errors.Is(err, context.Canceled) {
+		return executed, requestAndResponse, response, err
+	}
+	requestAndResponse = RequestAndResponse{
+		Request:   request,
+		Timing:    Timing{Total: time.Since(start)},
+		Cancelled: true,
+	}
+	g, err = g.AppendToHistory(requestAndResponse)
+	if err != nil {
+		return g, requestAndResponse, nil, fmt.Errorf("unable to append to history: %w", err)
+	}
+	return g, requestAndResponse, nil, ErrRequestCancelled
+}
 
-	response, err := g.client.Do(req)
+func (g Gogetter) execute(ctx context.Context, request Request) (Gogetter, RequestAndResponse, *http.Response, error) {
+	req, err := newHttpRequest(ctx, request)
+	if err != nil {
+		return g, RequestAndResponse{}, nil, err
+	}
+
+	freshOAuth2Token := false
+	if request.Auth != nil && request.Auth.Scheme == OAuth2AuthScheme {
+		freshOAuth2Token, err = g.authorizeWithOAuth2(req, request.Auth.Configuration)
+		if err != nil {
+			return g, RequestAndResponse{}, nil, err
+		}
+	}
+
+	req = g.useCookieJar(req, request)
+	req, tracer := traceRequest(req)
+	timestamp := time.Now()
+	response, err := g.do(req, request.Options)
+	timing := tracer.done()
 	if err != nil {
 		return g, RequestAndResponse{}, nil, fmt.Errorf("request execution error: %w", err)
 	}
+	err = storeResponseCookies(req, response)
+	if err != nil {
+		return g, RequestAndResponse{}, nil, err
+	}
+	if request.Auth != nil && request.Auth.Scheme == DigestAuthScheme && response.StatusCode == http.StatusUnauthorized {
+		response, timing, err = g.answerDigestChallenge(ctx, request, response, timing)
+		if err != nil {
+			return g, RequestAndResponse{}, nil, err
+		}
+	}
+	if request.Auth != nil && request.Auth.Scheme == OAuth2AuthScheme && response.StatusCode == http.StatusUnauthorized && !freshOAuth2Token {
+		response, timing, err = g.retryWithNewOAuth2Token(ctx, request, response)
+		if err != nil {
+			return g, RequestAndResponse{}, nil, err
+		}
+	}
 
 	responseCode := 0
 	if response != nil {
@@ -122,6 +224,22 @@ func (g Gogetter) Execute(request Request) (Gogetter, RequestAndResponse, *http.
 	requestAndResponse := RequestAndResponse{
 		Request:      request,
 		ResponseCode: responseCode,
+		Timing:       timing,
+	}
+	if g.recordResponses && response != nil {
+		requestAndResponse.Response, err = g.recordResponse(response, timestamp, timing)
+		if err != nil {
+			return g, requestAndResponse, nil, err
+		}
+	}
+	if (len(request.Assertions) > 0 || len(request.Captures) > 0) && response != nil {
+		checked, err := newCheckedResponse(response, timing)
+		if err != nil {
+			return g, requestAndResponse, nil, err
+		}
+		requestAndResponse.Assertions = checkAssertions(request.Assertions, checked)
+		requestAndResponse.Captures = captureVariables(request.Captures, checked)
+		g = g.storeCapturedVariables(requestAndResponse.Captures)
 	}
 	g, err = g.AppendToHistory(requestAndResponse)
 	if err != nil {
@@ -130,13 +248,42 @@ func (g Gogetter) Execute(request Request) (Gogetter, RequestAndResponse, *http.
 	return g, requestAndResponse, response, nil
 }
 
+// answerDigestChallenge sends the request again with the digest credentials
+// answering the challenge of the unauthorized response
+func (g Gogetter) answerDigestChallenge(ctx context.Context, request Request, unauthorized *http.Response, timing Timing) (*http.Response, Timing, error) {
+	challenge, found := parseDigestChallenge(unauthorized.Header)
+	if !found {
+		return unauthorized, timing, nil
+	}
+	io.Copy(io.Discard, unauthorized.Body)
+	unauthorized.Body.Close()
+
+	req, err := newHttpRequest(ctx, request)
+	if err != nil {
+		return nil, Timing{}, err
+	}
+	authorization, err := request.Auth.digestAuthorization(req, challenge)
+	if err != nil {
+		return nil, Timing{}, err
+	}
+	req.Header.Set("Authorization", authorization)
+	req, tracer := traceRequest(g.useCookieJar(req, request))
+	response, err := g.do(req, request.Options)
+	timing = tracer.done()
+	if err != nil {
+		return nil, Timing{}, fmt.Errorf("digest request execution error: %w", err)
+	}
+	return response, timing, storeResponseCookies(req, response)
+}
+
 type GogetterOption interface {
 	Apply(Gogetter) (Gogetter, error)
 }
 
 func NewGogetter(client HttpClient, options ...GogetterOption) (Gogetter, error) {
 	gogetter := Gogetter{
-		client: client,
+		client:  client,
+		secrets: newSecrets(map[string]string{}),
 	}
 	for _, option := range options {
 		var err error
@@ -1,30 +1,136 @@
 package app
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
+	"net/http"
+	"time"
+	"unicode/utf8"
 )
 
+const DefaultResponseBodySizeLimit = 64 * 1024
+
+type HistoryResponseWritingDto struct {
+	Proto         string
+	Status        string
+	Headers       http.Header
+	Body          string
+	BodyTruncated bool `json:",omitempty"`
+	Timestamp     time.Time
+	Duration      time.Duration
+}
+
 type HistoryEntryWritingDto struct {
 	Request      string
 	ResponseCode int
+	Response     *HistoryResponseWritingDto `json:",omitempty"`
+	Cancelled    bool                       `json:",omitempty"`
+}
+
+// RecordedResponse is the response kept in history when responses recording
+// is enabled
+type RecordedResponse struct {
+	Proto         string
+	Status        string
+	Headers       http.Header
+	Body          string
+	BodyTruncated bool
+	Timestamp     time.Time
+	Duration      time.Duration
+}
+
+func (g Gogetter) recordResponse(response *http.Response, timestamp time.Time, timing Timing) (*RecordedResponse, error) {
+	body, err := io.ReadAll(response.Body)
+	response.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("response body reading error: %w", err)
+	}
+	response.Body = io.NopCloser(bytes.NewReader(body))
+
+	recordedBody := body
+	if int64(len(recordedBody)) > g.responseBodySizeLimit {
+		recordedBody = trimIncompleteRune(recordedBody[:g.responseBodySizeLimit])
+	}
+	return &RecordedResponse{
+		Proto:         response.Proto,
+		Status:        response.Status,
+		Headers:       response.Header,
+		Body:          string(recordedBody),
+		BodyTruncated: len(recordedBody) != len(body),
+		Timestamp:     timestamp,
+		Duration:      timing.Total,
+	}, nil
+}
+
+// trimIncompleteRune drops the start of the UTF-8 rune split by the body
+// truncation, which would otherwise be written as a replacement character
+func trimIncompleteRune(body []byte) []byte {
+	for start := len(body) - 1; start >= 0 && start >= len(body)-utf8.UTFMax; start-- {
+		if utf8.RuneStart(body[start]) {
+			if !utf8.FullRune(body[start:]) {
+				return body[:start]
+			}
+			return body
+		}
+	}
+	return body
+}
+
+// LimitRecordedResponseBodies caps the response bodies kept in the history to
+// limit bytes, DefaultResponseBodySizeLimit if not positive
+func (g Gogetter) LimitRecordedResponseBodies(limit int64) Gogetter {
+	g.responseBodySizeLimit = limit
+	if g.responseBodySizeLimit <= 0 {
+		g.responseBodySizeLimit = DefaultResponseBodySizeLimit
+	}
+	return g
+}
+
+func newHistoryEntryWritingDto(requestAndResponse RequestAndResponse) HistoryEntryWritingDto {
+	entry := HistoryEntryWritingDto{
+		Request:      requestAndResponse.Raw,
+		ResponseCode: requestAndResponse.ResponseCode,
+		Cancelled:    requestAndResponse.Cancelled,
+	}
+	if requestAndResponse.Response != nil {
+		entry.Response = &HistoryResponseWritingDto{
+			Proto:         requestAndResponse.Response.Proto,
+			Status:        requestAndResponse.Response.Status,
+			Headers:       requestAndResponse.Response.Headers,
+			Body:          requestAndResponse.Response.Body,
+			BodyTruncated: requestAndResponse.Response.BodyTruncated,
+			Timestamp:     requestAndResponse.Response.Timestamp,
+			Duration:      requestAndResponse.Response.Duration,
+		}
+	}
+	return entry
 }
 
 type History []RequestAndResponse
 
+// AppendToHistory keeps the request and its response, the secrets they hold
+// being redacted
 func (g Gogetter) AppendToHistory(requestAndResponse RequestAndResponse) (Gogetter, error) {
+	if g.secrets != nil {
+		requestAndResponse = g.secrets.redactRequestAndResponse(requestAndResponse)
+	}
 	g.history = append(g.history, requestAndResponse)
+	if g.historyStore != nil {
+		err := g.historyStore.Append(newHistoryEntryWritingDto(requestAndResponse))
+		if err != nil {
+			return g, fmt.Errorf("history store appending error: %w", err)
+		}
+		return g, nil
+	}
 	if g.historyWriter == nil {
 		return g, nil
 	}
 
 	history := []HistoryEntryWritingDto{}
 	for _, request := range g.history {
-		history = append(history, HistoryEntryWritingDto{
-			Request:      request.Raw,
-			ResponseCode: request.ResponseCode,
-		})
+		history = append(history, newHistoryEntryWritingDto(request))
 	}
 	toWrite, err := json.Marshal(history)
 	if err != nil {
@@ -47,24 +153,48 @@ func extractHistory(reader io.Reader) (History, error) {
 	}
 	var rawHistory []HistoryEntryWritingDto
 	err = json.Unmarshal(readerContent, &rawHistory)
+	return historyFromWritingDtos(rawHistory)
+}
+
+func historyFromWritingDtos(rawHistory []HistoryEntryWritingDto) (History, error) {
 	history := History{}
 	for _, historyEntry := range rawHistory {
-		request, err := ParseRequest(historyEntry.Request)
+		// Entries were already validated when executed, extension methods included
+		request, err := ParseRequest(historyEntry.Request, ExtensionMethodsOption{})
 		if err != nil {
 			return nil, fmt.Errorf("history entry parsing error: %w", err)
 		}
-		history = append(history, RequestAndResponse{
+		requestAndResponse := RequestAndResponse{
 			Request:      request,
 			ResponseCode: historyEntry.ResponseCode,
-		})
+			Cancelled:    historyEntry.Cancelled,
+		}
+		if historyEntry.Response != nil {
+			requestAndResponse.Response = &RecordedResponse{
+				Proto:         historyEntry.Response.Proto,
+				Status:        historyEntry.Response.Status,
+				Headers:       historyEntry.Response.Headers,
+				Body:          historyEntry.Response.Body,
+				BodyTruncated: historyEntry.Response.BodyTruncated,
+				Timestamp:     historyEntry.Response.Timestamp,
+				Duration:      historyEntry.Response.Duration,
+			}
+			requestAndResponse.Timing = Timing{Total: historyEntry.Response.Duration}
+		}
+		history = append(history, requestAndResponse)
 	}
 
 	return history, nil
 }
 
+// WithHistory loads the previous history and persists the new entries. The
+// responses are only kept when RecordResponses is set, with their body capped
+// to ResponseBodySizeLimit bytes (DefaultResponseBodySizeLimit if unset).
 type WithHistory struct {
-	PreviousHistory io.Reader
-	HistoryWriter   func([]byte) error
+	PreviousHistory       io.Reader
+	HistoryWriter         func([]byte) error
+	RecordResponses       bool
+	ResponseBodySizeLimit int64
 }
 
 func (w WithHistory) Apply(g Gogetter) (Gogetter, error) {
@@ -74,5 +204,44 @@ func (w WithHistory) Apply(g Gogetter) (Gogetter, error) {
 	}
 	g.history = history
 	g.historyWriter = w.HistoryWriter
+	g.recordResponses = w.RecordResponses
+	g.responseBodySizeLimit = w.ResponseBodySizeLimit
+	if g.responseBodySizeLimit <= 0 {
+		g.responseBodySizeLimit = DefaultResponseBodySizeLimit
+	}
+	return g, nil
+}
+
+// HistoryStore persists history entries one at a time instead of rewriting
+// the whole history on every request
+type HistoryStore interface {
+	Load() ([]HistoryEntryWritingDto, error)
+	Append(entry HistoryEntryWritingDto) error
+}
+
+// WithHistoryStore is the HistoryStore based alternative to WithHistory
+type WithHistoryStore struct {
+	Store                 HistoryStore
+	RecordResponses       bool
+	ResponseBodySizeLimit int64
+}
+
+func (w WithHistoryStore) Apply(g Gogetter) (Gogetter, error) {
+	rawHistory, err := w.Store.Load()
+	if err != nil {
+		return Gogetter{}, fmt.Errorf("history store loading error: %w", err)
+	}
+	history, err := historyFromWritingDtos(rawHistory)
+	if err != nil {
+		return Gogetter{}, err
+	}
+	g.history = history
+	g.historyStore = w.Store
+	g.historyWriter = nil
+	g.recordResponses = w.RecordResponses
+	g.responseBodySizeLimit = w.ResponseBodySizeLimit
+	if g.responseBodySizeLimit <= 0 {
+		g.responseBodySizeLimit = DefaultResponseBodySizeLimit
+	}
 	return g, nil
 }
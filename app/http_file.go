@@ -0,0 +1,489 @@
+package app
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"maps"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// HttpFile holds the requests of a .http or .rest file, as written for the
+// JetBrains HTTP client or the VS Code REST Client
+type HttpFile struct {
+	SavedRequests SavedRequests
+	// Variables are the @variable = value declarations of the file
+	Variables Variables
+	// Unsupported describes the parts of the file that were not imported
+	Unsupported []string
+}
+
+const (
+	httpFileSeparator     = "###"
+	httpFileBoundary      = "gogetter-boundary"
+	httpFileNameDirective = "@name"
+)
+
+var (
+	httpFileVariableDeclaration = regexp.MustCompile(`^@([^\s=]+)\s*=\s*(.*)$`)
+	httpFileHttpVersion         = regexp.MustCompile(`^HTTP/[0-9.]+$`)
+	goTemplateVariable          = regexp.MustCompile(`^\{\{\s*\.([a-zA-Z_][a-zA-Z0-9_]*)\s*\}\}$`)
+	// goTemplateAction matches the string literals first, as they can hold }}
+	goTemplateAction = regexp.MustCompile("\\{\\{`[^`]*`\\}\\}|" + `\{\{"(?:[^"\\]|\\.)*"\}\}|\{\{.*?\}\}`)
+)
+
+type httpFileImporter struct {
+	converter
+	// rawVariables are the declared values, before their translation
+	rawVariables map[string]string
+}
+
+// translate also keeps the references to the responses of other requests
+// (e.g. {{login.response.body.token}}), which have no equivalent
+func (i *httpFileImporter) translate(location string, text string) string {
+	return mustacheVariablePattern.ReplaceAllStringFunc(text, func(match string) string {
+		name := mustacheVariablePattern.FindStringSubmatch(match)[1]
+		if strings.Contains(name, ".") && !strings.HasPrefix(name, "$") {
+			i.report(location, "response reference %v is not supported", name)
+			return keepLiteral(match)
+		}
+		return i.translateVariable(location, name, match)
+	})
+}
+
+// declareVariable resolves the references to the previously declared
+// variables, as the other ones cannot be known at import time
+func (i *httpFileImporter) declareVariable(name string, value string, variables Variables) {
+	value = mustacheVariablePattern.ReplaceAllStringFunc(value, func(match string) string {
+		if declaredValue, ok := i.rawVariables[mustacheVariablePattern.FindStringSubmatch(match)[1]]; ok {
+			return declaredValue
+		}
+		return match
+	})
+	i.rawVariables[name] = value
+	if strings.Contains(value, "{{") {
+		i.report("", "variable %q references other variables, which are not resolved", name)
+	}
+	identifier := variableIdentifier(name)
+	i.reportRenamedVariable(name, identifier)
+	variables[identifier] = value
+}
+
+type httpFileBlock struct {
+	name        string
+	description []string
+	lines       []string
+}
+
+func isHttpFileComment(line string) bool {
+	return strings.HasPrefix(line, "#") || strings.HasPrefix(line, "//")
+}
+
+func httpFileComment(line string) string {
+	return strings.TrimSpace(strings.TrimLeft(line, "#/"))
+}
+
+func (i *httpFileImporter) splitBlocks(reader io.Reader, variables Variables) ([]httpFileBlock, error) {
+	blocks := []httpFileBlock{}
+	block := httpFileBlock{}
+	flush := func() {
+		if len(block.lines) > 0 {
+			blocks = append(blocks, block)
+		}
+	}
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		trimmedLine := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmedLine, httpFileSeparator) {
+			flush()
+			block = httpFileBlock{name: strings.TrimSpace(strings.TrimLeft(trimmedLine, "#"))}
+			continue
+		}
+		// Before the request line come the variables and the comments
+		if len(block.lines) == 0 {
+			if declaration := httpFileVariableDeclaration.FindStringSubmatch(trimmedLine); declaration != nil {
+				i.declareVariable(declaration[1], strings.TrimSpace(declaration[2]), variables)
+				continue
+			}
+			if trimmedLine == "" {
+				continue
+			}
+			if isHttpFileComment(trimmedLine) {
+				comment := httpFileComment(trimmedLine)
+				switch {
+				case strings.HasPrefix(comment, httpFileNameDirective+" "):
+					block.name = strings.TrimSpace(strings.TrimPrefix(comment, httpFileNameDirective))
+				case strings.HasPrefix(comment, "@"):
+					directive, _, _ := strings.Cut(comment, " ")
+					i.report(block.name, "directive %v is not supported", directive)
+				case comment != "":
+					block.description = append(block.description, comment)
+				}
+				continue
+			}
+		}
+		block.lines = append(block.lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("http file reading error: %w", err)
+	}
+	flush()
+	return blocks, nil
+}
+
+func (i *httpFileImporter) applyBody(location string, request *Request, body string) {
+	contentType := ""
+	for header, value := range request.Headers {
+		if strings.EqualFold(header, "Content-Type") {
+			contentType = value
+		}
+	}
+	mediaType, parameters, _ := mime.ParseMediaType(contentType)
+	switch {
+	case strings.HasPrefix(body, "<"):
+		i.report(location, "body read from a file is not supported")
+	case strings.HasPrefix(body, string(JSON_OBJECT_START)) || strings.HasPrefix(body, string(JSON_ARRAY_START)):
+		request.JsonBody = JsonBody(i.translate(location, body))
+	case mediaType == "application/x-www-form-urlencoded":
+		values, err := url.ParseQuery(strings.ReplaceAll(body, "\n", ""))
+		if err != nil {
+			i.report(location, "invalid url encoded body: %v", err)
+			return
+		}
+		i.report(location, "url encoded body is sent as multipart form data")
+		for key := range values {
+			request.MultipartBody[i.translate(location, key)] = i.translate(location, values.Get(key))
+		}
+		removeContentType(request.Headers)
+	case mediaType == "multipart/form-data":
+		reader := multipart.NewReader(strings.NewReader(body), parameters["boundary"])
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				i.report(location, "invalid multipart body: %v", err)
+				return
+			}
+			value, err := io.ReadAll(part)
+			if err != nil {
+				i.report(location, "invalid multipart body: %v", err)
+				return
+			}
+			if part.FileName() != "" || strings.HasPrefix(string(value), "<") {
+				i.report(location, "file form field %q is not supported", part.FormName())
+				continue
+			}
+			request.MultipartBody[i.translate(location, part.FormName())] = i.translate(location, string(value))
+		}
+		removeContentType(request.Headers)
+	default:
+		i.report(location, "body of type %q is not supported", contentType)
+	}
+}
+
+// applyAuth turns the "Basic user password" and "Digest user password"
+// authorization headers, encoded by the clients, as well as the already
+// encoded basic credentials, into an auth directive
+func (i *httpFileImporter) applyAuth(request *Request) {
+	for header, value := range request.Headers {
+		if !strings.EqualFold(header, "Authorization") {
+			continue
+		}
+		credentials := strings.Fields(value)
+		if len(credentials) == 2 && strings.EqualFold(credentials[0], string(BasicAuthScheme)) {
+			decoded, err := base64.StdEncoding.DecodeString(credentials[1])
+			username, password, found := strings.Cut(string(decoded), ":")
+			if err != nil || !found {
+				return
+			}
+			credentials = []string{credentials[0], username, password}
+		}
+		if len(credentials) != 3 {
+			return
+		}
+		scheme := AuthScheme(strings.ToLower(credentials[0]))
+		if scheme != BasicAuthScheme && scheme != DigestAuthScheme {
+			return
+		}
+		request.Auth = &Auth{Scheme: scheme, Username: credentials[1], Password: credentials[2]}
+		delete(request.Headers, header)
+		return
+	}
+}
+
+func (i *httpFileImporter) importBlock(index int, block httpFileBlock) (SavedRequest, error) {
+	location := block.name
+	if location == "" {
+		location = fmt.Sprintf("request %v", index+1)
+	}
+	requestLine := strings.Fields(block.lines[0])
+	if len(requestLine) > 1 && httpFileHttpVersion.MatchString(requestLine[len(requestLine)-1]) {
+		requestLine = requestLine[:len(requestLine)-1]
+	}
+	method, rawUrl := http.MethodGet, requestLine[0]
+	if len(requestLine) > 1 {
+		method, rawUrl = requestLine[0], strings.Join(requestLine[1:], " ")
+	}
+	request := Request{
+		Method:        method,
+		Headers:       Headers{},
+		SearchParams:  SearchParams{},
+		MultipartBody: MultipartBody{},
+	}
+
+	request.Url = i.translate(location, rawUrl)
+
+	// The query can be split on the following lines, starting with ? or &
+	lines := block.lines[1:]
+	for len(lines) > 0 && (strings.HasPrefix(strings.TrimSpace(lines[0]), "?") || strings.HasPrefix(strings.TrimSpace(lines[0]), "&")) {
+		key, value, _ := strings.Cut(strings.TrimSpace(lines[0])[1:], "=")
+		request.SearchParams[i.translate(location, queryUnescape(key))] = i.translate(location, queryUnescape(value))
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) != "" {
+		line := strings.TrimSpace(lines[0])
+		lines = lines[1:]
+		if isHttpFileComment(line) {
+			continue
+		}
+		header, value, found := strings.Cut(line, ":")
+		if !found {
+			return SavedRequest{}, fmt.Errorf("invalid header line %q", line)
+		}
+		request.Headers[i.translate(location, strings.TrimSpace(header))] = i.translate(location, strings.TrimSpace(value))
+	}
+	i.applyAuth(&request)
+
+	bodyLines := []string{}
+	for _, line := range lines {
+		if strings.HasPrefix(line, ">") || strings.HasPrefix(line, "<>") {
+			i.report(location, "response handler %q is not supported", line)
+			continue
+		}
+		bodyLines = append(bodyLines, line)
+	}
+	if body := strings.TrimSpace(strings.Join(bodyLines, "\n")); body != "" {
+		i.applyBody(location, &request, body)
+	}
+
+	parsedRequest, err := reparseImportedRequest(request)
+	if err != nil {
+		return SavedRequest{}, err
+	}
+	return SavedRequest{
+		Request: parsedRequest,
+		SavedRequestMetadata: SavedRequestMetadata{
+			Name:        block.name,
+			Description: strings.Join(block.description, " "),
+		},
+	}, nil
+}
+
+// ParseHttpFile converts the requests of a .http or .rest file into saved
+// requests, translating its variables into Go template ones
+func ParseHttpFile(reader io.Reader) (HttpFile, error) {
+	fileImporter := &httpFileImporter{rawVariables: map[string]string{}}
+	httpFile := HttpFile{
+		SavedRequests: SavedRequests{},
+		Variables:     Variables{},
+	}
+	blocks, err := fileImporter.splitBlocks(reader, httpFile.Variables)
+	if err != nil {
+		return HttpFile{}, err
+	}
+	for index, block := range blocks {
+		savedRequest, err := fileImporter.importBlock(index, block)
+		if err != nil {
+			location := block.name
+			if location == "" {
+				location = fmt.Sprintf("request %v", index+1)
+			}
+			fileImporter.report(location, "request skipped, %v", err)
+			continue
+		}
+		httpFile.SavedRequests = append(httpFile.SavedRequests, savedRequest)
+	}
+	httpFile.Unsupported = fileImporter.unsupported
+	return httpFile, nil
+}
+
+type httpFileExporter struct {
+	converter
+}
+
+// untemplate turns the Go template variables back into {{variable}}, the
+// other actions having no equivalent
+func (e *httpFileExporter) untemplate(location string, text string) string {
+	return goTemplateAction.ReplaceAllStringFunc(text, func(action string) string {
+		if variable := goTemplateVariable.FindStringSubmatch(action); variable != nil {
+			return "{{" + variable[1] + "}}"
+		}
+		if strings.HasPrefix(action, "{{`") || strings.HasPrefix(action, `{{"`) {
+			if literal, err := strconv.Unquote(action[2 : len(action)-2]); err == nil {
+				return literal
+			}
+		}
+		e.report(location, "template action %v has no equivalent", action)
+		return action
+	})
+}
+
+// queryUnescape decodes the search param split on its own line, which is
+// kept as is when it is not encoded
+func queryUnescape(text string) string {
+	unescaped, err := url.QueryUnescape(text)
+	if err != nil {
+		return text
+	}
+	return unescaped
+}
+
+var httpFileVariable = regexp.MustCompile(`\{\{.*?\}\}`)
+
+// untemplateQuery encodes a search param key or value, the variables
+// excepted as the clients substitute them before sending the request
+func (e *httpFileExporter) untemplateQuery(location string, text string) string {
+	text = e.untemplate(location, text)
+	encoded := strings.Builder{}
+	position := 0
+	for _, variable := range httpFileVariable.FindAllStringIndex(text, -1) {
+		encoded.WriteString(url.QueryEscape(text[position:variable[0]]))
+		encoded.WriteString(text[variable[0]:variable[1]])
+		position = variable[1]
+	}
+	encoded.WriteString(url.QueryEscape(text[position:]))
+	return encoded.String()
+}
+
+func sortedKeys(values map[string]string) []string {
+	keys := []string{}
+	for key := range values {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// credentialsAuthorization writes the basic and digest credentials as is
+// since both clients encode them. As they are split on spaces, the basic
+// credentials holding spaces are encoded beforehand, which is only possible
+// without template actions.
+func (e *httpFileExporter) credentialsAuthorization(location string, auth Auth) (string, bool) {
+	scheme := "Basic"
+	if auth.Scheme == DigestAuthScheme {
+		scheme = "Digest"
+	}
+	if !strings.ContainsAny(auth.Username+auth.Password, " \t\r\n") && auth.Username != "" && auth.Password != "" {
+		return fmt.Sprintf("%v %v %v", scheme, auth.Username, auth.Password), true
+	}
+	if auth.Scheme == BasicAuthScheme && !goTemplateAction.MatchString(auth.Username+auth.Password) {
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(auth.Username+":"+auth.Password)), true
+	}
+	e.report(location, "%v credentials with spaces or empty values have no equivalent", auth.Scheme)
+	return "", false
+}
+
+// authElements adds the auth of the request to its search params and headers
+func (e *httpFileExporter) authElements(location string, request Request) (map[string]string, map[string]string) {
+	searchParams, headers := map[string]string{}, map[string]string{}
+	maps.Copy(searchParams, request.SearchParams)
+	maps.Copy(headers, request.Headers)
+	if request.Auth == nil {
+		return searchParams, headers
+	}
+	switch request.Auth.Scheme {
+	case BasicAuthScheme, DigestAuthScheme:
+		if authorization, ok := e.credentialsAuthorization(location, *request.Auth); ok {
+			headers["Authorization"] = authorization
+		}
+	case BearerAuthScheme:
+		headers["Authorization"] = "Bearer " + request.Auth.Token
+	case ApiKeyAuthScheme:
+		if request.Auth.KeyLocation == QueryApiKeyLocation {
+			searchParams[request.Auth.KeyName] = request.Auth.Token
+		} else {
+			headers[request.Auth.KeyName] = request.Auth.Token
+		}
+	}
+	return searchParams, headers
+}
+
+func (e *httpFileExporter) writeRequest(builder *strings.Builder, savedRequest SavedRequest) {
+	location := savedRequest.Name
+	if location == "" {
+		location = savedRequest.Request.String()
+	}
+	fmt.Fprintln(builder, strings.TrimSpace(httpFileSeparator+" "+savedRequest.Name))
+	if savedRequest.Description != "" {
+		fmt.Fprintf(builder, "# %v\n", savedRequest.Description)
+	}
+	fmt.Fprintf(builder, "%v %v\n", savedRequest.Method, e.untemplate(location, savedRequest.Url))
+
+	searchParams, headers := e.authElements(location, savedRequest.Request)
+	if savedRequest.Auth != nil && savedRequest.Auth.Scheme == OAuth2AuthScheme {
+		e.report(location, "oauth2 auth has no equivalent")
+	}
+	querySeparator := "?"
+	if strings.Contains(savedRequest.Url, "?") {
+		querySeparator = "&"
+	}
+	for _, key := range sortedKeys(searchParams) {
+		fmt.Fprintf(builder, "    %v%v=%v\n", querySeparator, e.untemplateQuery(location, key), e.untemplateQuery(location, searchParams[key]))
+		querySeparator = "&"
+	}
+	// gogetter sets the JSON content type when executing the request, the
+	// other clients sending the body without it
+	hasContentType := slices.ContainsFunc(sortedKeys(headers), func(header string) bool {
+		return strings.EqualFold(header, "Content-Type")
+	})
+	if savedRequest.JsonBody != "" && !hasContentType {
+		headers["Content-Type"] = "application/json"
+	}
+	for _, header := range sortedKeys(headers) {
+		fmt.Fprintf(builder, "%v: %v\n", e.untemplate(location, header), e.untemplate(location, headers[header]))
+	}
+	if len(savedRequest.MultipartBody) > 0 {
+		fmt.Fprintf(builder, "Content-Type: multipart/form-data; boundary=%v\n\n", httpFileBoundary)
+		for _, field := range sortedKeys(savedRequest.MultipartBody) {
+			fmt.Fprintf(builder, "--%v\nContent-Disposition: form-data; name=%q\n\n%v\n", httpFileBoundary, e.untemplate(location, field), e.untemplate(location, savedRequest.MultipartBody[field]))
+		}
+		fmt.Fprintf(builder, "--%v--\n", httpFileBoundary)
+	}
+	if savedRequest.JsonBody != "" {
+		fmt.Fprintf(builder, "\n%v\n", e.untemplate(location, string(savedRequest.JsonBody)))
+	}
+	builder.WriteString("\n")
+}
+
+// FormatHttpFile writes the saved requests in the .http format, preceded by
+// the given variables. It also returns what has no equivalent in this format.
+func FormatHttpFile(savedRequests SavedRequests, variables Variables) (string, []string) {
+	exporter := &httpFileExporter{}
+	builder := &strings.Builder{}
+	names := []string{}
+	for name := range variables {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		fmt.Fprintf(builder, "@%v = %v\n", name, variables[name])
+	}
+	if len(names) > 0 {
+		builder.WriteString("\n")
+	}
+	for _, savedRequest := range savedRequests {
+		exporter.writeRequest(builder, savedRequest)
+	}
+	return builder.String(), exporter.unsupported
+}
@@ -0,0 +1,102 @@
+package app
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ImportedSavedRequest is a request to save in a folder of the collection
+type ImportedSavedRequest struct {
+	SavedRequest
+	Folder string
+}
+
+var (
+	mustacheVariablePattern = regexp.MustCompile(`\{\{\s*([^{}]*?)\s*\}\}`)
+	nonIdentifierCharacters = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+)
+
+// variableIdentifier turns a variable name of another tool into an identifier
+// usable in a Go template
+func variableIdentifier(name string) string {
+	identifier := nonIdentifierCharacters.ReplaceAllString(name, "_")
+	if identifier == "" || (identifier[0] >= '0' && identifier[0] <= '9') {
+		identifier = "_" + identifier
+	}
+	return identifier
+}
+
+// converter gathers what could not be converted from or to the files of other
+// tools
+type converter struct {
+	unsupported      []string
+	renamedVariables map[string]bool
+}
+
+func (c *converter) report(location string, format string, args ...any) {
+	message := fmt.Sprintf(format, args...)
+	if location != "" {
+		message = location + ": " + message
+	}
+	c.unsupported = append(c.unsupported, message)
+}
+
+func (c *converter) reportRenamedVariable(name string, identifier string) {
+	if name == identifier || c.renamedVariables[name] {
+		return
+	}
+	if c.renamedVariables == nil {
+		c.renamedVariables = map[string]bool{}
+	}
+	c.renamedVariables[name] = true
+	c.report("", "variable %q renamed %q", name, identifier)
+}
+
+// keepLiteral writes a template action printing the {{variable}} as is, as a
+// raw string so that the value does not hold quotes
+func keepLiteral(match string) string {
+	if strings.Contains(match, "`") {
+		return "{{" + strconv.Quote(match) + "}}"
+	}
+	return "{{`" + match + "`}}"
+}
+
+func (c *converter) translateVariable(location string, name string, match string) string {
+	if strings.HasPrefix(name, "$") {
+		c.report(location, "dynamic variable %v is not supported", name)
+		return keepLiteral(match)
+	}
+	identifier := variableIdentifier(name)
+	c.reportRenamedVariable(name, identifier)
+	return "{{." + identifier + "}}"
+}
+
+// translate replaces the {{variable}} of other tools with their Go template
+// equivalent, dynamic variables such as {{$guid}} being kept as is
+func (c *converter) translate(location string, text string) string {
+	return mustacheVariablePattern.ReplaceAllStringFunc(text, func(match string) string {
+		return c.translateVariable(location, mustacheVariablePattern.FindStringSubmatch(match)[1], match)
+	})
+}
+
+// removeContentType lets Execute set the multipart content type, which holds
+// the boundary
+func removeContentType(headers Headers) {
+	for header := range headers {
+		if strings.EqualFold(header, "Content-Type") {
+			delete(headers, header)
+		}
+	}
+}
+
+// reparseImportedRequest writes the request in the gogetter language, its raw
+// form being the one saved
+func reparseImportedRequest(request Request) (Request, error) {
+	raw, err := FormatRequest(request)
+	if err != nil {
+		return Request{}, err
+	}
+	return ParseRequest(raw, ExtensionMethodsOption{})
+}
@@ -0,0 +1,90 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var ErrJsonPathNotFound = errors.New("no value at this JSON path")
+
+type jsonPathSegment struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+// parseJsonPath supports the $.key.other[0]["quoted key"] subset of JSONPath
+func parseJsonPath(path string) ([]jsonPathSegment, error) {
+	rest, found := strings.CutPrefix(path, "$")
+	if !found {
+		return nil, fmt.Errorf("JSON path %q must start with $", path)
+	}
+	segments := []jsonPathSegment{}
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			end := strings.IndexAny(rest[1:], ".[")
+			if end == -1 {
+				end = len(rest) - 1
+			}
+			key := rest[1 : end+1]
+			if key == "" {
+				return nil, fmt.Errorf("empty key in JSON path %q", path)
+			}
+			segments = append(segments, jsonPathSegment{key: key})
+			rest = rest[end+1:]
+		case '[':
+			end := strings.Index(rest, "]")
+			if end == -1 {
+				return nil, fmt.Errorf("unclosed bracket in JSON path %q", path)
+			}
+			selector := rest[1:end]
+			if key, err := strconv.Unquote(strings.ReplaceAll(selector, "'", "\"")); err == nil {
+				segments = append(segments, jsonPathSegment{key: key})
+			} else if index, err := strconv.Atoi(selector); err == nil {
+				segments = append(segments, jsonPathSegment{index: index, isIndex: true})
+			} else {
+				return nil, fmt.Errorf("invalid selector %q in JSON path %q", selector, path)
+			}
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("unexpected %q in JSON path %q", rest[0], path)
+		}
+	}
+	return segments, nil
+}
+
+// evaluateJsonPath returns the value at the path of the unmarshalled JSON
+// document, negative indexes starting from the end of arrays
+func evaluateJsonPath(document any, path string) (any, error) {
+	segments, err := parseJsonPath(path)
+	if err != nil {
+		return nil, err
+	}
+	value := document
+	for _, segment := range segments {
+		if segment.isIndex {
+			array, ok := value.([]any)
+			index := segment.index
+			if index < 0 {
+				index += len(array)
+			}
+			if !ok || index < 0 || index >= len(array) {
+				return nil, ErrJsonPathNotFound
+			}
+			value = array[index]
+			continue
+		}
+		object, ok := value.(map[string]any)
+		if !ok {
+			return nil, ErrJsonPathNotFound
+		}
+		value, ok = object[segment.key]
+		if !ok {
+			return nil, ErrJsonPathNotFound
+		}
+	}
+	return value, nil
+}
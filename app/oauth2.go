@@ -0,0 +1,504 @@
+package app
+
+import (
+	"cmp"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+type OAuth2Flow string
+
+const (
+	ClientCredentialsOAuth2Flow OAuth2Flow = "client_credentials"
+	PasswordOAuth2Flow          OAuth2Flow = "password"
+	RefreshTokenOAuth2Flow      OAuth2Flow = "refresh_token"
+	AuthorizationCodeOAuth2Flow OAuth2Flow = "authorization_code"
+)
+
+const (
+	defaultOAuth2RedirectUrl = "http://127.0.0.1:8910/callback"
+	// oauth2ExpiryLeeway renews the tokens a bit before they expire, so that
+	// they do not expire on their way to the server
+	oauth2ExpiryLeeway         = 30 * time.Second
+	oauth2AuthorizationTimeout = 5 * time.Minute
+)
+
+// OAuth2Configuration describes how to get a token, its values being
+// templated with the variables of the active environment and the secrets
+// (e.g. "client_secret": "{{secret \"CLIENT_SECRET\"}}")
+type OAuth2Configuration struct {
+	Flow             OAuth2Flow `json:"flow"`
+	TokenUrl         string     `json:"token_url"`
+	AuthorizationUrl string     `json:"authorization_url,omitempty"`
+	ClientId         string     `json:"client_id"`
+	ClientSecret     string     `json:"client_secret,omitempty"`
+	Scope            string     `json:"scope,omitempty"`
+	// Username and Password are the credentials of the password flow
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	// RefreshToken is the long-lived token of the refresh token flow
+	RefreshToken string `json:"refresh_token,omitempty"`
+	// RedirectUrl is where the authorization code flow listens for the
+	// authorization server redirection, http://127.0.0.1:8910/callback by default
+	RedirectUrl string `json:"redirect_url,omitempty"`
+	// ClientAuthentication sends the client credentials with basic auth
+	// ("basic", the default) or in the request body ("body")
+	ClientAuthentication string `json:"client_authentication,omitempty"`
+}
+
+type OAuth2Configurations map[string]OAuth2Configuration
+
+// OAuth2Token is a token acquired with an OAuth2 configuration
+type OAuth2Token struct {
+	AccessToken  string    `json:"access_token"`
+	TokenType    string    `json:"token_type,omitempty"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	Scope        string    `json:"scope,omitempty"`
+	AcquiredAt   time.Time `json:"acquired_at"`
+	// ExpiresAt is zero when the server did not tell when the token expires
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+}
+
+// Expired is true when the token expires in less than the leeway, or when it
+// has no access token anymore
+func (t OAuth2Token) Expired(now time.Time) bool {
+	if t.AccessToken == "" {
+		return true
+	}
+	return !t.ExpiresAt.IsZero() && now.Add(oauth2ExpiryLeeway).After(t.ExpiresAt)
+}
+
+// authorization is the Authorization header value, bearer tokens being the
+// default when the server does not give the token type
+func (t OAuth2Token) authorization() string {
+	if t.TokenType == "" || strings.EqualFold(t.TokenType, "bearer") {
+		return "Bearer " + t.AccessToken
+	}
+	return t.TokenType + " " + t.AccessToken
+}
+
+// OAuth2Tokens caches the tokens per environment then per configuration. It
+// is shared by the copies of the gogetter, the tokens being acquired while
+// executing requests.
+type OAuth2Tokens struct {
+	mutex      sync.Mutex
+	tokens     map[string]map[string]OAuth2Token
+	savingFunc func([]byte) error
+}
+
+func (t *OAuth2Tokens) get(environment string, configuration string) (OAuth2Token, bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	token, ok := t.tokens[environment][configuration]
+	return token, ok
+}
+
+func (t *OAuth2Tokens) set(environment string, configuration string, token OAuth2Token) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.tokens[environment] == nil {
+		t.tokens[environment] = map[string]OAuth2Token{}
+	}
+	t.tokens[environment][configuration] = token
+	if t.savingFunc == nil {
+		return nil
+	}
+	content, err := json.MarshalIndent(t.tokens, "", "  ")
+	if err != nil {
+		return fmt.Errorf("oauth2 tokens marshal error: %w", err)
+	}
+	err = t.savingFunc(content)
+	if err != nil {
+		return fmt.Errorf("oauth2 tokens writing error: %w", err)
+	}
+	return nil
+}
+
+// OAuth2ConfigurationNames lists the configurations in alphabetical order
+func (g Gogetter) OAuth2ConfigurationNames() []string {
+	names := []string{}
+	for name := range g.oauth2Configurations {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// OAuth2Token returns the token cached for the configuration in the active
+// environment
+func (g Gogetter) OAuth2Token(configuration string) (OAuth2Token, bool) {
+	if g.oauth2Tokens == nil {
+		return OAuth2Token{}, false
+	}
+	return g.oauth2Tokens.get(g.activeEnvironment, configuration)
+}
+
+// templatedOAuth2Configuration templates the values of the configuration with
+// the active environment and the captured variables
+func (g Gogetter) templatedOAuth2Configuration(name string) (OAuth2Configuration, error) {
+	configuration, ok := g.oauth2Configurations[name]
+	if !ok {
+		return OAuth2Configuration{}, fmt.Errorf("unknown oauth2 configuration %q", name)
+	}
+	data, err := g.TemplateData(nil)
+	if err != nil {
+		return OAuth2Configuration{}, err
+	}
+	option := TemplatedRequestOption{Data: data, Functions: g.TemplateFunctions()}
+	for _, value := range []*string{
+		&configuration.TokenUrl,
+		&configuration.AuthorizationUrl,
+		&configuration.ClientId,
+		&configuration.ClientSecret,
+		&configuration.Scope,
+		&configuration.Username,
+		&configuration.Password,
+		&configuration.RefreshToken,
+		&configuration.RedirectUrl,
+	} {
+		*value, err = option.Apply(*value)
+		if err != nil {
+			return OAuth2Configuration{}, fmt.Errorf("oauth2 configuration %q templating error: %w", name, err)
+		}
+	}
+	return configuration, nil
+}
+
+type oauth2TokenResponseDto struct {
+	AccessToken      string      `json:"access_token"`
+	TokenType        string      `json:"token_type"`
+	RefreshToken     string      `json:"refresh_token"`
+	Scope            string      `json:"scope"`
+	ExpiresIn        json.Number `json:"expires_in"`
+	Error            string      `json:"error"`
+	ErrorDescription string      `json:"error_description"`
+}
+
+// requestToken sends the grant to the token endpoint, authenticating the
+// client as configured
+func (g Gogetter) requestToken(ctx context.Context, configuration OAuth2Configuration, grant url.Values) (OAuth2Token, error) {
+	if configuration.TokenUrl == "" {
+		return OAuth2Token{}, errors.New("missing oauth2 token url")
+	}
+	basicClientAuthentication := configuration.ClientSecret != "" && configuration.ClientAuthentication != "body"
+	if !basicClientAuthentication {
+		grant.Set("client_id", configuration.ClientId)
+		if configuration.ClientSecret != "" {
+			grant.Set("client_secret", configuration.ClientSecret)
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, configuration.TokenUrl, strings.NewReader(grant.Encode()))
+	if err != nil {
+		return OAuth2Token{}, fmt.Errorf("oauth2 token request error: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if basicClientAuthentication {
+		req.SetBasicAuth(url.QueryEscape(configuration.ClientId), url.QueryEscape(configuration.ClientSecret))
+	}
+
+	acquiredAt := time.Now()
+	response, err := g.do(req, RequestOptions{})
+	if err != nil {
+		return OAuth2Token{}, fmt.Errorf("oauth2 token request execution error: %w", err)
+	}
+	defer response.Body.Close()
+	content, err := io.ReadAll(response.Body)
+	if err != nil {
+		return OAuth2Token{}, fmt.Errorf("oauth2 token response reading error: %w", err)
+	}
+	var tokenResponse oauth2TokenResponseDto
+	err = json.Unmarshal(content, &tokenResponse)
+	if err != nil && response.StatusCode < 400 {
+		return OAuth2Token{}, fmt.Errorf("oauth2 token response unmarshal error: %w", err)
+	}
+	if response.StatusCode >= 400 || tokenResponse.Error != "" {
+		description := strings.TrimSpace(tokenResponse.Error + " " + tokenResponse.ErrorDescription)
+		if description == "" {
+			description = string(content)
+		}
+		return OAuth2Token{}, fmt.Errorf("oauth2 token request failed with status %v: %v", response.StatusCode, description)
+	}
+	if tokenResponse.AccessToken == "" {
+		return OAuth2Token{}, errors.New("oauth2 token response without access token")
+	}
+
+	token := OAuth2Token{
+		AccessToken:  tokenResponse.AccessToken,
+		TokenType:    tokenResponse.TokenType,
+		RefreshToken: tokenResponse.RefreshToken,
+		Scope:        cmp.Or(tokenResponse.Scope, configuration.Scope),
+		AcquiredAt:   acquiredAt,
+	}
+	if expiresIn, err := tokenResponse.ExpiresIn.Int64(); err == nil && expiresIn > 0 {
+		token.ExpiresAt = acquiredAt.Add(time.Duration(expiresIn) * time.Second)
+	}
+	return token, nil
+}
+
+func randomUrlSafeString(length int) (string, error) {
+	bytes := make([]byte, length)
+	_, err := rand.Read(bytes)
+	if err != nil {
+		return "", fmt.Errorf("random generation error: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
+
+type authorizationCallback struct {
+	code string
+	err  error
+}
+
+// authorizeWithPkce gets an authorization code (RFC 7636): the authorization
+// url is opened with the authorize function and the code is read from the
+// redirection, on a server listening on the redirect url
+func (g Gogetter) authorizeWithPkce(ctx context.Context, configuration OAuth2Configuration) (url.Values, error) {
+	if g.oauth2Authorize == nil {
+		return nil, errors.New("no way to open the oauth2 authorization url")
+	}
+	redirectUrl, err := url.Parse(cmp.Or(configuration.RedirectUrl, defaultOAuth2RedirectUrl))
+	if err != nil {
+		return nil, fmt.Errorf("invalid oauth2 redirect url: %w", err)
+	}
+	authorizationUrl, err := url.Parse(configuration.AuthorizationUrl)
+	if err != nil || configuration.AuthorizationUrl == "" {
+		return nil, fmt.Errorf("invalid oauth2 authorization url %q", configuration.AuthorizationUrl)
+	}
+	verifier, err := randomUrlSafeString(32)
+	if err != nil {
+		return nil, err
+	}
+	state, err := randomUrlSafeString(16)
+	if err != nil {
+		return nil, err
+	}
+	challenge := sha256.Sum256([]byte(verifier))
+
+	listener, err := net.Listen("tcp", redirectUrl.Host)
+	if err != nil {
+		return nil, fmt.Errorf("oauth2 redirect listener error: %w", err)
+	}
+	callbacks := make(chan authorizationCallback, 1)
+	mux := http.NewServeMux()
+	mux.HandleFunc(cmp.Or(redirectUrl.Path, "/"), func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		callback := authorizationCallback{code: query.Get("code")}
+		switch {
+		case query.Get("state") != state:
+			callback.err = errors.New("oauth2 authorization state mismatch")
+		case query.Get("error") != "":
+			callback.err = fmt.Errorf("oauth2 authorization denied: %v", strings.TrimSpace(query.Get("error")+" "+query.Get("error_description")))
+		case callback.code == "":
+			callback.err = errors.New("oauth2 authorization without code")
+		}
+		if callback.err != nil {
+			http.Error(w, callback.err.Error(), http.StatusBadRequest)
+		} else {
+			fmt.Fprintln(w, "Authorization received, you can close this window.")
+		}
+		select {
+		case callbacks <- callback:
+		default:
+		}
+	})
+	server := &http.Server{Handler: mux}
+	go server.Serve(listener)
+	defer server.Shutdown(context.Background())
+
+	query := authorizationUrl.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", configuration.ClientId)
+	query.Set("redirect_uri", redirectUrl.String())
+	query.Set("state", state)
+	query.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	query.Set("code_challenge_method", "S256")
+	if configuration.Scope != "" {
+		query.Set("scope", configuration.Scope)
+	}
+	authorizationUrl.RawQuery = query.Encode()
+	err = g.oauth2Authorize(authorizationUrl.String())
+	if err != nil {
+		return nil, fmt.Errorf("oauth2 authorization error: %w", err)
+	}
+
+	select {
+	case callback := <-callbacks:
+		if callback.err != nil {
+			return nil, callback.err
+		}
+		return url.Values{
+			"grant_type":    {"authorization_code"},
+			"code":          {callback.code},
+			"redirect_uri":  {redirectUrl.String()},
+			"code_verifier": {verifier},
+			"client_id":     {configuration.ClientId},
+		}, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("oauth2 authorization aborted: %w", ctx.Err())
+	case <-time.After(oauth2AuthorizationTimeout):
+		return nil, fmt.Errorf("oauth2 authorization not received after %v", oauth2AuthorizationTimeout)
+	}
+}
+
+// grant builds the token request of the flow
+func (g Gogetter) grant(ctx context.Context, configuration OAuth2Configuration) (url.Values, error) {
+	grant := url.Values{"grant_type": {string(configuration.Flow)}}
+	if configuration.Scope != "" {
+		grant.Set("scope", configuration.Scope)
+	}
+	switch configuration.Flow {
+	case ClientCredentialsOAuth2Flow:
+		return grant, nil
+	case PasswordOAuth2Flow:
+		grant.Set("username", configuration.Username)
+		grant.Set("password", configuration.Password)
+		return grant, nil
+	case RefreshTokenOAuth2Flow:
+		grant.Set("refresh_token", configuration.RefreshToken)
+		return grant, nil
+	case AuthorizationCodeOAuth2Flow:
+		return g.authorizeWithPkce(ctx, configuration)
+	}
+	return nil, fmt.Errorf("unknown oauth2 flow %q, expected client_credentials, password, refresh_token or authorization_code", configuration.Flow)
+}
+
+// AcquireOAuth2Token returns the cached token of the configuration while it is
+// valid. Otherwise it is refreshed, when the server gave a refresh token, or
+// acquired again with the configured flow. The acquired token is cached for
+// the active environment.
+func (g Gogetter) AcquireOAuth2Token(ctx context.Context, name string) (OAuth2Token, error) {
+	if g.oauth2Tokens == nil {
+		return OAuth2Token{}, fmt.Errorf("unknown oauth2 configuration %q", name)
+	}
+	cachedToken, cached := g.oauth2Tokens.get(g.activeEnvironment, name)
+	if cached && !cachedToken.Expired(time.Now()) {
+		return cachedToken, nil
+	}
+	configuration, err := g.templatedOAuth2Configuration(name)
+	if err != nil {
+		return OAuth2Token{}, err
+	}
+
+	// The flow is run again when the refresh token is rejected (e.g. expired)
+	refreshToken := cmp.Or(cachedToken.RefreshToken, configuration.RefreshToken)
+	token, err := OAuth2Token{}, errors.New("no refresh token")
+	if refreshToken != "" {
+		token, err = g.requestToken(ctx, configuration, url.Values{"grant_type": {"refresh_token"}, "refresh_token": {refreshToken}})
+	}
+	if err != nil {
+		grant, err := g.grant(ctx, configuration)
+		if err != nil {
+			return OAuth2Token{}, err
+		}
+		token, err = g.requestToken(ctx, configuration, grant)
+		if err != nil {
+			return OAuth2Token{}, err
+		}
+	}
+	token.RefreshToken = cmp.Or(token.RefreshToken, refreshToken)
+	return token, g.oauth2Tokens.set(g.activeEnvironment, name, token)
+}
+
+// invalidateOAuth2Token forgets the access token rejected by the server,
+// keeping the refresh token
+func (g Gogetter) invalidateOAuth2Token(name string) error {
+	token, ok := g.oauth2Tokens.get(g.activeEnvironment, name)
+	if !ok {
+		return nil
+	}
+	token.AccessToken = ""
+	return g.oauth2Tokens.set(g.activeEnvironment, name, token)
+}
+
+// authorizeWithOAuth2 sets the token of the configuration on the request,
+// telling whether it was just acquired
+func (g Gogetter) authorizeWithOAuth2(req *http.Request, name string) (bool, error) {
+	cachedToken, _ := g.OAuth2Token(name)
+	token, err := g.AcquireOAuth2Token(req.Context(), name)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Authorization", token.authorization())
+	return token.AccessToken != cachedToken.AccessToken, nil
+}
+
+// retryWithNewOAuth2Token sends the request again with a new token when the
+// cached one is rejected, e.g. revoked before its expiry
+func (g Gogetter) retryWithNewOAuth2Token(ctx context.Context, request Request, unauthorized *http.Response) (*http.Response, Timing, error) {
+	io.Copy(io.Discard, unauthorized.Body)
+	unauthorized.Body.Close()
+	err := g.invalidateOAuth2Token(request.Auth.Configuration)
+	if err != nil {
+		return nil, Timing{}, err
+	}
+	req, err := newHttpRequest(ctx, request)
+	if err != nil {
+		return nil, Timing{}, err
+	}
+	_, err = g.authorizeWithOAuth2(req, request.Auth.Configuration)
+	if err != nil {
+		return nil, Timing{}, err
+	}
+	req, tracer := traceRequest(g.useCookieJar(req, request))
+	response, err := g.do(req, request.Options)
+	timing := tracer.done()
+	if err != nil {
+		return nil, Timing{}, fmt.Errorf("request execution error: %w", err)
+	}
+	return response, timing, storeResponseCookies(req, response)
+}
+
+// WithOAuth2 reads the OAuth2 configurations and the tokens cached by the
+// previous sessions. Authorize opens the authorization url of the
+// authorization code flow, e.g. in a browser.
+type WithOAuth2 struct {
+	Configurations   io.Reader
+	Tokens           io.Reader
+	TokensSavingFunc func([]byte) error
+	Authorize        func(authorizationUrl string) error
+}
+
+func readJson(reader io.Reader, value any) error {
+	if reader == nil {
+		return nil
+	}
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+	if len(content) == 0 {
+		return nil
+	}
+	return json.Unmarshal(content, value)
+}
+
+func (w WithOAuth2) Apply(g Gogetter) (Gogetter, error) {
+	configurations := OAuth2Configurations{}
+	err := readJson(w.Configurations, &configurations)
+	if err != nil {
+		return g, fmt.Errorf("oauth2 configurations reading error: %w", err)
+	}
+	tokens := map[string]map[string]OAuth2Token{}
+	err = readJson(w.Tokens, &tokens)
+	if err != nil {
+		return g, fmt.Errorf("oauth2 tokens reading error: %w", err)
+	}
+	g.oauth2Configurations = configurations
+	g.oauth2Tokens = &OAuth2Tokens{tokens: tokens, savingFunc: w.TokensSavingFunc}
+	g.oauth2Authorize = w.Authorize
+	return g, nil
+}
@@ -0,0 +1,447 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// OpenApiSpecification holds the requests generated from the operations of an
+// OpenAPI 3 document
+type OpenApiSpecification struct {
+	Title         string
+	SavedRequests []ImportedSavedRequest
+	// Variables holds the base URL of the first server
+	Variables Variables
+	// Unsupported describes the parts of the document that were not imported
+	Unsupported []string
+}
+
+const (
+	openApiBaseUrlVariable   = "baseUrl"
+	openApiComponentRefStart = "#/components/"
+)
+
+// openApiTemplateParameter matches the {parameters} of the paths and the
+// {variables} of the server URLs
+var openApiTemplateParameter = regexp.MustCompile(`\{([^{}]+)\}`)
+
+type openApiSchema struct {
+	Ref        string                    `yaml:"$ref"`
+	Type       any                       `yaml:"type"`
+	Format     string                    `yaml:"format"`
+	Properties map[string]*openApiSchema `yaml:"properties"`
+	Items      *openApiSchema            `yaml:"items"`
+	Example    any                       `yaml:"example"`
+	Default    any                       `yaml:"default"`
+	Enum       []any                     `yaml:"enum"`
+	AllOf      []*openApiSchema          `yaml:"allOf"`
+	OneOf      []*openApiSchema          `yaml:"oneOf"`
+	AnyOf      []*openApiSchema          `yaml:"anyOf"`
+}
+
+// schemaType returns the first non null type, OpenAPI 3.1 allowing a list
+func (s *openApiSchema) schemaType() string {
+	switch schemaType := s.Type.(type) {
+	case string:
+		return schemaType
+	case []any:
+		for _, oneType := range schemaType {
+			if oneType != "null" {
+				return fmt.Sprint(oneType)
+			}
+		}
+	}
+	if len(s.Properties) > 0 {
+		return "object"
+	}
+	return ""
+}
+
+type openApiParameter struct {
+	Ref      string         `yaml:"$ref"`
+	Name     string         `yaml:"name"`
+	In       string         `yaml:"in"`
+	Required bool           `yaml:"required"`
+	Schema   *openApiSchema `yaml:"schema"`
+	Example  any            `yaml:"example"`
+}
+
+type openApiMediaType struct {
+	Schema  *openApiSchema `yaml:"schema"`
+	Example any            `yaml:"example"`
+}
+
+type openApiRequestBody struct {
+	Ref     string                      `yaml:"$ref"`
+	Content map[string]openApiMediaType `yaml:"content"`
+}
+
+type openApiOperation struct {
+	OperationId string              `yaml:"operationId"`
+	Summary     string              `yaml:"summary"`
+	Description string              `yaml:"description"`
+	Tags        []string            `yaml:"tags"`
+	Parameters  []openApiParameter  `yaml:"parameters"`
+	RequestBody *openApiRequestBody `yaml:"requestBody"`
+}
+
+type openApiPathItem struct {
+	Parameters []openApiParameter `yaml:"parameters"`
+	Get        *openApiOperation  `yaml:"get"`
+	Put        *openApiOperation  `yaml:"put"`
+	Post       *openApiOperation  `yaml:"post"`
+	Delete     *openApiOperation  `yaml:"delete"`
+	Options    *openApiOperation  `yaml:"options"`
+	Head       *openApiOperation  `yaml:"head"`
+	Patch      *openApiOperation  `yaml:"patch"`
+	Trace      *openApiOperation  `yaml:"trace"`
+}
+
+type openApiMethodOperation struct {
+	method    string
+	operation *openApiOperation
+}
+
+// operations lists the operations of the path by method, in the order of
+// the specification
+func (p openApiPathItem) operations() []openApiMethodOperation {
+	return []openApiMethodOperation{
+		{http.MethodGet, p.Get},
+		{http.MethodPut, p.Put},
+		{http.MethodPost, p.Post},
+		{http.MethodDelete, p.Delete},
+		{http.MethodOptions, p.Options},
+		{http.MethodHead, p.Head},
+		{http.MethodPatch, p.Patch},
+		{http.MethodTrace, p.Trace},
+	}
+}
+
+type openApiDocument struct {
+	OpenApi string `yaml:"openapi"`
+	Info    struct {
+		Title string `yaml:"title"`
+	} `yaml:"info"`
+	Servers []struct {
+		Url       string `yaml:"url"`
+		Variables map[string]struct {
+			Default string `yaml:"default"`
+		} `yaml:"variables"`
+	} `yaml:"servers"`
+	Paths      map[string]openApiPathItem `yaml:"paths"`
+	Components struct {
+		Schemas       map[string]*openApiSchema      `yaml:"schemas"`
+		Parameters    map[string]*openApiParameter   `yaml:"parameters"`
+		RequestBodies map[string]*openApiRequestBody `yaml:"requestBodies"`
+	} `yaml:"components"`
+}
+
+type openApiImporter struct {
+	converter
+	document openApiDocument
+}
+
+// componentName returns the name of a local component reference of the given
+// kind (e.g. #/components/schemas/Pet)
+func (i *openApiImporter) componentName(location string, ref string, kind string) string {
+	name, found := strings.CutPrefix(ref, openApiComponentRefStart+kind+"/")
+	if !found {
+		i.report(location, "reference %v is not supported", ref)
+		return ""
+	}
+	return name
+}
+
+func (i *openApiImporter) resolveSchema(location string, schema *openApiSchema) *openApiSchema {
+	if schema == nil || schema.Ref == "" {
+		return schema
+	}
+	name := i.componentName(location, schema.Ref, "schemas")
+	return i.document.Components.Schemas[name]
+}
+
+func (i *openApiImporter) resolveParameter(location string, parameter openApiParameter) (openApiParameter, bool) {
+	if parameter.Ref == "" {
+		return parameter, true
+	}
+	resolved, ok := i.document.Components.Parameters[i.componentName(location, parameter.Ref, "parameters")]
+	if !ok || resolved == nil {
+		return openApiParameter{}, false
+	}
+	return *resolved, true
+}
+
+func (i *openApiImporter) resolveRequestBody(location string, requestBody *openApiRequestBody) *openApiRequestBody {
+	if requestBody == nil || requestBody.Ref == "" {
+		return requestBody
+	}
+	return i.document.Components.RequestBodies[i.componentName(location, requestBody.Ref, "requestBodies")]
+}
+
+// example synthesizes a value from the examples, defaults and types of the
+// schema. The references being expanded are followed only once, to stop on
+// recursive schemas.
+func (i *openApiImporter) example(location string, schema *openApiSchema, expandedRefs []string) any {
+	if schema != nil && schema.Ref != "" {
+		if slices.Contains(expandedRefs, schema.Ref) {
+			return nil
+		}
+		expandedRefs = append(slices.Clone(expandedRefs), schema.Ref)
+	}
+	schema = i.resolveSchema(location, schema)
+	if schema == nil {
+		return nil
+	}
+	if schema.Example != nil {
+		return schema.Example
+	}
+	if schema.Default != nil {
+		return schema.Default
+	}
+	if len(schema.Enum) > 0 {
+		return schema.Enum[0]
+	}
+	if len(schema.AllOf) > 0 {
+		merged := map[string]any{}
+		for _, subSchema := range schema.AllOf {
+			if object, ok := i.example(location, subSchema, expandedRefs).(map[string]any); ok {
+				for key, value := range object {
+					merged[key] = value
+				}
+			}
+		}
+		return merged
+	}
+	for _, alternatives := range [][]*openApiSchema{schema.OneOf, schema.AnyOf} {
+		if len(alternatives) > 0 {
+			return i.example(location, alternatives[0], expandedRefs)
+		}
+	}
+	switch schema.schemaType() {
+	case "object":
+		object := map[string]any{}
+		for name, property := range schema.Properties {
+			object[name] = i.example(location, property, expandedRefs)
+		}
+		return object
+	case "array":
+		item := i.example(location, schema.Items, expandedRefs)
+		if item == nil {
+			return []any{}
+		}
+		return []any{item}
+	case "integer", "number":
+		return 0
+	case "boolean":
+		return false
+	case "string":
+		switch schema.Format {
+		case "date":
+			return "2006-01-02"
+		case "date-time":
+			return "2006-01-02T15:04:05Z"
+		case "uuid":
+			return "00000000-0000-0000-0000-000000000000"
+		case "email":
+			return "user@example.com"
+		}
+		return "string"
+	}
+	return nil
+}
+
+// parameterValue uses the example of the parameter, or a template variable
+// named after it
+func (i *openApiImporter) parameterValue(location string, parameter openApiParameter) string {
+	if parameter.Example != nil {
+		return fmt.Sprint(parameter.Example)
+	}
+	if schema := i.resolveSchema(location, parameter.Schema); schema != nil {
+		if schema.Example != nil {
+			return fmt.Sprint(schema.Example)
+		}
+		if schema.Default != nil {
+			return fmt.Sprint(schema.Default)
+		}
+	}
+	return i.translateVariable(location, parameter.Name, "")
+}
+
+func (i *openApiImporter) applyRequestBody(location string, request *Request, requestBody *openApiRequestBody) {
+	requestBody = i.resolveRequestBody(location, requestBody)
+	if requestBody == nil {
+		return
+	}
+	mediaTypes := []string{}
+	for mediaType := range requestBody.Content {
+		mediaTypes = append(mediaTypes, mediaType)
+	}
+	slices.Sort(mediaTypes)
+	for _, mediaType := range mediaTypes {
+		content := requestBody.Content[mediaType]
+		switch {
+		case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+			example := content.Example
+			if example == nil {
+				example = i.example(location, content.Schema, nil)
+			}
+			body, err := json.MarshalIndent(example, "", "  ")
+			if err != nil {
+				i.report(location, "example body error: %v", err)
+				return
+			}
+			if !strings.HasPrefix(string(body), string(JSON_OBJECT_START)) && !strings.HasPrefix(string(body), string(JSON_ARRAY_START)) {
+				i.report(location, "JSON body other than an object or an array is not supported")
+				return
+			}
+			request.JsonBody = JsonBody(body)
+			if mediaType != "application/json" {
+				request.Headers["Content-Type"] = mediaType
+			}
+			return
+		case mediaType == "multipart/form-data" || mediaType == "application/x-www-form-urlencoded":
+			if mediaType == "application/x-www-form-urlencoded" {
+				i.report(location, "url encoded body is sent as multipart form data")
+			}
+			schema := i.resolveSchema(location, content.Schema)
+			if schema == nil {
+				return
+			}
+			for name, property := range schema.Properties {
+				if property := i.resolveSchema(location, property); property != nil && property.Format == "binary" {
+					i.report(location, "file form field %q is not supported", name)
+					continue
+				}
+				request.MultipartBody[name] = fmt.Sprint(i.example(location, property, nil))
+			}
+			return
+		}
+	}
+	i.report(location, "request body of type %v is not supported", strings.Join(mediaTypes, ", "))
+}
+
+func (i *openApiImporter) importOperation(method string, path string, pathItem openApiPathItem, operation *openApiOperation) (SavedRequest, error) {
+	location := method + " " + path
+	request := Request{
+		Method: method,
+		Url: "{{." + openApiBaseUrlVariable + "}}" + openApiTemplateParameter.ReplaceAllStringFunc(path, func(match string) string {
+			return i.translateVariable(location, openApiTemplateParameter.FindStringSubmatch(match)[1], match)
+		}),
+		Headers:       Headers{},
+		SearchParams:  SearchParams{},
+		MultipartBody: MultipartBody{},
+	}
+
+	// The parameters of the operation override the ones of the path
+	parameters := map[string]openApiParameter{}
+	for _, parameter := range append(slices.Clone(pathItem.Parameters), operation.Parameters...) {
+		parameter, ok := i.resolveParameter(location, parameter)
+		if !ok {
+			i.report(location, "unknown parameter reference %v", parameter.Ref)
+			continue
+		}
+		parameters[parameter.In+":"+parameter.Name] = parameter
+	}
+	for _, parameter := range parameters {
+		if !parameter.Required {
+			continue
+		}
+		switch parameter.In {
+		case "query":
+			request.SearchParams[parameter.Name] = i.parameterValue(location, parameter)
+		case "header":
+			request.Headers[parameter.Name] = i.parameterValue(location, parameter)
+		case "cookie":
+			i.report(location, "cookie parameter %q is not supported", parameter.Name)
+		}
+	}
+	i.applyRequestBody(location, &request, operation.RequestBody)
+
+	parsedRequest, err := reparseImportedRequest(request)
+	if err != nil {
+		return SavedRequest{}, err
+	}
+	name := operation.Summary
+	if name == "" {
+		name = operation.OperationId
+	}
+	return SavedRequest{
+		Request: parsedRequest,
+		SavedRequestMetadata: SavedRequestMetadata{
+			Name:        name,
+			Description: operation.Description,
+			Tags:        operation.Tags,
+		},
+	}, nil
+}
+
+func (i *openApiImporter) baseUrl() string {
+	if len(i.document.Servers) == 0 {
+		i.report("", "no server, the %v variable has to be set", openApiBaseUrlVariable)
+		return ""
+	}
+	if len(i.document.Servers) > 1 {
+		i.report("", "only the first server is imported")
+	}
+	server := i.document.Servers[0]
+	return strings.TrimSuffix(openApiTemplateParameter.ReplaceAllStringFunc(server.Url, func(match string) string {
+		return server.Variables[openApiTemplateParameter.FindStringSubmatch(match)[1]].Default
+	}), "/")
+}
+
+// ParseOpenApiSpecification generates a saved request per operation of an
+// OpenAPI 3 document, written in YAML or JSON. The requests are put in a
+// folder named after their first tag.
+func ParseOpenApiSpecification(reader io.Reader) (OpenApiSpecification, error) {
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return OpenApiSpecification{}, fmt.Errorf("openapi document reading error: %w", err)
+	}
+	specificationImporter := &openApiImporter{}
+	err = yaml.Unmarshal(content, &specificationImporter.document)
+	if err != nil {
+		return OpenApiSpecification{}, fmt.Errorf("openapi document unmarshal error: %w", err)
+	}
+	document := specificationImporter.document
+	if !strings.HasPrefix(document.OpenApi, "3.") {
+		return OpenApiSpecification{}, fmt.Errorf("unsupported openapi version %q, only OpenAPI 3 is supported", document.OpenApi)
+	}
+
+	specification := OpenApiSpecification{
+		Title:         document.Info.Title,
+		SavedRequests: []ImportedSavedRequest{},
+		Variables:     Variables{openApiBaseUrlVariable: specificationImporter.baseUrl()},
+	}
+	paths := []string{}
+	for path := range document.Paths {
+		paths = append(paths, path)
+	}
+	slices.Sort(paths)
+	for _, path := range paths {
+		pathItem := document.Paths[path]
+		for _, methodOperation := range pathItem.operations() {
+			if methodOperation.operation == nil {
+				continue
+			}
+			savedRequest, err := specificationImporter.importOperation(methodOperation.method, path, pathItem, methodOperation.operation)
+			if err != nil {
+				specificationImporter.report(methodOperation.method+" "+path, "request skipped, %v", err)
+				continue
+			}
+			folder := ""
+			if len(savedRequest.Tags) > 0 {
+				folder = savedRequest.Tags[0]
+			}
+			specification.SavedRequests = append(specification.SavedRequests, ImportedSavedRequest{SavedRequest: savedRequest, Folder: folder})
+		}
+	}
+	specification.Unsupported = specificationImporter.unsupported
+	return specification, nil
+}
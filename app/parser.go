@@ -3,18 +3,48 @@ package app
 import (
 	"errors"
 	"fmt"
+	"net/http"
 	"slices"
 	"strings"
 	"text/template"
 )
 
-var availableMethods = []string{"GET", "POST", "PUT", "DELETE"}
+// availableMethods lists the RFC 9110 methods plus PATCH (RFC 5789).
+var availableMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+	http.MethodConnect,
+	http.MethodOptions,
+	http.MethodTrace,
+}
+
+const methodTokenSpecialChars = "!#$%&'*+-.^_`|~"
 
-func extractMethod(firstInputElement string) (string, error) {
-	if slices.Index(availableMethods, firstInputElement) == -1 {
-		return "", errors.New("invalid request, provide a valid method")
+func isValidMethodToken(method string) bool {
+	if method == "" {
+		return false
+	}
+	for _, char := range method {
+		isAlphaNumeric := (char >= 'a' && char <= 'z') || (char >= 'A' && char <= 'Z') || (char >= '0' && char <= '9')
+		if !isAlphaNumeric && !strings.ContainsRune(methodTokenSpecialChars, char) {
+			return false
+		}
 	}
-	return firstInputElement, nil
+	return true
+}
+
+func extractMethod(firstInputElement string, extensionMethods *ExtensionMethodsOption) (string, error) {
+	if slices.Contains(availableMethods, firstInputElement) {
+		return firstInputElement, nil
+	}
+	if extensionMethods != nil && extensionMethods.allows(firstInputElement) {
+		return firstInputElement, nil
+	}
+	return "", errors.New("invalid request, provide a valid method")
 }
 
 type keyword string
@@ -25,14 +55,37 @@ const (
 	FORM_DATA         keyword = "="
 	JSON_OBJECT_START keyword = "{"
 	JSON_ARRAY_START  keyword = "["
+	ASSERTION         keyword = "assert"
+	CAPTURE           keyword = "capture"
+	AUTH              keyword = "auth"
+	OPTION            keyword = "option"
 )
 
+// keyValueSeparator finds the separator of a key/value element, the first
+// "=" telling which one it is so that values may contain the others (e.g. a
+// base64 header value ending with "==")
+func keyValueSeparator(literal string) (keyword, bool) {
+	index := strings.Index(literal, string(FORM_DATA))
+	if index == -1 {
+		return "", false
+	}
+	switch {
+	case strings.HasPrefix(literal[index:], string(HEADER)):
+		return HEADER, true
+	case strings.HasPrefix(literal[index:], string(SEARCH_PARAM)):
+		return SEARCH_PARAM, true
+	}
+	return FORM_DATA, true
+}
+
+// extractKeyValuePair splits the literal on the first occurrence of the
+// separator, the rest being the value
 func extractKeyValuePair(literal string, separator string) (key string, value string) {
-	split := strings.Split(literal, separator)
-	if strings.HasPrefix(split[1], "\"") && strings.HasSuffix(split[1], "\"") {
-		return split[0], split[1][1 : len(split[1])-1]
+	key, value, _ = strings.Cut(literal, separator)
+	if len(value) > 1 && strings.HasPrefix(value, "\"") && strings.HasSuffix(value, "\"") {
+		return key, value[1 : len(value)-1]
 	}
-	return split[0], split[1]
+	return key, value
 }
 
 var separators = []string{" ", "\t", "\r", "\n"}
@@ -78,12 +131,15 @@ type RequestParsingOption interface {
 	Apply(input string) (string, error)
 }
 
+// TemplatedRequestOption executes the request as a Go template with the
+// default functions, completed or overridden by the given ones
 type TemplatedRequestOption struct {
-	Data any
+	Data      any
+	Functions template.FuncMap
 }
 
 func (o TemplatedRequestOption) Apply(input string) (string, error) {
-	tmpl, err := template.New("request").Parse(input)
+	tmpl, err := template.New("request").Funcs(templateFunctions(o.Functions)).Parse(input)
 	if err != nil {
 		return input, fmt.Errorf("template parsing error: %w", err)
 	}
@@ -96,9 +152,28 @@ func (o TemplatedRequestOption) Apply(input string) (string, error) {
 
 }
 
+// ExtensionMethodsOption allows methods outside of the standard ones (e.g.
+// PROPFIND or PURGE). Any valid method token is allowed when Methods is empty.
+type ExtensionMethodsOption struct {
+	Methods []string
+}
+
+func (o ExtensionMethodsOption) Apply(input string) (string, error) { return input, nil }
+
+func (o ExtensionMethodsOption) allows(method string) bool {
+	if !isValidMethodToken(method) {
+		return false
+	}
+	return len(o.Methods) == 0 || slices.Contains(o.Methods, method)
+}
+
 func ParseRequest(input string, options ...RequestParsingOption) (Request, error) {
 	var err error
+	var extensionMethods *ExtensionMethodsOption
 	for _, option := range options {
+		if extensionMethodsOption, ok := option.(ExtensionMethodsOption); ok {
+			extensionMethods = &extensionMethodsOption
+		}
 		input, err = option.Apply(input)
 		if err != nil {
 			return Request{}, err
@@ -108,12 +183,28 @@ func ParseRequest(input string, options ...RequestParsingOption) (Request, error
 	request := Request{
 		Raw: input,
 	}
+	input, request.Assertions, err = extractAssertions(input)
+	if err != nil {
+		return request, err
+	}
+	input, request.Captures, err = extractCaptures(input)
+	if err != nil {
+		return request, err
+	}
+	input, request.Auth, err = extractAuth(input)
+	if err != nil {
+		return request, err
+	}
+	input, request.Options, err = extractOptions(input)
+	if err != nil {
+		return request, err
+	}
 	inputElements := splitRequestInput(input)
 	if len(inputElements) < 2 {
 		return request, errors.New("invalid request, provide at least a method and the url")
 	}
 
-	method, err := extractMethod(inputElements[0])
+	method, err := extractMethod(inputElements[0], extensionMethods)
 	if err != nil {
 		return request, err
 	}
@@ -125,31 +216,28 @@ func ParseRequest(input string, options ...RequestParsingOption) (Request, error
 
 	if len(inputElements) > 2 {
 		requestAdditionalParameters := inputElements[2:]
+		// The elements are looked up in order so that the JSON body is not
+		// searched in the previous ones (e.g. a templated URL)
+		searchFrom := strings.Index(input, request.Url) + len(request.Url)
 		for _, additionalParameter := range requestAdditionalParameters {
-			if strings.Contains(additionalParameter, string(HEADER)) {
-				key, value := extractKeyValuePair(additionalParameter, string(HEADER))
-				request.Headers[key] = value
-				continue
+			position := searchFrom + strings.Index(input[searchFrom:], additionalParameter)
+			searchFrom = position + len(additionalParameter)
+			if strings.HasPrefix(additionalParameter, string(JSON_ARRAY_START)) || strings.HasPrefix(additionalParameter, string(JSON_OBJECT_START)) {
+				request.JsonBody = JsonBody(input[position:])
+				break
 			}
-			if strings.Contains(additionalParameter, string(SEARCH_PARAM)) {
-				key, value := extractKeyValuePair(additionalParameter, string(SEARCH_PARAM))
-				request.SearchParams[key] = value
+			separator, found := keyValueSeparator(additionalParameter)
+			if !found {
 				continue
 			}
-			if strings.Contains(additionalParameter, string(FORM_DATA)) {
-				key, value := extractKeyValuePair(additionalParameter, string(FORM_DATA))
+			key, value := extractKeyValuePair(additionalParameter, string(separator))
+			switch separator {
+			case HEADER:
+				request.Headers[key] = value
+			case SEARCH_PARAM:
+				request.SearchParams[key] = value
+			default:
 				request.MultipartBody[key] = value
-				continue
-			}
-			if strings.HasPrefix(additionalParameter, string(JSON_ARRAY_START)) {
-				index := strings.Index(input, string(JSON_ARRAY_START))
-				request.JsonBody = JsonBody(input[index:])
-				break
-			}
-			if strings.HasPrefix(additionalParameter, string(JSON_OBJECT_START)) {
-				index := strings.Index(input, string(JSON_OBJECT_START))
-				request.JsonBody = JsonBody(input[index:])
-				break
 			}
 		}
 	}
@@ -0,0 +1,428 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+type PostmanCollection struct {
+	Name          string
+	SavedRequests []ImportedSavedRequest
+	// Variables are the collection variables, usable as template data
+	Variables Variables
+	// Unsupported describes the parts of the collection that were not imported
+	Unsupported []string
+}
+
+type PostmanEnvironment struct {
+	Environment
+	Unsupported []string
+}
+
+// postmanDescription is either a string or an object with a content
+type postmanDescription string
+
+func (d *postmanDescription) UnmarshalJSON(data []byte) error {
+	var description string
+	if err := json.Unmarshal(data, &description); err == nil {
+		*d = postmanDescription(description)
+		return nil
+	}
+	var descriptionObject struct {
+		Content string `json:"content"`
+	}
+	if err := json.Unmarshal(data, &descriptionObject); err != nil {
+		return err
+	}
+	*d = postmanDescription(descriptionObject.Content)
+	return nil
+}
+
+// postmanStrings is either a string or an array of strings, such as the lines
+// of a script or the segments of a path
+type postmanStrings []string
+
+func (s *postmanStrings) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err == nil {
+		*s = postmanStrings{value}
+		return nil
+	}
+	var values []string
+	if err := json.Unmarshal(data, &values); err != nil {
+		return err
+	}
+	*s = values
+	return nil
+}
+
+type postmanKeyValueDto struct {
+	Key      string `json:"key"`
+	Value    any    `json:"value"`
+	Disabled bool   `json:"disabled"`
+	Type     string `json:"type"`
+}
+
+func (kv postmanKeyValueDto) value() string {
+	if kv.Value == nil {
+		return ""
+	}
+	if value, ok := kv.Value.(string); ok {
+		return value
+	}
+	return fmt.Sprint(kv.Value)
+}
+
+// postmanUrlDto is either the raw URL or its parsed elements, the query of
+// the raw URL being part of it
+type postmanUrlDto struct {
+	Raw      string               `json:"raw"`
+	Protocol string               `json:"protocol"`
+	Host     postmanStrings       `json:"host"`
+	Port     string               `json:"port"`
+	Path     postmanStrings       `json:"path"`
+	Query    []postmanKeyValueDto `json:"query"`
+}
+
+func (u *postmanUrlDto) UnmarshalJSON(data []byte) error {
+	var raw string
+	if err := json.Unmarshal(data, &raw); err == nil {
+		*u = postmanUrlDto{Raw: raw}
+		return nil
+	}
+	type urlObject postmanUrlDto
+	return json.Unmarshal(data, (*urlObject)(u))
+}
+
+func (u postmanUrlDto) String() string {
+	if u.Raw != "" {
+		return u.Raw
+	}
+	url := strings.Join(u.Host, ".")
+	if u.Port != "" {
+		url += ":" + u.Port
+	}
+	if u.Protocol != "" {
+		url = u.Protocol + "://" + url
+	}
+	if len(u.Path) > 0 {
+		url += "/" + strings.Join(u.Path, "/")
+	}
+	return url
+}
+
+type postmanBodyDto struct {
+	Mode       string               `json:"mode"`
+	Raw        string               `json:"raw"`
+	Urlencoded []postmanKeyValueDto `json:"urlencoded"`
+	Formdata   []postmanKeyValueDto `json:"formdata"`
+	Disabled   bool                 `json:"disabled"`
+}
+
+type postmanAuthDto struct {
+	Type   string               `json:"type"`
+	Bearer []postmanKeyValueDto `json:"bearer"`
+	Basic  []postmanKeyValueDto `json:"basic"`
+	Apikey []postmanKeyValueDto `json:"apikey"`
+	Digest []postmanKeyValueDto `json:"digest"`
+}
+
+func (a postmanAuthDto) attribute(attributes []postmanKeyValueDto, key string) string {
+	index := slices.IndexFunc(attributes, func(attribute postmanKeyValueDto) bool { return attribute.Key == key })
+	if index == -1 {
+		return ""
+	}
+	return attributes[index].value()
+}
+
+type postmanEventDto struct {
+	Listen string `json:"listen"`
+	Script struct {
+		Exec postmanStrings `json:"exec"`
+	} `json:"script"`
+}
+
+// postmanRequestDto is either the URL of a GET request or the full request
+type postmanRequestDto struct {
+	Method      string               `json:"method"`
+	Header      []postmanKeyValueDto `json:"header"`
+	Url         postmanUrlDto        `json:"url"`
+	Body        *postmanBodyDto      `json:"body"`
+	Auth        *postmanAuthDto      `json:"auth"`
+	Description postmanDescription   `json:"description"`
+}
+
+func (r *postmanRequestDto) UnmarshalJSON(data []byte) error {
+	var url string
+	if err := json.Unmarshal(data, &url); err == nil {
+		*r = postmanRequestDto{Url: postmanUrlDto{Raw: url}}
+		return nil
+	}
+	type requestObject postmanRequestDto
+	return json.Unmarshal(data, (*requestObject)(r))
+}
+
+// postmanItemDto is a folder when it holds items, a request otherwise
+type postmanItemDto struct {
+	Name        string             `json:"name"`
+	Description postmanDescription `json:"description"`
+	Item        []postmanItemDto   `json:"item"`
+	Request     *postmanRequestDto `json:"request"`
+	Auth        *postmanAuthDto    `json:"auth"`
+	Event       []postmanEventDto  `json:"event"`
+}
+
+type postmanCollectionDto struct {
+	Info struct {
+		Name   string `json:"name"`
+		Schema string `json:"schema"`
+	} `json:"info"`
+	Item     []postmanItemDto     `json:"item"`
+	Variable []postmanKeyValueDto `json:"variable"`
+	Auth     *postmanAuthDto      `json:"auth"`
+	Event    []postmanEventDto    `json:"event"`
+}
+
+type postmanEnvironmentDto struct {
+	Name   string `json:"name"`
+	Values []struct {
+		Key     string `json:"key"`
+		Value   any    `json:"value"`
+		Enabled *bool  `json:"enabled"`
+	} `json:"values"`
+}
+
+const postmanCollectionSchemaVersion = "v2.1"
+
+var postmanScriptDescriptions = map[string]string{"prerequest": "pre-request script", "test": "test script"}
+
+type postmanImporter struct {
+	converter
+}
+
+func (i *postmanImporter) reportScripts(location string, events []postmanEventDto) {
+	for _, event := range events {
+		if strings.TrimSpace(strings.Join(event.Script.Exec, "")) == "" {
+			continue
+		}
+		description, ok := postmanScriptDescriptions[event.Listen]
+		if !ok {
+			description = event.Listen + " script"
+		}
+		i.report(location, "%v is not supported", description)
+	}
+}
+
+func (i *postmanImporter) variables(location string, keyValues []postmanKeyValueDto) Variables {
+	variables := Variables{}
+	for _, keyValue := range keyValues {
+		if keyValue.Disabled {
+			continue
+		}
+		identifier := variableIdentifier(keyValue.Key)
+		i.reportRenamedVariable(keyValue.Key, identifier)
+		if strings.Contains(keyValue.value(), "{{") {
+			i.report(location, "variable %q references other variables, which are not resolved", keyValue.Key)
+		}
+		variables[identifier] = keyValue.Value
+	}
+	return variables
+}
+
+func (i *postmanImporter) applyAuth(location string, request *Request, auth *postmanAuthDto) {
+	if auth == nil {
+		return
+	}
+	switch auth.Type {
+	case "", "noauth", "inherit":
+	case "bearer":
+		request.Auth = &Auth{
+			Scheme: BearerAuthScheme,
+			Token:  i.translate(location, auth.attribute(auth.Bearer, "token")),
+		}
+	case "apikey":
+		request.Auth = &Auth{
+			Scheme:      ApiKeyAuthScheme,
+			KeyLocation: HeaderApiKeyLocation,
+			KeyName:     i.translate(location, auth.attribute(auth.Apikey, "key")),
+			Token:       i.translate(location, auth.attribute(auth.Apikey, "value")),
+		}
+		if auth.attribute(auth.Apikey, "in") == "query" {
+			request.Auth.KeyLocation = QueryApiKeyLocation
+		}
+	case "basic":
+		request.Auth = &Auth{
+			Scheme:   BasicAuthScheme,
+			Username: i.translate(location, auth.attribute(auth.Basic, "username")),
+			Password: i.translate(location, auth.attribute(auth.Basic, "password")),
+		}
+	case "digest":
+		request.Auth = &Auth{
+			Scheme:   DigestAuthScheme,
+			Username: i.translate(location, auth.attribute(auth.Digest, "username")),
+			Password: i.translate(location, auth.attribute(auth.Digest, "password")),
+		}
+	default:
+		i.report(location, "%v auth is not supported", auth.Type)
+	}
+}
+
+func (i *postmanImporter) applyBody(location string, request *Request, body *postmanBodyDto) {
+	if body == nil || body.Disabled {
+		return
+	}
+	switch body.Mode {
+	case "":
+	case "raw":
+		raw := strings.TrimSpace(i.translate(location, body.Raw))
+		if raw == "" {
+			return
+		}
+		if !strings.HasPrefix(raw, string(JSON_OBJECT_START)) && !strings.HasPrefix(raw, string(JSON_ARRAY_START)) {
+			i.report(location, "raw body other than JSON is not supported")
+			return
+		}
+		request.JsonBody = JsonBody(raw)
+	case "urlencoded", "formdata":
+		if body.Mode == "urlencoded" && len(body.Urlencoded) > 0 {
+			i.report(location, "url encoded body is sent as multipart form data")
+		}
+		for _, field := range append(body.Urlencoded, body.Formdata...) {
+			if field.Disabled {
+				continue
+			}
+			if field.Type == "file" {
+				i.report(location, "file form field %q is not supported", field.Key)
+				continue
+			}
+			request.MultipartBody[i.translate(location, field.Key)] = i.translate(location, field.value())
+		}
+		if len(request.MultipartBody) != 0 {
+			removeContentType(request.Headers)
+		}
+	default:
+		i.report(location, "%v body is not supported", body.Mode)
+	}
+}
+
+func (i *postmanImporter) importRequest(location string, item postmanItemDto, auth *postmanAuthDto) (SavedRequest, error) {
+	method := strings.ToUpper(item.Request.Method)
+	if method == "" {
+		method = "GET"
+	}
+	request := Request{
+		Method:        method,
+		Url:           i.translate(location, item.Request.Url.String()),
+		Headers:       Headers{},
+		SearchParams:  SearchParams{},
+		MultipartBody: MultipartBody{},
+	}
+	if item.Request.Url.Raw == "" {
+		for _, param := range item.Request.Url.Query {
+			if !param.Disabled {
+				request.SearchParams[i.translate(location, param.Key)] = i.translate(location, param.value())
+			}
+		}
+	}
+	for _, header := range item.Request.Header {
+		if !header.Disabled {
+			request.Headers[i.translate(location, header.Key)] = i.translate(location, header.value())
+		}
+	}
+	if item.Request.Auth != nil && item.Request.Auth.Type != "inherit" {
+		auth = item.Request.Auth
+	}
+	i.applyAuth(location, &request, auth)
+	i.applyBody(location, &request, item.Request.Body)
+
+	parsedRequest, err := reparseImportedRequest(request)
+	if err != nil {
+		return SavedRequest{}, err
+	}
+	description := item.Description
+	if description == "" {
+		description = item.Request.Description
+	}
+	return SavedRequest{
+		Request: parsedRequest,
+		SavedRequestMetadata: SavedRequestMetadata{
+			Name:        item.Name,
+			Description: string(description),
+		},
+	}, nil
+}
+
+// importItems walks the folders, the auth of a folder being inherited by its
+// requests
+func (i *postmanImporter) importItems(items []postmanItemDto, folders []string, auth *postmanAuthDto) []ImportedSavedRequest {
+	savedRequests := []ImportedSavedRequest{}
+	for _, item := range items {
+		location := strings.Join(append(slices.Clone(folders), item.Name), "/")
+		i.reportScripts(location, item.Event)
+		if item.Request == nil {
+			folderAuth := auth
+			if item.Auth != nil && item.Auth.Type != "inherit" {
+				folderAuth = item.Auth
+			}
+			savedRequests = append(savedRequests, i.importItems(item.Item, append(slices.Clone(folders), item.Name), folderAuth)...)
+			continue
+		}
+		savedRequest, err := i.importRequest(location, item, auth)
+		if err != nil {
+			i.report(location, "request skipped, %v", err)
+			continue
+		}
+		savedRequests = append(savedRequests, ImportedSavedRequest{SavedRequest: savedRequest, Folder: strings.Join(folders, "/")})
+	}
+	return savedRequests
+}
+
+// ParsePostmanCollection converts a Postman collection v2.1 into saved
+// requests, translating its variables into Go template ones
+func ParsePostmanCollection(reader io.Reader) (PostmanCollection, error) {
+	var collectionDto postmanCollectionDto
+	err := json.NewDecoder(reader).Decode(&collectionDto)
+	if err != nil {
+		return PostmanCollection{}, fmt.Errorf("postman collection unmarshal error: %w", err)
+	}
+	if !strings.Contains(collectionDto.Info.Schema, postmanCollectionSchemaVersion) {
+		return PostmanCollection{}, fmt.Errorf("unsupported postman collection schema %q, export the collection as v2.1", collectionDto.Info.Schema)
+	}
+
+	collectionImporter := &postmanImporter{}
+	collectionImporter.reportScripts("", collectionDto.Event)
+	collection := PostmanCollection{
+		Name:      collectionDto.Info.Name,
+		Variables: collectionImporter.variables("", collectionDto.Variable),
+	}
+	collection.SavedRequests = collectionImporter.importItems(collectionDto.Item, []string{}, collectionDto.Auth)
+	collection.Unsupported = collectionImporter.unsupported
+	return collection, nil
+}
+
+// ParsePostmanEnvironment converts a Postman environment into an environment
+// named after it
+func ParsePostmanEnvironment(reader io.Reader) (PostmanEnvironment, error) {
+	var environmentDto postmanEnvironmentDto
+	err := json.NewDecoder(reader).Decode(&environmentDto)
+	if err != nil {
+		return PostmanEnvironment{}, fmt.Errorf("postman environment unmarshal error: %w", err)
+	}
+	if environmentDto.Name == "" {
+		return PostmanEnvironment{}, errors.New("postman environment without name")
+	}
+	keyValues := []postmanKeyValueDto{}
+	for _, value := range environmentDto.Values {
+		keyValues = append(keyValues, postmanKeyValueDto{Key: value.Key, Value: value.Value, Disabled: value.Enabled != nil && !*value.Enabled})
+	}
+	environmentImporter := &postmanImporter{}
+	variables := environmentImporter.variables(environmentDto.Name, keyValues)
+	return PostmanEnvironment{
+		Environment: Environment{Name: environmentDto.Name, Variables: variables},
+		Unsupported: environmentImporter.unsupported,
+	}, nil
+}
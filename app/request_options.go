@@ -0,0 +1,141 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// RequestOptions override the settings of the gogetter for one request, they
+// are written on their own line (e.g. "option cookies off")
+type RequestOptions struct {
+	// SkipCookies neither sends the cookies of the jar nor stores the ones of
+	// the response
+	SkipCookies bool
+	// Timeout, Redirects, Proxy and Insecure override the client settings
+	// when set, "option timeout 0" waiting forever
+	Timeout   *time.Duration
+	Redirects Redirects
+	Proxy     string
+	Insecure  *bool
+}
+
+func parseOnOff(name string, value string) (bool, error) {
+	switch value {
+	case "on":
+		return true, nil
+	case "off":
+		return false, nil
+	}
+	return false, fmt.Errorf("invalid %v option value %q, expected on or off", name, value)
+}
+
+func formatOnOff(value bool) string {
+	if value {
+		return "on"
+	}
+	return "off"
+}
+
+// parseRequestOption sets the option of the "option <name> <value>" line
+func (o *RequestOptions) parseRequestOption(line string) error {
+	elements := strings.Fields(line)
+	if len(elements) != 3 || elements[0] != string(OPTION) {
+		return fmt.Errorf("invalid option %q, expected option <name> <value>", line)
+	}
+	name, value := elements[1], elements[2]
+	switch name {
+	case "cookies":
+		cookies, err := parseOnOff(name, value)
+		if err != nil {
+			return err
+		}
+		o.SkipCookies = !cookies
+		return nil
+	case "timeout":
+		timeout, err := time.ParseDuration(value)
+		if err != nil || timeout < 0 {
+			return fmt.Errorf("invalid timeout option value %q, expected a duration such as 30s", value)
+		}
+		o.Timeout = &timeout
+		return nil
+	case "redirects":
+		redirects, err := ParseRedirects(value)
+		if err != nil {
+			return err
+		}
+		o.Redirects = redirects
+		return nil
+	case "proxy":
+		err := validateProxy(value)
+		if err != nil {
+			return err
+		}
+		o.Proxy = value
+		return nil
+	case "insecure":
+		insecure, err := parseOnOff(name, value)
+		if err != nil {
+			return err
+		}
+		o.Insecure = &insecure
+		return nil
+	}
+	return fmt.Errorf("unknown option %q", name)
+}
+
+// lines writes the options back in the gogetter language, omitting the
+// default ones
+func (o RequestOptions) lines() []string {
+	lines := []string{}
+	if o.SkipCookies {
+		lines = append(lines, string(OPTION)+" cookies off")
+	}
+	if o.Timeout != nil {
+		lines = append(lines, fmt.Sprintf("%v timeout %v", OPTION, *o.Timeout))
+	}
+	if o.Redirects != "" {
+		lines = append(lines, fmt.Sprintf("%v redirects %v", OPTION, o.Redirects))
+	}
+	if o.Proxy != "" {
+		lines = append(lines, fmt.Sprintf("%v proxy %v", OPTION, o.Proxy))
+	}
+	if o.Insecure != nil {
+		lines = append(lines, fmt.Sprintf("%v insecure %v", OPTION, formatOnOff(*o.Insecure)))
+	}
+	return lines
+}
+
+// clientSettings overrides the client settings with the options
+func (o RequestOptions) clientSettings(settings ClientSettings) ClientSettings {
+	if o.Timeout != nil {
+		settings.Timeout = *o.Timeout
+	}
+	if o.Redirects != "" {
+		settings.Redirects = o.Redirects
+	}
+	if o.Proxy != "" {
+		settings.Proxy = o.Proxy
+	}
+	if o.Insecure != nil {
+		settings.Insecure = *o.Insecure
+	}
+	return settings
+}
+
+// extractOptions removes the option lines from the request input
+func extractOptions(input string) (string, RequestOptions, error) {
+	options := RequestOptions{}
+	lines := []string{}
+	for _, line := range strings.Split(input, "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), string(OPTION)+" ") {
+			lines = append(lines, line)
+			continue
+		}
+		err := options.parseRequestOption(strings.TrimSpace(line))
+		if err != nil {
+			return input, RequestOptions{}, err
+		}
+	}
+	return strings.Join(lines, "\n"), options, nil
+}
@@ -5,17 +5,101 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
 )
 
-type SavedRequests []Request
+type SavedRequestMetadata struct {
+	Name        string
+	Description string
+	Tags        []string
+}
+
+type SavedRequest struct {
+	Request
+	SavedRequestMetadata
+	// Path locates the request in a collection (e.g. "users/create.gg"), it is
+	// empty when saved requests are not stored in a collection
+	Path string
+}
+
+type SavedRequests []SavedRequest
 
 type SavedRequestsWritingDto []string
 
+const (
+	metadataPrefix      = "# "
+	nameMetadata        = "name"
+	descriptionMetadata = "description"
+	tagsMetadata        = "tags"
+)
+
+// encodeSavedRequest writes the metadata as comment lines before the request
+func encodeSavedRequest(savedRequest SavedRequest) string {
+	builder := strings.Builder{}
+	writeMetadata := func(key string, value string) {
+		value = strings.Join(strings.Fields(value), " ")
+		if value != "" {
+			fmt.Fprintf(&builder, "%v%v: %v\n", metadataPrefix, key, value)
+		}
+	}
+	writeMetadata(nameMetadata, savedRequest.Name)
+	writeMetadata(descriptionMetadata, savedRequest.Description)
+	writeMetadata(tagsMetadata, strings.Join(savedRequest.Tags, ", "))
+	builder.WriteString(savedRequest.Raw)
+	return builder.String()
+}
+
+func decodeSavedRequest(encoded string) (SavedRequest, error) {
+	savedRequest := SavedRequest{}
+	rest := encoded
+	for strings.HasPrefix(rest, metadataPrefix) {
+		line, nextLines, _ := strings.Cut(rest, "\n")
+		key, value, found := strings.Cut(strings.TrimPrefix(line, metadataPrefix), ":")
+		if !found {
+			break
+		}
+		value = strings.TrimSpace(value)
+		switch strings.TrimSpace(key) {
+		case nameMetadata:
+			savedRequest.Name = value
+		case descriptionMetadata:
+			savedRequest.Description = value
+		case tagsMetadata:
+			savedRequest.Tags = ParseTags(value)
+		default:
+			return SavedRequest{}, fmt.Errorf("unknown saved request metadata %q", key)
+		}
+		rest = nextLines
+	}
+	// Requests were already validated when saved, extension methods included
+	request, err := ParseRequest(rest, ExtensionMethodsOption{})
+	if err != nil {
+		return SavedRequest{}, err
+	}
+	savedRequest.Request = request
+	return savedRequest, nil
+}
+
+// ParseTags splits comma separated tags
+func ParseTags(tags string) []string {
+	parsedTags := []string{}
+	for _, tag := range strings.Split(tags, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag != "" && !slices.Contains(parsedTags, tag) {
+			parsedTags = append(parsedTags, tag)
+		}
+	}
+	return parsedTags
+}
+
 func (g Gogetter) writeSavedRequests() error {
 	savedRequests := SavedRequestsWritingDto{}
 
 	for _, savedRequest := range g.savedRequests {
-		savedRequests = append(savedRequests, savedRequest.Raw)
+		savedRequests = append(savedRequests, encodeSavedRequest(savedRequest))
 	}
 	toWrite, err := json.Marshal(savedRequests)
 	if err != nil {
@@ -29,7 +113,43 @@ func (g Gogetter) writeSavedRequests() error {
 }
 
 func (g Gogetter) SaveRequest(request Request) (Gogetter, error) {
-	g.savedRequests = append(g.savedRequests, request)
+	return g.AddSavedRequest(SavedRequest{Request: request}, "")
+}
+
+// AddSavedRequest saves the request in a folder of the collection, the folder
+// and the path of the saved request are ignored when saved requests are not
+// stored in a collection
+func (g Gogetter) AddSavedRequest(savedRequest SavedRequest, folder string) (Gogetter, error) {
+	savedRequest.Path = ""
+	if g.savedRequestsCollection != nil {
+		savedRequest.Path = g.savedRequests.newPath(savedRequest, folder)
+		err := g.savedRequestsCollection.Write(SavedRequestWritingDto{Path: savedRequest.Path, Request: encodeSavedRequest(savedRequest)})
+		if err != nil {
+			return g, fmt.Errorf("saved request writing error: %w", err)
+		}
+	}
+	g.savedRequests = append(slices.Clone(g.savedRequests), savedRequest)
+	if g.requestsSavingFunc == nil {
+		return g, nil
+	}
+	return g, g.writeSavedRequests()
+}
+
+// EditSavedRequest replaces the metadata of a saved request, its file in the
+// collection keeps the same path
+func (g Gogetter) EditSavedRequest(index int, metadata SavedRequestMetadata) (Gogetter, error) {
+	if index < 0 || index >= len(g.savedRequests) {
+		return g, errors.New("cannot edit saved request, invalid index")
+	}
+	savedRequests := slices.Clone(g.savedRequests)
+	savedRequests[index].SavedRequestMetadata = metadata
+	if g.savedRequestsCollection != nil {
+		err := g.savedRequestsCollection.Write(SavedRequestWritingDto{Path: savedRequests[index].Path, Request: encodeSavedRequest(savedRequests[index])})
+		if err != nil {
+			return g, fmt.Errorf("saved request writing error: %w", err)
+		}
+	}
+	g.savedRequests = savedRequests
 	if g.requestsSavingFunc == nil {
 		return g, nil
 	}
@@ -37,10 +157,16 @@ func (g Gogetter) SaveRequest(request Request) (Gogetter, error) {
 }
 
 func (g Gogetter) RemoveSavedRequest(index int) (Gogetter, error) {
-	if index >= len(g.savedRequests) {
+	if index < 0 || index >= len(g.savedRequests) {
 		return g, errors.New("cannot remove saved request, invalid index")
 	}
-	g.savedRequests = append(g.savedRequests[:index], g.savedRequests[index+1:]...)
+	if g.savedRequestsCollection != nil {
+		err := g.savedRequestsCollection.Remove(g.savedRequests[index].Path)
+		if err != nil {
+			return g, fmt.Errorf("saved request removal error: %w", err)
+		}
+	}
+	g.savedRequests = slices.Delete(slices.Clone(g.savedRequests), index, index+1)
 	if g.requestsSavingFunc == nil {
 		return g, nil
 	}
@@ -59,11 +185,11 @@ func extractSavedRequests(reader io.Reader) (SavedRequests, error) {
 	err = json.Unmarshal(readerContent, &rawSavedRequests)
 	savedRequests := SavedRequests{}
 	for _, rawSavedRequest := range rawSavedRequests {
-		request, err := ParseRequest(rawSavedRequest)
+		savedRequest, err := decodeSavedRequest(rawSavedRequest)
 		if err != nil {
 			return nil, fmt.Errorf("saved request parsing error: %w", err)
 		}
-		savedRequests = append(savedRequests, request)
+		savedRequests = append(savedRequests, savedRequest)
 	}
 
 	return savedRequests, nil
@@ -83,3 +209,77 @@ func (w WithSavedRequests) Apply(g Gogetter) (Gogetter, error) {
 	g.requestsSavingFunc = w.RequestsSavingFunc
 	return g, nil
 }
+
+const SavedRequestExtension = ".gg"
+
+type SavedRequestWritingDto struct {
+	Path    string
+	Request string
+}
+
+// SavedRequestsCollection stores each saved request in its own file written in
+// the gogetter language
+type SavedRequestsCollection interface {
+	Load() ([]SavedRequestWritingDto, error)
+	Write(savedRequest SavedRequestWritingDto) error
+	Remove(path string) error
+}
+
+var pathUnsafeCharacters = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+
+// pathSegment turns a name into a lower case file or folder name
+func pathSegment(name string) string {
+	return strings.Trim(pathUnsafeCharacters.ReplaceAllString(strings.ToLower(name), "-"), "-.")
+}
+
+// newPath derives a file name from the name of the saved request, or its
+// method and URL, made unique among the already saved requests. The folder
+// names go through the same conversion.
+func (s SavedRequests) newPath(savedRequest SavedRequest, folder string) string {
+	name := savedRequest.Name
+	if name == "" {
+		name = savedRequest.Url
+		if parsedUrl, err := url.Parse(savedRequest.Url); err == nil && parsedUrl.Host != "" {
+			name = parsedUrl.Host + parsedUrl.Path
+		}
+		name = savedRequest.Method + "-" + name
+	}
+	name = pathSegment(name)
+	folderSegments := []string{}
+	for _, folderName := range strings.Split(folder, "/") {
+		if segment := pathSegment(folderName); segment != "" {
+			folderSegments = append(folderSegments, segment)
+		}
+	}
+	name = strings.Join(append(folderSegments, name), "/")
+
+	path := name + SavedRequestExtension
+	for i := 2; slices.ContainsFunc(s, func(savedRequest SavedRequest) bool { return savedRequest.Path == path }); i++ {
+		path = fmt.Sprintf("%v-%v%v", name, i, SavedRequestExtension)
+	}
+	return path
+}
+
+type WithSavedRequestsCollection struct {
+	Collection SavedRequestsCollection
+}
+
+func (w WithSavedRequestsCollection) Apply(g Gogetter) (Gogetter, error) {
+	rawSavedRequests, err := w.Collection.Load()
+	if err != nil {
+		return Gogetter{}, fmt.Errorf("saved requests collection loading error: %w", err)
+	}
+	savedRequests := SavedRequests{}
+	for _, rawSavedRequest := range rawSavedRequests {
+		savedRequest, err := decodeSavedRequest(rawSavedRequest.Request)
+		if err != nil {
+			return Gogetter{}, fmt.Errorf("saved request %v parsing error: %w", rawSavedRequest.Path, err)
+		}
+		savedRequest.Path = rawSavedRequest.Path
+		savedRequests = append(savedRequests, savedRequest)
+	}
+	g.savedRequests = savedRequests
+	g.savedRequestsCollection = w.Collection
+	g.requestsSavingFunc = nil
+	return g, nil
+}
@@ -0,0 +1,204 @@
+package app
+
+import (
+	"bufio"
+	"cmp"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var dotEnvLine = regexp.MustCompile(`^(?:export\s+)?([a-zA-Z_][a-zA-Z0-9_.]*)\s*=\s*(.*)$`)
+
+// ParseDotEnv reads the NAME=value lines of a .env file, ignoring blank and
+// comment lines. Double quoted values support escapes such as \n, single
+// quoted ones are taken as is.
+func ParseDotEnv(reader io.Reader) (map[string]string, error) {
+	values := map[string]string{}
+	scanner := bufio.NewScanner(reader)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		match := dotEnvLine.FindStringSubmatch(line)
+		if match == nil {
+			return nil, fmt.Errorf(".env line %v is not a NAME=value declaration", lineNumber)
+		}
+		value := match[2]
+		switch {
+		case strings.HasPrefix(value, `"`):
+			unquoted, err := strconv.Unquote(value)
+			if err != nil {
+				return nil, fmt.Errorf(".env line %v has an invalid quoted value: %w", lineNumber, err)
+			}
+			value = unquoted
+		case strings.HasPrefix(value, "'") && strings.HasSuffix(value, "'") && len(value) > 1:
+			value = value[1 : len(value)-1]
+		default:
+			value, _, _ = strings.Cut(value, " #")
+			value = strings.TrimSpace(value)
+		}
+		values[match[1]] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf(".env reading error: %w", err)
+	}
+	return values, nil
+}
+
+// minRedactedSecretLength keeps short values such as 1, true or dev from
+// being replaced everywhere in the history
+const minRedactedSecretLength = 5
+
+// Secrets are the values read with the secret and env template functions,
+// from the .env file or the process environment. They are shared by the
+// copies of the gogetter so that the values read while templating are
+// redacted from the history.
+type Secrets struct {
+	mutex  sync.Mutex
+	dotEnv map[string]string
+	// read are the values read so far, by the template action reading them
+	// (e.g. {{`API_KEY`|secret}})
+	read map[string]string
+}
+
+func newSecrets(dotEnv map[string]string) *Secrets {
+	return &Secrets{dotEnv: dotEnv, read: map[string]string{}}
+}
+
+func (s *Secrets) remember(reference string, value string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.read[reference] = value
+}
+
+// lookup returns the value of the .env file, or of the process environment
+// when the file does not declare it
+func (s *Secrets) lookup(name string) (string, error) {
+	value, ok := s.dotEnv[name]
+	if !ok {
+		value, ok = os.LookupEnv(name)
+	}
+	if !ok {
+		return "", fmt.Errorf("secret %q is neither in the .env file nor in the environment", name)
+	}
+	s.remember(secretReference(name), value)
+	return value, nil
+}
+
+// env reads the process environment like the default env function, the
+// value being redacted from the history as well
+func (s *Secrets) env(name string) string {
+	value := os.Getenv(name)
+	s.remember(envReference(name), value)
+	return value
+}
+
+// secretReference is the template action reading the secret again, without
+// spaces nor quotes so that the redacted request can still be parsed
+func secretReference(name string) string {
+	return "{{`" + name + "`|secret}}"
+}
+
+func envReference(name string) string {
+	return "{{`" + name + "`|env}}"
+}
+
+// redactedSecrets are the secrets to replace with their template action,
+// the longest values first
+type redactedSecrets []redactedSecret
+
+type redactedSecret struct {
+	reference string
+	value     string
+}
+
+// usedIn returns the secrets read so far which the templated request holds,
+// ignoring the values shorter than minRedactedSecretLength
+func (s *Secrets) usedIn(raw string) redactedSecrets {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	secrets := redactedSecrets{}
+	for reference, value := range s.read {
+		if len(value) >= minRedactedSecretLength && strings.Contains(raw, value) {
+			secrets = append(secrets, redactedSecret{reference, value})
+		}
+	}
+	slices.SortFunc(secrets, func(a, b redactedSecret) int {
+		return cmp.Or(cmp.Compare(len(b.value), len(a.value)), cmp.Compare(a.reference, b.reference))
+	})
+	return secrets
+}
+
+func (r redactedSecrets) redact(text string) string {
+	for _, secret := range r {
+		text = strings.ReplaceAll(text, secret.value, secret.reference)
+	}
+	return text
+}
+
+func (r redactedSecrets) redactValues(values map[string]string) map[string]string {
+	if values == nil {
+		return nil
+	}
+	redacted := map[string]string{}
+	for key, value := range values {
+		redacted[key] = r.redact(value)
+	}
+	return redacted
+}
+
+// redactRequestAndResponse removes the secrets the request was templated
+// with from what is kept in the history, the response headers being copied
+// as they belong to the response
+func (s *Secrets) redactRequestAndResponse(requestAndResponse RequestAndResponse) RequestAndResponse {
+	secrets := s.usedIn(requestAndResponse.Raw)
+	if len(secrets) == 0 {
+		return requestAndResponse
+	}
+	request := requestAndResponse.Request
+	request.Raw = secrets.redact(request.Raw)
+	request.Url = secrets.redact(request.Url)
+	request.Headers = secrets.redactValues(request.Headers)
+	request.SearchParams = secrets.redactValues(request.SearchParams)
+	request.MultipartBody = secrets.redactValues(request.MultipartBody)
+	request.JsonBody = JsonBody(secrets.redact(string(request.JsonBody)))
+	requestAndResponse.Request = request
+
+	if requestAndResponse.Response != nil {
+		response := *requestAndResponse.Response
+		response.Body = secrets.redact(response.Body)
+		response.Headers = http.Header{}
+		for header, values := range requestAndResponse.Response.Headers {
+			for _, value := range values {
+				response.Headers[header] = append(response.Headers[header], secrets.redact(value))
+			}
+		}
+		requestAndResponse.Response = &response
+	}
+	return requestAndResponse
+}
+
+// WithSecrets reads the secrets of a .env file, the secret template function
+// falling back on the process environment
+type WithSecrets struct {
+	DotEnv io.Reader
+}
+
+func (w WithSecrets) Apply(g Gogetter) (Gogetter, error) {
+	dotEnv, err := ParseDotEnv(w.DotEnv)
+	if err != nil {
+		return g, err
+	}
+	g.secrets = newSecrets(dotEnv)
+	return g, nil
+}
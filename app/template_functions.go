@@ -0,0 +1,155 @@
+package app
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"math/big"
+	"net/url"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const randomStringCharacters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+func newUuid() (string, error) {
+	bytes := make([]byte, 16)
+	_, err := rand.Read(bytes)
+	if err != nil {
+		return "", fmt.Errorf("uuid generation error: %w", err)
+	}
+	bytes[6] = (bytes[6] & 0x0f) | 0x40
+	bytes[8] = (bytes[8] & 0x3f) | 0x80
+	encoded := hex.EncodeToString(bytes)
+	return fmt.Sprintf("%v-%v-%v-%v-%v", encoded[0:8], encoded[8:12], encoded[12:16], encoded[16:20], encoded[20:32]), nil
+}
+
+// randomInt returns an integer between min, included, and max, excluded
+func randomInt(min int, max int) (int, error) {
+	if max <= min {
+		return 0, fmt.Errorf("randomInt max %v must be greater than min %v", max, min)
+	}
+	value, err := rand.Int(rand.Reader, big.NewInt(int64(max-min)))
+	if err != nil {
+		return 0, fmt.Errorf("random integer generation error: %w", err)
+	}
+	return min + int(value.Int64()), nil
+}
+
+func randomString(length int) (string, error) {
+	if length < 0 {
+		return "", errors.New("randomString length must be positive")
+	}
+	builder := strings.Builder{}
+	for range length {
+		index, err := randomInt(0, len(randomStringCharacters))
+		if err != nil {
+			return "", err
+		}
+		builder.WriteByte(randomStringCharacters[index])
+	}
+	return builder.String(), nil
+}
+
+func hmacSha256(key string, message string) []byte {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(message))
+	return mac.Sum(nil)
+}
+
+func base64Decode(encoded string) (string, error) {
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("base64 decoding error: %w", err)
+	}
+	return string(decoded), nil
+}
+
+func toJson(value any) (string, error) {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("json encoding error: %w", err)
+	}
+	return string(encoded), nil
+}
+
+// defaultValue returns the value, or the fallback when the value is missing or
+// empty. The value comes last so that it can be piped (e.g. {{.page | default 1}})
+func defaultValue(fallback any, value any) any {
+	if value == nil || value == "" {
+		return fallback
+	}
+	return value
+}
+
+// DefaultTemplateFunctions are the functions available in every templated
+// request, e.g. {{uuid}} or {{hmacSha256 .secret .payload}}
+func DefaultTemplateFunctions() template.FuncMap {
+	return template.FuncMap{
+		"uuid":          newUuid,
+		"now":           time.Now,
+		"timestamp":     func() int64 { return time.Now().Unix() },
+		"timestampMs":   func() int64 { return time.Now().UnixMilli() },
+		"isoTimestamp":  func() string { return time.Now().UTC().Format(time.RFC3339) },
+		"date":          func(layout string) string { return time.Now().Format(layout) },
+		"randomInt":     randomInt,
+		"randomString":  randomString,
+		"base64":        func(text string) string { return base64.StdEncoding.EncodeToString([]byte(text)) },
+		"base64Url":     func(text string) string { return base64.RawURLEncoding.EncodeToString([]byte(text)) },
+		"base64Decode":  base64Decode,
+		"urlEncode":     url.QueryEscape,
+		"urlPathEncode": url.PathEscape,
+		"sha256":        func(text string) string { return fmt.Sprintf("%x", sha256.Sum256([]byte(text))) },
+		"hmacSha256":    func(key string, message string) string { return hex.EncodeToString(hmacSha256(key, message)) },
+		"hmacSha256Base64": func(key string, message string) string {
+			return base64.StdEncoding.EncodeToString(hmacSha256(key, message))
+		},
+		"env":     os.Getenv,
+		"upper":   strings.ToUpper,
+		"lower":   strings.ToLower,
+		"trim":    strings.TrimSpace,
+		"json":    toJson,
+		"default": defaultValue,
+	}
+}
+
+// templateFunctions completes the default functions with the given ones,
+// which take precedence
+func templateFunctions(functions template.FuncMap) template.FuncMap {
+	allFunctions := DefaultTemplateFunctions()
+	maps.Copy(allFunctions, functions)
+	return allFunctions
+}
+
+// TemplateFunctions are the functions added to the default ones: secret,
+// which reads the .env file and the process environment, and the ones given
+// with WithTemplateFunctions
+func (g Gogetter) TemplateFunctions() template.FuncMap {
+	functions := template.FuncMap{}
+	if g.secrets != nil {
+		functions["secret"] = g.secrets.lookup
+		functions["env"] = g.secrets.env
+	}
+	maps.Copy(functions, g.templateFunctions)
+	return functions
+}
+
+// WithTemplateFunctions adds functions to the templated requests, or replaces
+// default ones sharing their name
+type WithTemplateFunctions struct {
+	Functions template.FuncMap
+}
+
+func (w WithTemplateFunctions) Apply(g Gogetter) (Gogetter, error) {
+	g.templateFunctions = template.FuncMap{}
+	maps.Copy(g.templateFunctions, w.Functions)
+	return g, nil
+}
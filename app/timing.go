@@ -0,0 +1,89 @@
+package app
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/http/httptrace"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Timing holds the phases of a request execution, phases that did not happen
+// (e.g. DNS and TLS on a reused connection) are left to zero.
+type Timing struct {
+	DNS             time.Duration
+	Connect         time.Duration
+	TLS             time.Duration
+	TimeToFirstByte time.Duration
+	Total           time.Duration
+}
+
+func (t Timing) String() string {
+	phases := []string{}
+	if t.DNS != 0 {
+		phases = append(phases, fmt.Sprintf("DNS %v", t.DNS))
+	}
+	if t.Connect != 0 {
+		phases = append(phases, fmt.Sprintf("connect %v", t.Connect))
+	}
+	if t.TLS != 0 {
+		phases = append(phases, fmt.Sprintf("TLS %v", t.TLS))
+	}
+	if t.TimeToFirstByte != 0 {
+		phases = append(phases, fmt.Sprintf("TTFB %v", t.TimeToFirstByte))
+	}
+	if len(phases) == 0 {
+		return fmt.Sprintf("total %v", t.Total)
+	}
+	return fmt.Sprintf("total %v (%v)", t.Total, strings.Join(phases, ", "))
+}
+
+type timingTracer struct {
+	mutex        sync.Mutex
+	start        time.Time
+	dnsStart     time.Time
+	connectStart time.Time
+	tlsStart     time.Time
+	timing       Timing
+}
+
+func (t *timingTracer) record(phase *time.Duration, since time.Time) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if since.IsZero() {
+		return
+	}
+	*phase = time.Since(since)
+}
+
+func (t *timingTracer) begin(start *time.Time) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	*start = time.Now()
+}
+
+func (t *timingTracer) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart:             func(httptrace.DNSStartInfo) { t.begin(&t.dnsStart) },
+		DNSDone:              func(httptrace.DNSDoneInfo) { t.record(&t.timing.DNS, t.dnsStart) },
+		ConnectStart:         func(string, string) { t.begin(&t.connectStart) },
+		ConnectDone:          func(string, string, error) { t.record(&t.timing.Connect, t.connectStart) },
+		TLSHandshakeStart:    func() { t.begin(&t.tlsStart) },
+		TLSHandshakeDone:     func(tls.ConnectionState, error) { t.record(&t.timing.TLS, t.tlsStart) },
+		GotFirstResponseByte: func() { t.record(&t.timing.TimeToFirstByte, t.start) },
+	}
+}
+
+func traceRequest(req *http.Request) (*http.Request, *timingTracer) {
+	tracer := &timingTracer{start: time.Now()}
+	return req.WithContext(httptrace.WithClientTrace(req.Context(), tracer.clientTrace())), tracer
+}
+
+func (t *timingTracer) done() Timing {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.timing.Total = time.Since(t.start)
+	return t.timing
+}
@@ -0,0 +1,204 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+	"time"
+)
+
+type WorkflowSettings struct {
+	// StopOnFailure skips the steps following a failing one
+	StopOnFailure bool
+	// Delay is waited between two steps
+	Delay time.Duration
+	// FailingStatusClasses fail the steps without assertions whose status
+	// matches one of them
+	FailingStatusClasses []StatusClass
+}
+
+// Workflow runs saved requests in order, each step being templated with the
+// ad-hoc variables, the active environment and the values captured by the
+// previous steps
+type Workflow struct {
+	WorkflowSettings
+	Steps          SavedRequests
+	AdHocVariables any
+	ParsingOptions []RequestParsingOption
+	// OnStep is called after each step, e.g. to report the progress
+	OnStep func(WorkflowStepResult)
+}
+
+type WorkflowStepResult struct {
+	SavedRequest
+	RequestAndResponse RequestAndResponse
+	// Failures describe the failing assertions, captures or status
+	Failures []string
+	Err      error
+	Skipped  bool
+}
+
+// Title is the name of the saved request, or its path or method and URL when
+// it has none
+func (r WorkflowStepResult) Title() string {
+	if r.Name != "" {
+		return r.Name
+	}
+	if r.Path != "" {
+		return r.Path
+	}
+	return r.Request.String()
+}
+
+// Folder is the collection folder of the saved request, empty at the root
+func (r WorkflowStepResult) Folder() string {
+	folder := path.Dir(r.Path)
+	if folder == "." {
+		return ""
+	}
+	return folder
+}
+
+func (r WorkflowStepResult) Passed() bool {
+	return !r.Skipped && r.Err == nil && len(r.Failures) == 0
+}
+
+func (r WorkflowStepResult) String() string {
+	if r.Skipped {
+		return "SKIP " + r.Title()
+	}
+	status := "PASS"
+	if !r.Passed() {
+		status = "FAIL"
+	}
+	lines := []string{fmt.Sprintf("%v %v (%v)", status, r.Title(), r.RequestAndResponse.Timing.Total.Round(time.Millisecond))}
+	if r.Err != nil {
+		lines = append(lines, fmt.Sprintf("    %v", r.Err))
+	}
+	for _, failure := range r.Failures {
+		lines = append(lines, "    "+failure)
+	}
+	return strings.Join(lines, "\n")
+}
+
+type WorkflowReport struct {
+	Steps    []WorkflowStepResult
+	Duration time.Duration
+}
+
+func (r WorkflowReport) count(predicate func(WorkflowStepResult) bool) int {
+	count := 0
+	for _, step := range r.Steps {
+		if predicate(step) {
+			count++
+		}
+	}
+	return count
+}
+
+func (r WorkflowReport) Passed() int {
+	return r.count(WorkflowStepResult.Passed)
+}
+
+func (r WorkflowReport) Failed() int {
+	return r.count(func(step WorkflowStepResult) bool { return !step.Skipped && !step.Passed() })
+}
+
+func (r WorkflowReport) Skipped() int {
+	return r.count(func(step WorkflowStepResult) bool { return step.Skipped })
+}
+
+// Summary counts the passed, failed and skipped steps
+func (r WorkflowReport) Summary() string {
+	summary := fmt.Sprintf("%v passed, %v failed", r.Passed(), r.Failed())
+	if skipped := r.Skipped(); skipped > 0 {
+		summary += fmt.Sprintf(", %v skipped", skipped)
+	}
+	return summary
+}
+
+func (r WorkflowReport) String() string {
+	lines := []string{}
+	for _, step := range r.Steps {
+		lines = append(lines, step.String())
+	}
+	lines = append(lines, fmt.Sprintf("%v in %v", r.Summary(), r.Duration.Round(time.Millisecond)))
+	return strings.Join(lines, "\n")
+}
+
+// runStep executes a saved request, which passes when all its assertions pass
+// and all its captures get a value or, without assertions, when its status
+// does not match a failing class
+func (g Gogetter) runStep(ctx context.Context, workflow Workflow, savedRequest SavedRequest) (Gogetter, WorkflowStepResult) {
+	result := WorkflowStepResult{SavedRequest: savedRequest}
+	data, err := g.TemplateData(workflow.AdHocVariables)
+	if err != nil {
+		result.Err = err
+		return g, result
+	}
+	options := append([]RequestParsingOption{TemplatedRequestOption{Data: data, Functions: g.TemplateFunctions()}}, workflow.ParsingOptions...)
+	request, err := ParseRequest(savedRequest.Raw, options...)
+	if err != nil {
+		result.Err = fmt.Errorf("request parsing error: %w", err)
+		return g, result
+	}
+	var response *http.Response
+	g, result.RequestAndResponse, response, err = g.Execute(ctx, request)
+	if response != nil && response.Body != nil {
+		response.Body.Close()
+	}
+	if err != nil {
+		result.Err = err
+		return g, result
+	}
+
+	for _, assertion := range result.RequestAndResponse.Assertions {
+		if !assertion.Passed {
+			result.Failures = append(result.Failures, assertion.String())
+		}
+	}
+	for _, capture := range result.RequestAndResponse.Captures {
+		if capture.Err != nil {
+			result.Failures = append(result.Failures, capture.String())
+		}
+	}
+	responseCode := result.RequestAndResponse.ResponseCode
+	failingStatus := slices.ContainsFunc(workflow.FailingStatusClasses, func(class StatusClass) bool { return class.Matches(responseCode) })
+	if len(result.RequestAndResponse.Assertions) == 0 && failingStatus {
+		result.Failures = append(result.Failures, fmt.Sprintf("failing status: %v", responseCode))
+	}
+	return g, result
+}
+
+// RunWorkflow executes the steps of the workflow in order and reports their
+// results. Cancelling the context aborts the running step and skips the
+// following ones.
+func (g Gogetter) RunWorkflow(ctx context.Context, workflow Workflow) (Gogetter, WorkflowReport) {
+	start := time.Now()
+	report := WorkflowReport{Steps: []WorkflowStepResult{}}
+	stopped := false
+	for index, savedRequest := range workflow.Steps {
+		if !stopped && index > 0 && workflow.Delay > 0 {
+			select {
+			case <-time.After(workflow.Delay):
+			case <-ctx.Done():
+			}
+		}
+		var result WorkflowStepResult
+		if stopped || ctx.Err() != nil {
+			result = WorkflowStepResult{SavedRequest: savedRequest, Skipped: true}
+		} else {
+			g, result = g.runStep(ctx, workflow, savedRequest)
+			stopped = workflow.StopOnFailure && !result.Passed()
+		}
+		report.Steps = append(report.Steps, result)
+		if workflow.OnStep != nil {
+			workflow.OnStep(result)
+		}
+	}
+	report.Duration = time.Since(start)
+	return g, report
+}
@@ -0,0 +1,45 @@
+package cli
+
+import (
+	"flag"
+	"time"
+
+	"github.com/ThomasFerro/gogetter/app"
+)
+
+// ClientFlags are the flags overriding the client settings of the
+// gogetter_client.json file
+type ClientFlags struct {
+	timeout           *time.Duration
+	redirects         *string
+	proxy             *string
+	caBundle          *string
+	clientCertificate *string
+	clientKey         *string
+	insecure          *bool
+}
+
+func AddClientFlags(flags *flag.FlagSet) ClientFlags {
+	return ClientFlags{
+		timeout:           flags.Duration("timeout", 0, "time waited for a response (e.g. 30s)"),
+		redirects:         flags.String("redirects", "", "redirect policy: follow (up to 10 redirects), never or a maximum number of redirects"),
+		proxy:             flags.String("proxy", "", "http, https or socks5 proxy url, off ignoring the HTTP_PROXY and HTTPS_PROXY variables"),
+		caBundle:          flags.String("ca-bundle", "", "PEM file of certificate authorities trusted along the ones of the system"),
+		clientCertificate: flags.String("cert", "", "PEM file of the client certificate sent for mutual TLS"),
+		clientKey:         flags.String("key", "", "PEM file of the key of the client certificate"),
+		insecure:          flags.Bool("insecure", false, "skip the verification of the server certificates"),
+	}
+}
+
+// Apply overrides the client settings of the gogetter with the flags set
+func (f ClientFlags) Apply(gogetter app.Gogetter) (app.Gogetter, error) {
+	return gogetter.OverrideClientSettings(app.ClientSettings{
+		Timeout:           *f.timeout,
+		Redirects:         app.Redirects(*f.redirects),
+		Proxy:             *f.proxy,
+		CaBundle:          *f.caBundle,
+		ClientCertificate: *f.clientCertificate,
+		ClientKey:         *f.clientKey,
+		Insecure:          *f.insecure,
+	})
+}
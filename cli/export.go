@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ThomasFerro/gogetter/app"
+)
+
+// savedRequestsInFolder keeps the saved requests of a folder of the
+// collection, all of them when the folder is empty
+func savedRequestsInFolder(gogetter app.Gogetter, folder string) app.SavedRequests {
+	savedRequests := app.SavedRequests{}
+	folderPrefix := strings.Trim(folder, "/") + "/"
+	for _, savedRequest := range gogetter.SavedRequests() {
+		if folderPrefix == "/" || strings.HasPrefix(savedRequest.Path, folderPrefix) {
+			savedRequests = append(savedRequests, savedRequest)
+		}
+	}
+	return savedRequests
+}
+
+// ExportHttpFile writes the saved requests in the .http format on stdout,
+// the parts without equivalent being reported on stderr
+func ExportHttpFile(gogetter app.Gogetter, args []string, stdout io.Writer, stderr io.Writer) (app.Gogetter, int) {
+	flags := flag.NewFlagSet("export-http", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: gogetter export-http [flags] > requests.http")
+		flags.PrintDefaults()
+	}
+	folder := flags.String("folder", "", "only export the requests saved in this folder of the collection")
+	environmentName := flags.String("env", "", "environment whose variables are declared at the top of the file")
+	if err := flags.Parse(args); err != nil {
+		return gogetter, UsageExitCode
+	}
+	if flags.NArg() != 0 {
+		flags.Usage()
+		return gogetter, UsageExitCode
+	}
+
+	gogetter, err := gogetter.SelectEnvironment(*environmentName)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return gogetter, UsageExitCode
+	}
+	environment, _ := gogetter.ActiveEnvironment()
+
+	content, unsupported := app.FormatHttpFile(savedRequestsInFolder(gogetter, *folder), environment.Variables)
+	_, err = io.WriteString(stdout, content)
+	if err != nil {
+		fmt.Fprintf(stderr, "http file writing error: %v\n", err)
+		return gogetter, ErrorExitCode
+	}
+	for _, message := range unsupported {
+		fmt.Fprintf(stderr, "not exported: %v\n", message)
+	}
+	return gogetter, SuccessExitCode
+}
@@ -0,0 +1,224 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ThomasFerro/gogetter/app"
+)
+
+type filenames []string
+
+func (f *filenames) String() string { return fmt.Sprint(*f) }
+
+func (f *filenames) Set(filename string) error {
+	*f = append(*f, filename)
+	return nil
+}
+
+func parseFile[T any](filename string, parse func(io.Reader) (T, error)) (T, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		var empty T
+		return empty, err
+	}
+	defer file.Close()
+	return parse(file)
+}
+
+// ImportPostman saves the requests of a Postman collection in a folder named
+// after it and its environments, completed by the collection variables. The
+// parts of the collection that could not be imported are reported on stderr.
+func ImportPostman(gogetter app.Gogetter, args []string, stdout io.Writer, stderr io.Writer) (app.Gogetter, int) {
+	flags := flag.NewFlagSet("import-postman", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: gogetter import-postman [flags] <collection file>")
+		flags.PrintDefaults()
+	}
+	environmentFilenames := filenames{}
+	flags.Var(&environmentFilenames, "environment", "Postman environment file to import, can be repeated")
+	folder := flags.String("folder", "", "folder of the collection the requests are saved in, defaults to the collection name")
+	if err := flags.Parse(args); err != nil {
+		return gogetter, UsageExitCode
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return gogetter, UsageExitCode
+	}
+
+	collection, err := parseFile(flags.Arg(0), app.ParsePostmanCollection)
+	if err != nil {
+		fmt.Fprintf(stderr, "postman collection import error: %v\n", err)
+		return gogetter, ErrorExitCode
+	}
+	environments := []app.PostmanEnvironment{}
+	for _, environmentFilename := range environmentFilenames {
+		environment, err := parseFile(environmentFilename, app.ParsePostmanEnvironment)
+		if err != nil {
+			fmt.Fprintf(stderr, "postman environment %v import error: %v\n", environmentFilename, err)
+			return gogetter, ErrorExitCode
+		}
+		environments = append(environments, environment)
+	}
+	if len(environments) == 0 && len(collection.Variables) != 0 {
+		environments = append(environments, app.PostmanEnvironment{Environment: app.Environment{Name: collection.Name}})
+	}
+
+	if *folder == "" {
+		*folder = collection.Name
+	}
+	for _, savedRequest := range collection.SavedRequests {
+		gogetter, err = gogetter.AddSavedRequest(savedRequest.SavedRequest, *folder+"/"+savedRequest.Folder)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return gogetter, ErrorExitCode
+		}
+	}
+	fmt.Fprintf(stdout, "%v requests imported from %q\n", len(collection.SavedRequests), collection.Name)
+
+	for _, environment := range environments {
+		gogetter, err = saveImportedVariables(gogetter, environment.Name, environment.WithDefaults(collection.Variables).Variables)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return gogetter, ErrorExitCode
+		}
+		fmt.Fprintf(stdout, "environment %q imported\n", environment.Name)
+	}
+
+	for _, unsupported := range collection.Unsupported {
+		fmt.Fprintf(stderr, "not imported: %v\n", unsupported)
+	}
+	for _, environment := range environments {
+		for _, unsupported := range environment.Unsupported {
+			fmt.Fprintf(stderr, "not imported: %v\n", unsupported)
+		}
+	}
+	return gogetter, SuccessExitCode
+}
+
+// saveImportedVariables adds the variables to the named environment, created
+// if needed, overriding the existing values
+func saveImportedVariables(gogetter app.Gogetter, environmentName string, variables app.Variables) (app.Gogetter, error) {
+	environment := app.Environment{Name: environmentName, Variables: variables}
+	for _, existingEnvironment := range gogetter.Environments() {
+		if existingEnvironment.Name == environment.Name {
+			environment = environment.WithDefaults(existingEnvironment.Variables)
+		}
+	}
+	return gogetter.SaveEnvironment(environment)
+}
+
+// ImportHttpFile saves the requests of a .http or .rest file in a folder named
+// after it and its variables in an environment, also named after it
+func ImportHttpFile(gogetter app.Gogetter, args []string, stdout io.Writer, stderr io.Writer) (app.Gogetter, int) {
+	flags := flag.NewFlagSet("import-http", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: gogetter import-http [flags] <.http file>")
+		flags.PrintDefaults()
+	}
+	folder := flags.String("folder", "", "folder of the collection the requests are saved in, defaults to the file name")
+	environmentName := flags.String("env", "", "environment the file variables are saved in, defaults to the file name")
+	if err := flags.Parse(args); err != nil {
+		return gogetter, UsageExitCode
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return gogetter, UsageExitCode
+	}
+
+	httpFile, err := parseFile(flags.Arg(0), app.ParseHttpFile)
+	if err != nil {
+		fmt.Fprintf(stderr, "http file import error: %v\n", err)
+		return gogetter, ErrorExitCode
+	}
+	fileName := strings.TrimSuffix(filepath.Base(flags.Arg(0)), filepath.Ext(flags.Arg(0)))
+	if *folder == "" {
+		*folder = fileName
+	}
+	if *environmentName == "" {
+		*environmentName = fileName
+	}
+
+	for _, savedRequest := range httpFile.SavedRequests {
+		gogetter, err = gogetter.AddSavedRequest(savedRequest, *folder)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return gogetter, ErrorExitCode
+		}
+	}
+	fmt.Fprintf(stdout, "%v requests imported from %v\n", len(httpFile.SavedRequests), flags.Arg(0))
+
+	if len(httpFile.Variables) != 0 {
+		gogetter, err = saveImportedVariables(gogetter, *environmentName, httpFile.Variables)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return gogetter, ErrorExitCode
+		}
+		fmt.Fprintf(stdout, "variables saved in environment %q\n", *environmentName)
+	}
+
+	for _, unsupported := range httpFile.Unsupported {
+		fmt.Fprintf(stderr, "not imported: %v\n", unsupported)
+	}
+	return gogetter, SuccessExitCode
+}
+
+// ImportOpenApi saves a request per operation of an OpenAPI 3 document in a
+// folder named after its title, the base URL being saved in an environment
+// also named after it
+func ImportOpenApi(gogetter app.Gogetter, args []string, stdout io.Writer, stderr io.Writer) (app.Gogetter, int) {
+	flags := flag.NewFlagSet("import-openapi", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: gogetter import-openapi [flags] <OpenAPI document>")
+		flags.PrintDefaults()
+	}
+	folder := flags.String("folder", "", "folder of the collection the requests are saved in, defaults to the document title")
+	environmentName := flags.String("env", "", "environment the base URL is saved in, defaults to the document title")
+	if err := flags.Parse(args); err != nil {
+		return gogetter, UsageExitCode
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return gogetter, UsageExitCode
+	}
+
+	specification, err := parseFile(flags.Arg(0), app.ParseOpenApiSpecification)
+	if err != nil {
+		fmt.Fprintf(stderr, "openapi import error: %v\n", err)
+		return gogetter, ErrorExitCode
+	}
+	if *folder == "" {
+		*folder = specification.Title
+	}
+	if *environmentName == "" {
+		*environmentName = specification.Title
+	}
+
+	for _, savedRequest := range specification.SavedRequests {
+		gogetter, err = gogetter.AddSavedRequest(savedRequest.SavedRequest, *folder+"/"+savedRequest.Folder)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return gogetter, ErrorExitCode
+		}
+	}
+	fmt.Fprintf(stdout, "%v requests imported from %q\n", len(specification.SavedRequests), specification.Title)
+
+	gogetter, err = saveImportedVariables(gogetter, *environmentName, specification.Variables)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return gogetter, ErrorExitCode
+	}
+	fmt.Fprintf(stdout, "variables saved in environment %q\n", *environmentName)
+
+	for _, unsupported := range specification.Unsupported {
+		fmt.Fprintf(stderr, "not imported: %v\n", unsupported)
+	}
+	return gogetter, SuccessExitCode
+}
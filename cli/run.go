@@ -0,0 +1,212 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ThomasFerro/gogetter/app"
+)
+
+const (
+	SuccessExitCode       = 0
+	ErrorExitCode         = 1
+	UsageExitCode         = 2
+	StatusFailureExitCode = 3
+	TestFailureExitCode   = 4
+)
+
+var (
+	ErrStatusFailure    = errors.New("response status matches a failing status class")
+	ErrAssertionFailure = errors.New("assertions failed")
+)
+
+type statusClasses []app.StatusClass
+
+func parseStatusClasses(input string) (statusClasses, error) {
+	classes := statusClasses{}
+	for _, class := range strings.Split(input, ",") {
+		if strings.TrimSpace(class) == "" {
+			continue
+		}
+		statusClass, err := app.ParseStatusClass(class)
+		if err != nil {
+			return nil, err
+		}
+		classes = append(classes, statusClass)
+	}
+	return classes, nil
+}
+
+func (s statusClasses) matches(statusCode int) bool {
+	return slices.ContainsFunc(s, func(class app.StatusClass) bool { return class.Matches(statusCode) })
+}
+
+func readRequestFile(filename string, stdin io.Reader) (string, error) {
+	if filename == "-" {
+		content, err := io.ReadAll(stdin)
+		return string(content), err
+	}
+	content, err := os.ReadFile(filename)
+	return string(content), err
+}
+
+// interruptibleContext is cancelled by ctrl+c, the request being aborted and
+// recorded as cancelled in the history
+func interruptibleContext() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), os.Interrupt)
+}
+
+func writeResponse(stdout io.Writer, response *http.Response) error {
+	proto := response.Proto
+	if proto == "" {
+		proto = "HTTP/1.1"
+	}
+	status := response.Status
+	if status == "" {
+		status = strconv.Itoa(response.StatusCode)
+	}
+	fmt.Fprintf(stdout, "%v %v\n", proto, status)
+	headerKeys := make([]string, 0, len(response.Header))
+	for header := range response.Header {
+		headerKeys = append(headerKeys, header)
+	}
+	slices.Sort(headerKeys)
+	for _, header := range headerKeys {
+		for _, value := range response.Header[header] {
+			fmt.Fprintf(stdout, "%v: %v\n", header, value)
+		}
+	}
+	fmt.Fprintln(stdout)
+
+	if response.Body == nil {
+		return nil
+	}
+	_, err := io.Copy(stdout, response.Body)
+	return err
+}
+
+// selectTemplating parses the variables given as a JSON object and selects
+// the environment they are merged over when templating the requests
+func selectTemplating(gogetter app.Gogetter, variables string, environment string) (app.Gogetter, any, error) {
+	var adHocVariables any = nil
+	if variables != "" {
+		err := json.Unmarshal([]byte(variables), &adHocVariables)
+		if err != nil {
+			return gogetter, nil, fmt.Errorf("variable parsing error: %w", err)
+		}
+	}
+	gogetter, err := gogetter.SelectEnvironment(environment)
+	return gogetter, adHocVariables, err
+}
+
+// Run executes the request file given in args without the TUI and writes the
+// response to stdout. It returns the updated gogetter and the process exit code.
+func Run(gogetter app.Gogetter, args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) (app.Gogetter, int) {
+	flags := flag.NewFlagSet("run", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: gogetter run [flags] <request file | ->")
+		flags.PrintDefaults()
+	}
+	variables := flags.String("variables", "", "variables as a JSON object used to template the request")
+	allowExtensionMethods := flags.Bool("allow-extension-methods", false, "allow non standard methods such as PROPFIND or PURGE")
+	environment := flags.String("env", "", "name of the environment whose variables are used to template the request")
+	failOn := flags.String("fail-on", "4xx,5xx", "comma separated status classes (5xx) or codes (404) producing a non-zero exit code, ignored when the request has assertions")
+	cookies := flags.Bool("cookies", false, "send and store cookies with the cookie jar of the environment")
+	historyBodyLimit := flags.Int64("history-body-limit", app.DefaultResponseBodySizeLimit, "maximum size in bytes of the response bodies kept in the history")
+	clientFlags := AddClientFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return gogetter, UsageExitCode
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return gogetter, UsageExitCode
+	}
+	failingStatusClasses, err := parseStatusClasses(*failOn)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return gogetter, UsageExitCode
+	}
+
+	gogetter, adHocVariables, err := selectTemplating(gogetter, *variables, *environment)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return gogetter, UsageExitCode
+	}
+	gogetter = gogetter.EnableCookies(*cookies).LimitRecordedResponseBodies(*historyBodyLimit)
+	gogetter, err = clientFlags.Apply(gogetter)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return gogetter, UsageExitCode
+	}
+	data, err := gogetter.TemplateData(adHocVariables)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return gogetter, UsageExitCode
+	}
+
+	rawRequest, err := readRequestFile(flags.Arg(0), stdin)
+	if err != nil {
+		fmt.Fprintf(stderr, "request file reading error: %v\n", err)
+		return gogetter, ErrorExitCode
+	}
+	options := []app.RequestParsingOption{app.TemplatedRequestOption{Data: data, Functions: gogetter.TemplateFunctions()}}
+	if *allowExtensionMethods {
+		options = append(options, app.ExtensionMethodsOption{})
+	}
+	request, err := app.ParseRequest(rawRequest, options...)
+	if err != nil {
+		fmt.Fprintf(stderr, "request parsing error: %v\n", err)
+		return gogetter, ErrorExitCode
+	}
+
+	ctx, stop := interruptibleContext()
+	defer stop()
+	var response *http.Response
+	var requestAndResponse app.RequestAndResponse
+	gogetter, requestAndResponse, response, err = gogetter.Execute(ctx, request)
+	if response != nil && response.Body != nil {
+		defer response.Body.Close()
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return gogetter, ErrorExitCode
+	}
+
+	if response != nil {
+		err = writeResponse(stdout, response)
+		if err != nil {
+			fmt.Fprintf(stderr, "response writing error: %v\n", err)
+			return gogetter, ErrorExitCode
+		}
+	}
+
+	for _, result := range requestAndResponse.Captures {
+		fmt.Fprintln(stderr, result)
+	}
+	if len(requestAndResponse.Assertions) > 0 {
+		for _, result := range requestAndResponse.Assertions {
+			fmt.Fprintln(stderr, result)
+		}
+		if !requestAndResponse.AssertionsPassed() {
+			fmt.Fprintln(stderr, ErrAssertionFailure)
+			return gogetter, TestFailureExitCode
+		}
+		return gogetter, SuccessExitCode
+	}
+	if failingStatusClasses.matches(requestAndResponse.ResponseCode) {
+		fmt.Fprintf(stderr, "%v: %v\n", ErrStatusFailure, requestAndResponse.ResponseCode)
+		return gogetter, StatusFailureExitCode
+	}
+	return gogetter, SuccessExitCode
+}
@@ -0,0 +1,144 @@
+package cli
+
+import (
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ThomasFerro/gogetter/app"
+)
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Details string `xml:",chardata"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      float64       `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Error     *junitFailure `xml:"error,omitempty"`
+	Skipped   *struct{}     `xml:"skipped,omitempty"`
+}
+
+type junitTestSuite struct {
+	XMLName   xml.Name        `xml:"testsuite"`
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      float64         `xml:"time,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+func newJunitTestSuite(name string, report app.WorkflowReport) junitTestSuite {
+	suite := junitTestSuite{Name: name, Tests: len(report.Steps), TestCases: []junitTestCase{}}
+	for _, step := range report.Steps {
+		testCase := junitTestCase{
+			Name:      step.Title(),
+			Classname: step.Folder(),
+			Time:      step.RequestAndResponse.Timing.Total.Seconds(),
+		}
+		switch {
+		case step.Skipped:
+			suite.Skipped++
+			testCase.Skipped = &struct{}{}
+		case step.Err != nil:
+			suite.Errors++
+			testCase.Error = &junitFailure{Message: step.Err.Error()}
+		case len(step.Failures) > 0:
+			suite.Failures++
+			testCase.Failure = &junitFailure{Message: step.Failures[0], Details: strings.Join(step.Failures, "\n")}
+		}
+		suite.Time += testCase.Time
+		suite.TestCases = append(suite.TestCases, testCase)
+	}
+	return suite
+}
+
+func writeJunitReport(filename string, suite junitTestSuite) error {
+	content, err := xml.MarshalIndent(suite, "", "  ")
+	if err != nil {
+		return fmt.Errorf("junit report marshal error: %w", err)
+	}
+	return os.WriteFile(filename, append([]byte(xml.Header), append(content, '\n')...), 0o644)
+}
+
+// Test executes the saved requests, of a folder or all of them, as tests and
+// reports their result on stdout and optionally as a JUnit XML file
+func Test(gogetter app.Gogetter, args []string, stdout io.Writer, stderr io.Writer) (app.Gogetter, int) {
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: gogetter test [flags]")
+		flags.PrintDefaults()
+	}
+	folder := flags.String("folder", "", "only run the requests saved in this folder of the collection")
+	variables := flags.String("variables", "", "variables as a JSON object used to template the requests")
+	allowExtensionMethods := flags.Bool("allow-extension-methods", false, "allow non standard methods such as PROPFIND or PURGE")
+	environment := flags.String("env", "", "name of the environment whose variables are used to template the requests")
+	failOn := flags.String("fail-on", "4xx,5xx", "comma separated status classes (5xx) or codes (404) failing the requests without assertions")
+	junitFilename := flags.String("junit", "", "file the JUnit XML report is written to")
+	cookies := flags.Bool("cookies", false, "send and store cookies with the cookie jar of the environment")
+	historyBodyLimit := flags.Int64("history-body-limit", app.DefaultResponseBodySizeLimit, "maximum size in bytes of the response bodies kept in the history")
+	clientFlags := AddClientFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return gogetter, UsageExitCode
+	}
+	if flags.NArg() != 0 {
+		flags.Usage()
+		return gogetter, UsageExitCode
+	}
+	failingStatusClasses, err := parseStatusClasses(*failOn)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return gogetter, UsageExitCode
+	}
+	gogetter, adHocVariables, err := selectTemplating(gogetter, *variables, *environment)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return gogetter, UsageExitCode
+	}
+	gogetter = gogetter.EnableCookies(*cookies).LimitRecordedResponseBodies(*historyBodyLimit)
+	gogetter, err = clientFlags.Apply(gogetter)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return gogetter, UsageExitCode
+	}
+	parsingOptions := []app.RequestParsingOption{}
+	if *allowExtensionMethods {
+		parsingOptions = append(parsingOptions, app.ExtensionMethodsOption{})
+	}
+
+	ctx, stop := interruptibleContext()
+	defer stop()
+	gogetter, report := gogetter.RunWorkflow(ctx, app.Workflow{
+		WorkflowSettings: app.WorkflowSettings{FailingStatusClasses: failingStatusClasses},
+		Steps:            savedRequestsInFolder(gogetter, *folder),
+		AdHocVariables:   adHocVariables,
+		ParsingOptions:   parsingOptions,
+		OnStep:           func(step app.WorkflowStepResult) { fmt.Fprintln(stdout, step) },
+	})
+	fmt.Fprintln(stdout, report.Summary())
+
+	if *junitFilename != "" {
+		suiteName := *folder
+		if suiteName == "" {
+			suiteName = "gogetter"
+		}
+		err = writeJunitReport(*junitFilename, newJunitTestSuite(suiteName, report))
+		if err != nil {
+			fmt.Fprintf(stderr, "junit report writing error: %v\n", err)
+			return gogetter, ErrorExitCode
+		}
+	}
+	if report.Failed() > 0 {
+		return gogetter, TestFailureExitCode
+	}
+	return gogetter, SuccessExitCode
+}
@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+
+	"github.com/ThomasFerro/gogetter/app"
+)
+
+// findSavedRequest finds a saved request by its path in the collection, with
+// or without its extension, or by its name
+func findSavedRequest(gogetter app.Gogetter, reference string) (app.SavedRequest, error) {
+	savedRequests := gogetter.SavedRequests()
+	index := slices.IndexFunc(savedRequests, func(savedRequest app.SavedRequest) bool {
+		return savedRequest.Path != "" && (savedRequest.Path == reference || savedRequest.Path == reference+".gg")
+	})
+	if index == -1 {
+		index = slices.IndexFunc(savedRequests, func(savedRequest app.SavedRequest) bool { return savedRequest.Name == reference })
+	}
+	if index == -1 {
+		return app.SavedRequest{}, fmt.Errorf("unknown saved request %q", reference)
+	}
+	return savedRequests[index], nil
+}
+
+// Workflow executes saved requests in order, the given ones or the ones of a
+// folder, each one being templated with the values captured by the previous
+// ones, and reports their result on stdout
+func Workflow(gogetter app.Gogetter, args []string, stdout io.Writer, stderr io.Writer) (app.Gogetter, int) {
+	flags := flag.NewFlagSet("workflow", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: gogetter workflow [flags] [saved request path or name...]")
+		flags.PrintDefaults()
+	}
+	folder := flags.String("folder", "", "run the requests saved in this folder of the collection when no request is given")
+	variables := flags.String("variables", "", "variables as a JSON object used to template the requests")
+	allowExtensionMethods := flags.Bool("allow-extension-methods", false, "allow non standard methods such as PROPFIND or PURGE")
+	environment := flags.String("env", "", "name of the environment whose variables are used to template the requests")
+	failOn := flags.String("fail-on", "4xx,5xx", "comma separated status classes (5xx) or codes (404) failing the requests without assertions")
+	continueOnFailure := flags.Bool("continue-on-failure", false, "run the next requests after a failing one instead of skipping them")
+	delay := flags.Duration("delay", 0, "time waited between two requests (e.g. 500ms)")
+	cookies := flags.Bool("cookies", false, "send and store cookies with the cookie jar of the environment")
+	historyBodyLimit := flags.Int64("history-body-limit", app.DefaultResponseBodySizeLimit, "maximum size in bytes of the response bodies kept in the history")
+	clientFlags := AddClientFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return gogetter, UsageExitCode
+	}
+	failingStatusClasses, err := parseStatusClasses(*failOn)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return gogetter, UsageExitCode
+	}
+
+	steps := app.SavedRequests{}
+	for _, reference := range flags.Args() {
+		savedRequest, err := findSavedRequest(gogetter, reference)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return gogetter, UsageExitCode
+		}
+		steps = append(steps, savedRequest)
+	}
+	if flags.NArg() == 0 {
+		steps = savedRequestsInFolder(gogetter, *folder)
+	}
+
+	gogetter, adHocVariables, err := selectTemplating(gogetter, *variables, *environment)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return gogetter, UsageExitCode
+	}
+	gogetter = gogetter.EnableCookies(*cookies).LimitRecordedResponseBodies(*historyBodyLimit)
+	gogetter, err = clientFlags.Apply(gogetter)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return gogetter, UsageExitCode
+	}
+	parsingOptions := []app.RequestParsingOption{}
+	if *allowExtensionMethods {
+		parsingOptions = append(parsingOptions, app.ExtensionMethodsOption{})
+	}
+
+	ctx, stop := interruptibleContext()
+	defer stop()
+	gogetter, report := gogetter.RunWorkflow(ctx, app.Workflow{
+		WorkflowSettings: app.WorkflowSettings{
+			StopOnFailure:        !*continueOnFailure,
+			Delay:                *delay,
+			FailingStatusClasses: failingStatusClasses,
+		},
+		Steps:          steps,
+		AdHocVariables: adHocVariables,
+		ParsingOptions: parsingOptions,
+		OnStep:         func(step app.WorkflowStepResult) { fmt.Fprintln(stdout, step) },
+	})
+	fmt.Fprintf(stdout, "%v in %v\n", report.Summary(), report.Duration.Round(time.Millisecond))
+	if report.Failed() > 0 {
+		return gogetter, TestFailureExitCode
+	}
+	return gogetter, SuccessExitCode
+}
@@ -0,0 +1,17 @@
+package helpers
+
+import (
+	"os/exec"
+	"runtime"
+)
+
+// OpenBrowser opens the url with the default browser of the platform
+func OpenBrowser(url string) error {
+	switch runtime.GOOS {
+	case "darwin":
+		return exec.Command("open", url).Start()
+	case "windows":
+		return exec.Command("rundll32", "url.dll,FileProtocolHandler", url).Start()
+	}
+	return exec.Command("xdg-open", url).Start()
+}
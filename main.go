@@ -2,13 +2,17 @@ package main
 
 import (
 	"errors"
+	"flag"
+	"fmt"
 	"io"
 	"log/slog"
-	"net/http"
 	"os"
+	"time"
 
 	"github.com/ThomasFerro/gogetter/app"
+	"github.com/ThomasFerro/gogetter/cli"
 	"github.com/ThomasFerro/gogetter/helpers"
+	"github.com/ThomasFerro/gogetter/storage"
 	"github.com/ThomasFerro/gogetter/tui"
 	tea "github.com/charmbracelet/bubbletea"
 )
@@ -24,70 +28,254 @@ func optionFileReader(filename string) (io.ReadCloser, error) {
 	return historyFileReader, nil
 }
 
-const historyFilename = "gogetter_history.json"
+const (
+	historyFilename       = "gogetter_history.jsonl"
+	legacyHistoryFilename = "gogetter_history.json"
+	historyMaxEntries     = 1000
+	historyMaxAge         = 90 * 24 * time.Hour
+)
 
-func historyOption() (app.WithHistory, io.ReadCloser, error) {
-	historyFileReader, err := optionFileReader(historyFilename)
+// migrateLegacyHistory imports the history written as a single JSON array by
+// the previous versions, once
+func migrateLegacyHistory(store *storage.JsonLinesHistory) error {
+	if _, err := os.Stat(historyFilename); !os.IsNotExist(err) {
+		return err
+	}
+	legacyHistoryFileReader, err := optionFileReader(legacyHistoryFilename)
 	if err != nil {
-		return app.WithHistory{}, nil, errors.New("history file reader error")
+		return err
 	}
+	defer legacyHistoryFileReader.Close()
+	return store.ImportJsonHistory(legacyHistoryFileReader)
+}
+
+func historyOption() (app.WithHistoryStore, error) {
+	store := storage.NewJsonLinesHistory(historyFilename, historyMaxEntries, historyMaxAge)
+	err := migrateLegacyHistory(store)
+	if err != nil {
+		return app.WithHistoryStore{}, fmt.Errorf("legacy history migration error: %w", err)
+	}
+	return app.WithHistoryStore{Store: store, RecordResponses: true}, nil
+}
 
-	historyWritingFunc := func(toWrite []byte) error {
-		historyFileWriter, err := os.Create(historyFilename)
+const (
+	savedRequestsFolder         = "gogetter_requests"
+	legacySavedRequestsFilename = "gogetter_requests.json"
+)
+
+func savedRequestsOption() app.WithSavedRequestsCollection {
+	return app.WithSavedRequestsCollection{Collection: storage.DirectoryCollection{Root: savedRequestsFolder}}
+}
+
+// migrateLegacySavedRequests saves the requests of the single JSON file used
+// by the previous versions in the collection, once
+func migrateLegacySavedRequests(gogetter app.Gogetter) (app.Gogetter, error) {
+	if _, err := os.Stat(savedRequestsFolder); !os.IsNotExist(err) {
+		return gogetter, err
+	}
+	legacySavedRequestsFileReader, err := optionFileReader(legacySavedRequestsFilename)
+	if err != nil {
+		return gogetter, err
+	}
+	defer legacySavedRequestsFileReader.Close()
+	legacyGogetter, err := app.NewGogetter(nil, app.WithSavedRequests{InitialSavedRequests: legacySavedRequestsFileReader})
+	if err != nil {
+		return gogetter, err
+	}
+	for _, savedRequest := range legacyGogetter.SavedRequests() {
+		gogetter, err = gogetter.AddSavedRequest(savedRequest, "")
 		if err != nil {
-			return err
+			return gogetter, err
 		}
-		defer historyFileWriter.Close()
-		_, err = historyFileWriter.Write(toWrite)
-		return err
 	}
-	return app.WithHistory{
-		PreviousHistory: historyFileReader, HistoryWriter: historyWritingFunc,
-	}, historyFileReader, nil
+	return gogetter, nil
 }
 
-const savedRequestsFilename = "gogetter_requests.json"
+const environmentsFilename = "gogetter_environments.json"
 
-func savedRequestsOption() (app.WithSavedRequests, io.ReadCloser, error) {
-	savedRequestsFileReader, err := optionFileReader(savedRequestsFilename)
+func environmentsOption() (app.WithEnvironments, io.ReadCloser, error) {
+	environmentsFileReader, err := optionFileReader(environmentsFilename)
 	if err != nil {
-		return app.WithSavedRequests{}, nil, errors.New("saved requests file reader error")
+		return app.WithEnvironments{}, nil, errors.New("environments file reader error")
 	}
+	return app.WithEnvironments{
+		Environments: environmentsFileReader,
+		EnvironmentsSavingFunc: func(content []byte) error {
+			return storage.WriteFileAtomically(environmentsFilename, content)
+		},
+	}, environmentsFileReader, nil
+}
 
-	requestsSavingFunc := func(toWrite []byte) error {
-		savedRequestsFileWriter, err := os.Create(savedRequestsFilename)
-		if err != nil {
-			return err
+const secretsFilename = ".env"
+
+func secretsOption() (app.WithSecrets, io.ReadCloser, error) {
+	secretsFileReader, err := optionFileReader(secretsFilename)
+	if err != nil {
+		return app.WithSecrets{}, nil, errors.New("secrets file reader error")
+	}
+	return app.WithSecrets{DotEnv: secretsFileReader}, secretsFileReader, nil
+}
+
+const (
+	oauth2ConfigurationsFilename = "gogetter_oauth2.json"
+	oauth2TokensFilename         = "gogetter_oauth2_tokens.json"
+)
+
+func oauth2Option() (app.WithOAuth2, []io.ReadCloser, error) {
+	configurationsFileReader, err := optionFileReader(oauth2ConfigurationsFilename)
+	if err != nil {
+		return app.WithOAuth2{}, nil, errors.New("oauth2 configurations file reader error")
+	}
+	tokensFileReader, err := optionFileReader(oauth2TokensFilename)
+	if err != nil {
+		configurationsFileReader.Close()
+		return app.WithOAuth2{}, nil, errors.New("oauth2 tokens file reader error")
+	}
+	return app.WithOAuth2{
+		Configurations: configurationsFileReader,
+		Tokens:         tokensFileReader,
+		TokensSavingFunc: func(content []byte) error {
+			return storage.WriteFileAtomically(oauth2TokensFilename, content)
+		},
+		Authorize: func(authorizationUrl string) error {
+			err := helpers.OpenBrowser(authorizationUrl)
+			if err != nil {
+				return fmt.Errorf("open %v in a browser: %w", authorizationUrl, err)
+			}
+			return nil
+		},
+	}, []io.ReadCloser{configurationsFileReader, tokensFileReader}, nil
+}
+
+const cookiesFilename = "gogetter_cookies.json"
+
+func cookieJarOption() (app.WithCookieJar, io.ReadCloser, error) {
+	cookiesFileReader, err := optionFileReader(cookiesFilename)
+	if err != nil {
+		return app.WithCookieJar{}, nil, errors.New("cookies file reader error")
+	}
+	return app.WithCookieJar{
+		Cookies: cookiesFileReader,
+		CookiesSavingFunc: func(content []byte) error {
+			return storage.WriteFileAtomically(cookiesFilename, content)
+		},
+	}, cookiesFileReader, nil
+}
+
+const clientSettingsFilename = "gogetter_client.json"
+
+func clientSettingsOption() (app.WithClientSettings, io.ReadCloser, error) {
+	clientSettingsFileReader, err := optionFileReader(clientSettingsFilename)
+	if err != nil {
+		return app.WithClientSettings{}, nil, errors.New("client settings file reader error")
+	}
+	return app.WithClientSettings{Settings: clientSettingsFileReader}, clientSettingsFileReader, nil
+}
+
+func newGogetter() (app.Gogetter, func(), error) {
+	withHistory, err := historyOption()
+	if err != nil {
+		return app.Gogetter{}, func() {}, fmt.Errorf("error while creating history option: %w", err)
+	}
+	fileReaders := []io.ReadCloser{}
+	closeFiles := func() {
+		for _, fileReader := range fileReaders {
+			fileReader.Close()
 		}
-		defer savedRequestsFileWriter.Close()
-		// FIXME: suffisant pour tout écrire ou il faut boucler ? + mutualiser ou utiliser un helper standard
-		_, err = savedRequestsFileWriter.Write(toWrite)
-		return err
 	}
-	return app.WithSavedRequests{
-		InitialSavedRequests: savedRequestsFileReader, RequestsSavingFunc: requestsSavingFunc,
-	}, savedRequestsFileReader, nil
+	withEnvironments, environmentsFileReader, err := environmentsOption()
+	if err != nil {
+		return app.Gogetter{}, closeFiles, fmt.Errorf("error while creating environments option: %w", err)
+	}
+	fileReaders = append(fileReaders, environmentsFileReader)
+	withSecrets, secretsFileReader, err := secretsOption()
+	if err != nil {
+		return app.Gogetter{}, closeFiles, fmt.Errorf("error while creating secrets option: %w", err)
+	}
+	fileReaders = append(fileReaders, secretsFileReader)
+	withOAuth2, oauth2FileReaders, err := oauth2Option()
+	if err != nil {
+		return app.Gogetter{}, closeFiles, fmt.Errorf("error while creating oauth2 option: %w", err)
+	}
+	fileReaders = append(fileReaders, oauth2FileReaders...)
+	withCookieJar, cookiesFileReader, err := cookieJarOption()
+	if err != nil {
+		return app.Gogetter{}, closeFiles, fmt.Errorf("error while creating cookie jar option: %w", err)
+	}
+	fileReaders = append(fileReaders, cookiesFileReader)
+	withClientSettings, clientSettingsFileReader, err := clientSettingsOption()
+	if err != nil {
+		return app.Gogetter{}, closeFiles, fmt.Errorf("error while creating client settings option: %w", err)
+	}
+	fileReaders = append(fileReaders, clientSettingsFileReader)
+	gogetter, err := app.NewGogetter(app.NewHttpClient(), withHistory, savedRequestsOption(), withEnvironments, withSecrets, withOAuth2, withCookieJar, withClientSettings)
+	if err != nil {
+		return app.Gogetter{}, closeFiles, fmt.Errorf("error while creating new gogetter: %w", err)
+	}
+	gogetter, err = migrateLegacySavedRequests(gogetter)
+	if err != nil {
+		return app.Gogetter{}, closeFiles, fmt.Errorf("legacy saved requests migration error: %w", err)
+	}
+	return gogetter, closeFiles, nil
 }
 
 func main() {
-	withHistory, historyFileReader, err := historyOption()
-	defer historyFileReader.Close()
+	gogetter, closeFiles, err := newGogetter()
+	defer closeFiles()
 	if err != nil {
-		slog.Error("error while creating history option", slog.Any("error", err))
+		slog.Error("error while initializing gogetter", slog.Any("error", err))
 		os.Exit(1)
 	}
-	withSavedRequests, savedRequestsFileReader, err := savedRequestsOption()
-	defer savedRequestsFileReader.Close()
-	if err != nil {
-		slog.Error("error while creating saved requests option", slog.Any("error", err))
-		os.Exit(1)
+
+	if len(os.Args) > 1 {
+		exitCode := -1
+		switch os.Args[1] {
+		case "run":
+			_, exitCode = cli.Run(gogetter, os.Args[2:], os.Stdin, os.Stdout, os.Stderr)
+		case "test":
+			_, exitCode = cli.Test(gogetter, os.Args[2:], os.Stdout, os.Stderr)
+		case "workflow":
+			_, exitCode = cli.Workflow(gogetter, os.Args[2:], os.Stdout, os.Stderr)
+		case "import-postman":
+			_, exitCode = cli.ImportPostman(gogetter, os.Args[2:], os.Stdout, os.Stderr)
+		case "import-openapi":
+			_, exitCode = cli.ImportOpenApi(gogetter, os.Args[2:], os.Stdout, os.Stderr)
+		case "import-http":
+			_, exitCode = cli.ImportHttpFile(gogetter, os.Args[2:], os.Stdout, os.Stderr)
+		case "export-http":
+			_, exitCode = cli.ExportHttpFile(gogetter, os.Args[2:], os.Stdout, os.Stderr)
+		}
+		if exitCode != -1 {
+			closeFiles()
+			os.Exit(exitCode)
+		}
 	}
-	gogetter, err := app.NewGogetter(http.DefaultClient, withHistory, withSavedRequests)
+
+	allowExtensionMethods := flag.Bool("allow-extension-methods", false, "allow non standard methods such as PROPFIND or PURGE")
+	workflowContinueOnFailure := flag.Bool("workflow-continue-on-failure", false, "run the next requests of a workflow after a failing one instead of skipping them")
+	workflowDelay := flag.Duration("workflow-delay", 0, "time waited between two requests of a workflow (e.g. 500ms)")
+	cookies := flag.Bool("cookies", false, "send and store cookies with the cookie jar of the active environment")
+	historyBodyLimit := flag.Int64("history-body-limit", app.DefaultResponseBodySizeLimit, "maximum size in bytes of the response bodies kept in the history")
+	clientFlags := cli.AddClientFlags(flag.CommandLine)
+	flag.Parse()
+	gogetter = gogetter.EnableCookies(*cookies).LimitRecordedResponseBodies(*historyBodyLimit)
+	gogetter, err = clientFlags.Apply(gogetter)
 	if err != nil {
-		slog.Error("error while creating new gogetter", slog.Any("error", err))
-		os.Exit(1)
+		slog.Error("invalid client flags", slog.Any("error", err))
+		os.Exit(2)
 	}
-	if _, err = tea.NewProgram(tui.NewModel(gogetter), tea.WithAltScreen()).Run(); err != nil {
+	parsingOptions := []app.RequestParsingOption{}
+	if *allowExtensionMethods {
+		parsingOptions = append(parsingOptions, app.ExtensionMethodsOption{})
+	}
+
+	workflowSettings := app.WorkflowSettings{
+		StopOnFailure:        !*workflowContinueOnFailure,
+		Delay:                *workflowDelay,
+		FailingStatusClasses: []app.StatusClass{"4xx", "5xx"},
+	}
+
+	if _, err = tea.NewProgram(tui.NewModel(gogetter, workflowSettings, parsingOptions...), tea.WithAltScreen()).Run(); err != nil {
 		slog.Error("error while running program", slog.Any("error", err))
 		os.Exit(1)
 	}
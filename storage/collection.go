@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ThomasFerro/gogetter/app"
+)
+
+// DirectoryCollection stores each saved request in its own .gg file under
+// Root, folders being used to group them
+type DirectoryCollection struct {
+	Root string
+}
+
+func (c DirectoryCollection) filename(path string) (string, error) {
+	filename := filepath.Join(c.Root, filepath.FromSlash(path))
+	relative, err := filepath.Rel(c.Root, filename)
+	if err != nil || relative == "." || strings.HasPrefix(relative, "..") {
+		return "", fmt.Errorf("saved request path %q is outside of the collection", path)
+	}
+	return filename, nil
+}
+
+func (c DirectoryCollection) Load() ([]app.SavedRequestWritingDto, error) {
+	savedRequests := []app.SavedRequestWritingDto{}
+	err := filepath.WalkDir(c.Root, func(filename string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && filename == c.Root {
+				return fs.SkipAll
+			}
+			return err
+		}
+		if entry.IsDir() || filepath.Ext(filename) != app.SavedRequestExtension {
+			return nil
+		}
+		content, err := os.ReadFile(filename)
+		if err != nil {
+			return err
+		}
+		relative, err := filepath.Rel(c.Root, filename)
+		if err != nil {
+			return err
+		}
+		savedRequests = append(savedRequests, app.SavedRequestWritingDto{
+			Path:    filepath.ToSlash(relative),
+			Request: string(content),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("collection reading error: %w", err)
+	}
+	return savedRequests, nil
+}
+
+func (c DirectoryCollection) Write(savedRequest app.SavedRequestWritingDto) error {
+	filename, err := c.filename(savedRequest.Path)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(filename), 0o755)
+	if err != nil {
+		return fmt.Errorf("collection folder creation error: %w", err)
+	}
+	content := savedRequest.Request
+	if !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	return WriteFileAtomically(filename, []byte(content))
+}
+
+// Remove deletes the saved request file and the folders it leaves empty
+func (c DirectoryCollection) Remove(path string) error {
+	filename, err := c.filename(path)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(filename)
+	if err != nil {
+		return fmt.Errorf("saved request file removal error: %w", err)
+	}
+	root := filepath.Clean(c.Root)
+	for folder := filepath.Dir(filename); folder != root; folder = filepath.Dir(folder) {
+		if os.Remove(folder) != nil {
+			break
+		}
+	}
+	return nil
+}
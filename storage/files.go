@@ -0,0 +1,38 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// WriteFileAtomically writes to a temporary file in the same directory then
+// renames it over filename, so that a crash never leaves a partial file
+func WriteFileAtomically(filename string, content []byte) error {
+	temporaryFile, err := os.CreateTemp(filepath.Dir(filename), filepath.Base(filename)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("temporary file creation error: %w", err)
+	}
+	temporaryFilename := temporaryFile.Name()
+	defer os.Remove(temporaryFilename)
+
+	_, err = temporaryFile.Write(content)
+	if err != nil {
+		temporaryFile.Close()
+		return fmt.Errorf("temporary file writing error: %w", err)
+	}
+	err = temporaryFile.Sync()
+	if err != nil {
+		temporaryFile.Close()
+		return fmt.Errorf("temporary file sync error: %w", err)
+	}
+	err = temporaryFile.Close()
+	if err != nil {
+		return fmt.Errorf("temporary file closing error: %w", err)
+	}
+	err = os.Rename(temporaryFilename, filename)
+	if err != nil {
+		return fmt.Errorf("temporary file renaming error: %w", err)
+	}
+	return nil
+}
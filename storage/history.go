@@ -0,0 +1,214 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/ThomasFerro/gogetter/app"
+)
+
+type historyLine struct {
+	app.HistoryEntryWritingDto
+	AppendedAt time.Time
+}
+
+// JsonLinesHistory is an append-only history store writing one JSON entry per
+// line. The file is compacted once it holds twice as many entries as MaxEntries
+// or when entries older than MaxAge are found while loading it.
+type JsonLinesHistory struct {
+	Filename   string
+	MaxEntries int
+	MaxAge     time.Duration
+	lineCount  int
+	now        func() time.Time
+}
+
+func NewJsonLinesHistory(filename string, maxEntries int, maxAge time.Duration) *JsonLinesHistory {
+	return &JsonLinesHistory{
+		Filename:   filename,
+		MaxEntries: maxEntries,
+		MaxAge:     maxAge,
+		now:        time.Now,
+	}
+}
+
+func (h *JsonLinesHistory) readLines() ([]historyLine, error) {
+	file, err := os.Open(h.Filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []historyLine{}, nil
+		}
+		return nil, fmt.Errorf("history file opening error: %w", err)
+	}
+	defer file.Close()
+
+	lines := []historyLine{}
+	reader := bufio.NewReader(file)
+	for {
+		rawLine, err := reader.ReadBytes('\n')
+		if len(bytes.TrimSpace(rawLine)) != 0 {
+			var line historyLine
+			// A line that cannot be decoded was partially written by a crash, it is skipped
+			if json.Unmarshal(rawLine, &line) == nil {
+				lines = append(lines, line)
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("history file reading error: %w", err)
+		}
+	}
+	return lines, nil
+}
+
+func (h *JsonLinesHistory) retainedLines(lines []historyLine) []historyLine {
+	if h.MaxAge > 0 {
+		oldestAllowed := h.now().Add(-h.MaxAge)
+		firstRetained := 0
+		for firstRetained < len(lines) && lines[firstRetained].AppendedAt.Before(oldestAllowed) {
+			firstRetained++
+		}
+		lines = lines[firstRetained:]
+	}
+	if h.MaxEntries > 0 && len(lines) > h.MaxEntries {
+		lines = lines[len(lines)-h.MaxEntries:]
+	}
+	return lines
+}
+
+func (h *JsonLinesHistory) Load() ([]app.HistoryEntryWritingDto, error) {
+	lines, err := h.readLines()
+	if err != nil {
+		return nil, err
+	}
+	retainedLines := h.retainedLines(lines)
+	if len(retainedLines) != len(lines) {
+		err = h.writeLines(retainedLines)
+		if err != nil {
+			return nil, err
+		}
+	}
+	h.lineCount = len(retainedLines)
+
+	entries := []app.HistoryEntryWritingDto{}
+	for _, line := range retainedLines {
+		entries = append(entries, line.HistoryEntryWritingDto)
+	}
+	return entries, nil
+}
+
+// endsWithTornLine tells whether the last line of the file, partially written
+// by a crash, lacks its line break
+func endsWithTornLine(file *os.File) (bool, error) {
+	info, err := file.Stat()
+	if err != nil || info.Size() == 0 {
+		return false, err
+	}
+	lastByte := make([]byte, 1)
+	_, err = file.ReadAt(lastByte, info.Size()-1)
+	if err != nil {
+		return false, err
+	}
+	return lastByte[0] != '\n', nil
+}
+
+func (h *JsonLinesHistory) Append(entry app.HistoryEntryWritingDto) error {
+	toWrite, err := json.Marshal(historyLine{HistoryEntryWritingDto: entry, AppendedAt: h.now()})
+	if err != nil {
+		return fmt.Errorf("history entry marshal error: %w", err)
+	}
+	file, err := os.OpenFile(h.Filename, os.O_APPEND|os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return fmt.Errorf("history file opening error: %w", err)
+	}
+	defer file.Close()
+	tornLine, err := endsWithTornLine(file)
+	if err != nil {
+		return fmt.Errorf("history file reading error: %w", err)
+	}
+	if tornLine {
+		// The entry would be lost with the partially written one otherwise
+		toWrite = append([]byte{'\n'}, toWrite...)
+	}
+	_, err = file.Write(append(toWrite, '\n'))
+	if err != nil {
+		return fmt.Errorf("history entry writing error: %w", err)
+	}
+	err = file.Sync()
+	if err != nil {
+		return fmt.Errorf("history file sync error: %w", err)
+	}
+
+	h.lineCount++
+	if h.MaxEntries > 0 && h.lineCount >= 2*h.MaxEntries {
+		return h.Compact()
+	}
+	return nil
+}
+
+// Compact rewrites the history without the entries exceeding MaxEntries or
+// MaxAge
+func (h *JsonLinesHistory) Compact() error {
+	lines, err := h.readLines()
+	if err != nil {
+		return err
+	}
+	retainedLines := h.retainedLines(lines)
+	err = h.writeLines(retainedLines)
+	if err != nil {
+		return err
+	}
+	h.lineCount = len(retainedLines)
+	return nil
+}
+
+// writeLines replaces the history file atomically by writing a temporary file
+// renamed over the previous one
+func (h *JsonLinesHistory) writeLines(lines []historyLine) error {
+	buffer := &bytes.Buffer{}
+	encoder := json.NewEncoder(buffer)
+	for _, line := range lines {
+		err := encoder.Encode(line)
+		if err != nil {
+			return fmt.Errorf("history entry marshal error: %w", err)
+		}
+	}
+	return WriteFileAtomically(h.Filename, buffer.Bytes())
+}
+
+// ImportJsonHistory appends the entries of a history written by
+// app.WithHistory, to migrate it to the JSON lines format
+func (h *JsonLinesHistory) ImportJsonHistory(reader io.Reader) error {
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return fmt.Errorf("history reading error: %w", err)
+	}
+	if len(content) == 0 {
+		return nil
+	}
+	var entries []app.HistoryEntryWritingDto
+	err = json.Unmarshal(content, &entries)
+	if err != nil {
+		return fmt.Errorf("history unmarshal error: %w", err)
+	}
+	lines, err := h.readLines()
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		appendedAt := h.now()
+		if entry.Response != nil {
+			appendedAt = entry.Response.Timestamp
+		}
+		lines = append(lines, historyLine{HistoryEntryWritingDto: entry, AppendedAt: appendedAt})
+	}
+	return h.writeLines(lines)
+}
@@ -0,0 +1,175 @@
+package tests_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ThomasFerro/gogetter/app"
+	"github.com/ThomasFerro/gogetter/cli"
+	"github.com/ThomasFerro/gogetter/tests"
+)
+
+func TestShouldParseAssertions(t *testing.T) {
+	request, err := app.ParseRequest(`GET https://api.com/users/1
+assert status == 2xx
+assert header Content-Type contains json
+assert json $.name == "gopher"
+assert json $.roles[0] exists
+assert body contains gopher
+assert time < 2s`)
+	if err != nil {
+		t.Fatalf("request parsing failed: %v", err)
+	}
+	if request.Url != "https://api.com/users/1" || len(request.Assertions) != 6 {
+		t.Fatalf("request not parsed correctly: %+v", request)
+	}
+	expected := app.Assertion{Subject: app.JsonAssertionSubject, Target: "$.name", Operator: app.EqualsOperator, Expected: `"gopher"`}
+	if request.Assertions[2] != expected {
+		t.Fatalf("unexpected assertion: %+v", request.Assertions[2])
+	}
+
+	formatted, err := app.FormatRequest(request)
+	if err != nil {
+		t.Fatalf("request formatting failed: %v", err)
+	}
+	if !strings.HasSuffix(formatted, "assert body contains gopher\nassert time < 2s") {
+		t.Fatalf("assertions not formatted: %q", formatted)
+	}
+}
+
+func TestShouldRejectInvalidAssertions(t *testing.T) {
+	for _, line := range []string{
+		"assert code == 200",
+		"assert status < 200",
+		"assert json name == 1",
+		"assert json $.name exists 1",
+		"assert time < soon",
+		"assert header Location ~ (",
+	} {
+		_, err := app.ParseRequest("GET https://api.com\n" + line)
+		if err == nil {
+			t.Fatalf("expected an error for %q", line)
+		}
+	}
+}
+
+func TestShouldCheckAssertionsAfterExecution(t *testing.T) {
+	gogetter := tests.NewTestSetup(
+		t,
+		tests.SubstitutedRequest{
+			Request:         app.Request{Method: "GET", Url: "https://api.com/users/1"},
+			Response:        `{"name":"gopher","roles":["admin"],"age":12}`,
+			ResponseCode:    200,
+			ResponseHeaders: http.Header{"Content-Type": {"application/json"}},
+		},
+	)
+	request, err := app.ParseRequest(`GET https://api.com/users/1
+assert status == 200
+assert header Content-Type ~ ^application/json$
+assert json $.roles[-1] == admin
+assert json $.age == 12
+assert json $.email exists
+assert body contains "gopher"
+assert time < 1m`)
+	if err != nil {
+		t.Fatalf("request parsing failed: %v", err)
+	}
+
+	_, requestAndResponse, response, err := gogetter.Execute(context.Background(), request)
+	if err != nil {
+		t.Fatalf("request execution failed: %v", err)
+	}
+	if len(requestAndResponse.Assertions) != 7 {
+		t.Fatalf("unexpected assertion results: %v", requestAndResponse.Assertions)
+	}
+	for index, result := range requestAndResponse.Assertions {
+		if result.Passed == (index == 4) {
+			t.Fatalf("unexpected assertion result: %v", result)
+		}
+	}
+	if requestAndResponse.AssertionsPassed() {
+		t.Fatal("expected the assertions to fail")
+	}
+	if requestAndResponse.Assertions[4].String() != "FAIL assert json $.email exists (got no value at this JSON path)" {
+		t.Fatalf("unexpected failure description: %v", requestAndResponse.Assertions[4])
+	}
+	body := &bytes.Buffer{}
+	body.ReadFrom(response.Body)
+	if !strings.Contains(body.String(), "gopher") {
+		t.Fatalf("response body not readable after the assertions: %q", body.String())
+	}
+}
+
+func TestShouldRunSavedRequestsAsTests(t *testing.T) {
+	gogetter := tests.NewGogetter(t,
+		tests.NewTestClient(
+			tests.SubstitutedRequest{Request: app.Request{Method: "GET", Url: "https://api.com/health"}, Response: `{"status":"up"}`, ResponseCode: 200},
+			tests.SubstitutedRequest{Request: app.Request{Method: "GET", Url: "https://api.com/users"}, Response: "[]", ResponseCode: 200},
+			tests.SubstitutedRequest{Request: app.Request{Method: "GET", Url: "https://api.com/missing"}, Response: "not found", ResponseCode: 404},
+		),
+		app.WithSavedRequests{InitialSavedRequests: strings.NewReader(`[
+			"# name: Health\nGET https://{{.host}}/health\nassert json $.status == up",
+			"# name: Users\nGET https://{{.host}}/users\nassert json $[0] exists",
+			"# name: Missing\nGET https://{{.host}}/missing"
+		]`)},
+	)
+	junitFilename := filepath.Join(t.TempDir(), "report.xml")
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+
+	_, exitCode := cli.Test(gogetter, []string{"-variables", `{"host":"api.com"}`, "-junit", junitFilename}, stdout, stderr)
+	if exitCode != cli.TestFailureExitCode {
+		t.Fatalf("expected test failure exit code but got %v: %v", exitCode, stderr.String())
+	}
+	output := stdout.String()
+	if !strings.Contains(output, "PASS Health") ||
+		!strings.Contains(output, "FAIL Users") ||
+		!strings.Contains(output, "    FAIL assert json $[0] exists") ||
+		!strings.Contains(output, "FAIL Missing") ||
+		!strings.HasSuffix(output, "1 passed, 2 failed\n") {
+		t.Fatalf("unexpected output:\n%v", output)
+	}
+
+	content, err := os.ReadFile(junitFilename)
+	if err != nil {
+		t.Fatalf("junit report reading failed: %v", err)
+	}
+	var report struct {
+		Tests     int `xml:"tests,attr"`
+		Failures  int `xml:"failures,attr"`
+		TestCases []struct {
+			Name    string    `xml:"name,attr"`
+			Failure *struct{} `xml:"failure"`
+		} `xml:"testcase"`
+	}
+	err = xml.Unmarshal(content, &report)
+	if err != nil {
+		t.Fatalf("junit report parsing failed: %v", err)
+	}
+	if report.Tests != 3 || report.Failures != 2 || report.TestCases[0].Name != "Health" || report.TestCases[0].Failure != nil || report.TestCases[1].Failure == nil {
+		t.Fatalf("unexpected junit report:\n%s", content)
+	}
+}
+
+func TestShouldExitWithTestFailureWhenRunAssertionsFail(t *testing.T) {
+	gogetter := tests.NewTestSetup(
+		t,
+		tests.SubstitutedRequest{Request: app.Request{Method: "GET", Url: "https://api.com"}, Response: "ok", ResponseCode: 200},
+	)
+	filename := writeRequestFile(t, "GET https://api.com\nassert body contains ko")
+	stderr := &bytes.Buffer{}
+
+	_, exitCode := cli.Run(gogetter, []string{filename}, nil, &bytes.Buffer{}, stderr)
+	if exitCode != cli.TestFailureExitCode {
+		t.Fatalf("expected test failure exit code but got %v", exitCode)
+	}
+	if !strings.Contains(stderr.String(), "FAIL assert body contains ko") {
+		t.Fatalf("assertion result not reported: %q", stderr.String())
+	}
+}
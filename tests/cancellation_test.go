@@ -0,0 +1,99 @@
+package tests_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ThomasFerro/gogetter/app"
+)
+
+// newHungServer never answers /hung until the request is aborted
+func newHungServer(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/hung" {
+			<-r.Context().Done()
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestShouldRecordCancelledRequests(t *testing.T) {
+	server := newHungServer(t)
+	writtenHistory := ""
+	gogetter, err := app.NewGogetter(app.NewHttpClient(),
+		app.WithHistory{PreviousHistory: strings.NewReader("[]"), HistoryWriter: func(toWrite []byte) error { writtenHistory = string(toWrite); return nil }},
+		app.WithClientSettings{Settings: strings.NewReader(`{"timeout": "10s"}`)},
+	)
+	if err != nil {
+		t.Fatalf("new gogetter failed: %v", err)
+	}
+	request, err := app.ParseRequest(fmt.Sprintf("GET %v/hung", server.URL))
+	if err != nil {
+		t.Fatalf("request parsing failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	gogetter, requestAndResponse, response, err := gogetter.Execute(ctx, request)
+	if !errors.Is(err, app.ErrRequestCancelled) || response != nil || !requestAndResponse.Cancelled {
+		t.Fatalf("expected a cancelled request, got %v, %+v", err, requestAndResponse)
+	}
+	history := gogetter.History()
+	if len(history) != 1 || !history[0].Cancelled || !strings.HasSuffix(history[0].String(), "(cancelled)") {
+		t.Fatalf("cancellation not recorded: %v", history)
+	}
+
+	reloaded, err := app.NewGogetter(nil, app.WithHistory{PreviousHistory: strings.NewReader(writtenHistory)})
+	if err != nil {
+		t.Fatalf("history reloading failed: %v", err)
+	}
+	if history := reloaded.History(); len(history) != 1 || !history[0].Cancelled {
+		t.Fatalf("cancellation not persisted: %v", writtenHistory)
+	}
+}
+
+func TestShouldNotRecordTimeoutsAsCancellations(t *testing.T) {
+	server := newHungServer(t)
+	gogetter, err := app.NewGogetter(app.NewHttpClient(), app.WithClientSettings{Settings: strings.NewReader(`{"timeout": "50ms"}`)})
+	if err != nil {
+		t.Fatalf("new gogetter failed: %v", err)
+	}
+	request, err := app.ParseRequest(fmt.Sprintf("GET %v/hung", server.URL))
+	if err != nil {
+		t.Fatalf("request parsing failed: %v", err)
+	}
+	gogetter, _, _, err = gogetter.Execute(context.Background(), request)
+	if err == nil || errors.Is(err, app.ErrRequestCancelled) || len(gogetter.History()) != 0 {
+		t.Fatalf("expected a timeout error, got %v, %v", err, gogetter.History())
+	}
+}
+
+func TestShouldSkipWorkflowStepsOnceCancelled(t *testing.T) {
+	server := newHungServer(t)
+	gogetter, err := app.NewGogetter(app.NewHttpClient(),
+		app.WithSavedRequests{InitialSavedRequests: strings.NewReader(fmt.Sprintf(`[
+			"# name: Fast\nGET %[1]v/fast",
+			"# name: Hung\nGET %[1]v/hung",
+			"# name: Next\nGET %[1]v/fast"
+		]`, server.URL))},
+	)
+	if err != nil {
+		t.Fatalf("new gogetter failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	_, report := gogetter.RunWorkflow(ctx, app.Workflow{Steps: gogetter.SavedRequests()})
+	if report.Passed() != 1 || report.Failed() != 1 || report.Skipped() != 1 || !errors.Is(report.Steps[1].Err, app.ErrRequestCancelled) {
+		t.Fatalf("unexpected report %v", report)
+	}
+}
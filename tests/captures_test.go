@@ -0,0 +1,126 @@
+package tests_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/ThomasFerro/gogetter/app"
+	"github.com/ThomasFerro/gogetter/cli"
+	"github.com/ThomasFerro/gogetter/tests"
+)
+
+func TestShouldParseCaptures(t *testing.T) {
+	request, err := app.ParseRequest(`POST https://api.com/login {"user":"gopher"}
+capture token json $.access_token
+capture location header Location
+capture code status`)
+	if err != nil {
+		t.Fatalf("request parsing failed: %v", err)
+	}
+	if request.JsonBody != `{"user":"gopher"}` || len(request.Captures) != 3 {
+		t.Fatalf("request not parsed correctly: %+v", request)
+	}
+	expected := app.Capture{Variable: "location", Subject: app.HeaderAssertionSubject, Target: "Location"}
+	if request.Captures[1] != expected {
+		t.Fatalf("unexpected capture: %+v", request.Captures[1])
+	}
+	formatted, err := app.FormatRequest(request)
+	if err != nil {
+		t.Fatalf("request formatting failed: %v", err)
+	}
+	if !strings.HasSuffix(formatted, "capture location header Location\ncapture code status") {
+		t.Fatalf("captures not formatted: %q", formatted)
+	}
+
+	for _, line := range []string{
+		"capture token",
+		"capture access-token json $.token",
+		"capture token json token",
+		"capture token header",
+		"capture token status 200",
+		"capture token cookie session",
+	} {
+		_, err := app.ParseRequest("GET https://api.com\n" + line)
+		if err == nil {
+			t.Fatalf("expected an error for %q", line)
+		}
+	}
+}
+
+func TestShouldTemplateNextRequestsWithCapturedValues(t *testing.T) {
+	gogetter := tests.NewTestSetup(
+		t,
+		tests.SubstitutedRequest{
+			Request:         app.Request{Method: "POST", Url: "https://api.com/login"},
+			Response:        `{"access_token":"abc","user":{"id":12}}`,
+			ResponseCode:    200,
+			ResponseHeaders: http.Header{"Location": {"/users/12"}},
+		},
+		tests.SubstitutedRequest{
+			Request:  app.Request{Method: "GET", Url: "https://api.com/users/12", Headers: app.Headers{"Authorization": "Bearer abc"}},
+			Response: "gopher", ResponseCode: 200,
+		},
+	)
+	request, err := app.ParseRequest(`POST https://api.com/login
+capture token json $.access_token
+capture id json $.user.id
+capture location header Location
+capture missing json $.refresh_token`)
+	if err != nil {
+		t.Fatalf("request parsing failed: %v", err)
+	}
+	gogetter, requestAndResponse, _, err := gogetter.Execute(context.Background(), request)
+	if err != nil {
+		t.Fatalf("request execution failed: %v", err)
+	}
+	if requestAndResponse.CapturesSucceeded() || requestAndResponse.Captures[3].String() != "missing not captured: no value at this JSON path" {
+		t.Fatalf("unexpected captures: %v", requestAndResponse.Captures)
+	}
+	captured := gogetter.CapturedVariables()
+	if len(captured) != 3 || captured["token"] != "abc" || captured["id"] != "12" || captured["location"] != "/users/12" {
+		t.Fatalf("unexpected captured variables: %v", captured)
+	}
+
+	data, err := gogetter.TemplateData(nil)
+	if err != nil {
+		t.Fatalf("template data failed: %v", err)
+	}
+	request, err = app.ParseRequest(`GET https://api.com{{.location}} Authorization=:"Bearer {{.token}}"`, app.TemplatedRequestOption{Data: data})
+	if err != nil {
+		t.Fatalf("request parsing failed: %v", err)
+	}
+	_, requestAndResponse, _, err = gogetter.Execute(context.Background(), request)
+	if err != nil || requestAndResponse.ResponseCode != 200 {
+		t.Fatalf("templated request execution failed: %v", err)
+	}
+
+	data, err = gogetter.TemplateData(map[string]any{"token": "overridden"})
+	if err != nil || data.(app.Variables)["token"] != "overridden" {
+		t.Fatalf("ad-hoc variables should override the captured ones: %v %v", data, err)
+	}
+	if len(gogetter.ClearCapturedVariables().CapturedVariables()) != 0 {
+		t.Fatal("captured variables not cleared")
+	}
+}
+
+func TestShouldChainSavedRequestsInTests(t *testing.T) {
+	gogetter := tests.NewGogetter(t,
+		tests.NewTestClient(
+			tests.SubstitutedRequest{Request: app.Request{Method: "POST", Url: "https://api.com/login"}, Response: `{"token":"abc"}`, ResponseCode: 200},
+			tests.SubstitutedRequest{Request: app.Request{Method: "GET", Url: "https://api.com/me", Headers: app.Headers{"X-Token": "abc"}}, Response: "gopher", ResponseCode: 200},
+		),
+		app.WithSavedRequests{InitialSavedRequests: strings.NewReader(`[
+			"# name: Login\nPOST https://api.com/login\ncapture token json $.token",
+			"# name: Me\nGET https://api.com/me X-Token=:{{.token}}\nassert body contains gopher"
+		]`)},
+	)
+	stdout := &bytes.Buffer{}
+
+	_, exitCode := cli.Test(gogetter, nil, stdout, &bytes.Buffer{})
+	if exitCode != cli.SuccessExitCode {
+		t.Fatalf("expected success exit code but got %v:\n%v", exitCode, stdout.String())
+	}
+}
@@ -0,0 +1,190 @@
+package tests_test
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ThomasFerro/gogetter/app"
+	"github.com/ThomasFerro/gogetter/tests"
+)
+
+func newClientSettingsGogetter(t *testing.T, settings string) app.Gogetter {
+	return tests.NewGogetter(t, app.NewHttpClient(), app.WithClientSettings{Settings: strings.NewReader(settings)})
+}
+
+func TestShouldTimeOutHungRequests(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/hung" {
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+		}
+		w.Write([]byte("ok"))
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { close(release) })
+	gogetter := newClientSettingsGogetter(t, `{"timeout": "50ms"}`)
+
+	_, _, err := tests.ExecuteRawRequest(gogetter, fmt.Sprintf("GET %v/hung", server.URL))
+	if err == nil || !strings.Contains(err.Error(), "no response within 50ms") {
+		t.Fatalf("expected a timeout, got %v", err)
+	}
+	if _, status, err := tests.ExecuteRawRequest(gogetter, fmt.Sprintf("GET %v/fast", server.URL)); err != nil || status != http.StatusOK {
+		t.Fatalf("fast request failed: %v, %v", status, err)
+	}
+
+	unbounded := newClientSettingsGogetter(t, "")
+	_, _, err = tests.ExecuteRawRequest(unbounded, fmt.Sprintf("GET %v/hung\noption timeout 50ms", server.URL))
+	if err == nil || !strings.Contains(err.Error(), "no response within 50ms") {
+		t.Fatalf("expected the timeout of the request, got %v", err)
+	}
+}
+
+func TestShouldApplyRedirectPolicies(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		remaining, _ := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/redirects/"))
+		if remaining > 0 {
+			http.Redirect(w, r, fmt.Sprintf("/redirects/%v", remaining-1), http.StatusFound)
+			return
+		}
+		w.Write([]byte("arrived"))
+	}))
+	t.Cleanup(server.Close)
+
+	gogetter := newClientSettingsGogetter(t, "")
+	if _, status, err := tests.ExecuteRawRequest(gogetter, fmt.Sprintf("GET %v/redirects/3", server.URL)); err != nil || status != http.StatusOK {
+		t.Fatalf("redirects not followed: %v, %v", status, err)
+	}
+	if _, status, err := tests.ExecuteRawRequest(gogetter, fmt.Sprintf("GET %v/redirects/3\noption redirects never", server.URL)); err != nil || status != http.StatusFound {
+		t.Fatalf("redirect followed: %v, %v", status, err)
+	}
+	if _, _, err := tests.ExecuteRawRequest(gogetter, fmt.Sprintf("GET %v/redirects/3\noption redirects 2", server.URL)); err == nil || !strings.Contains(err.Error(), "stopped after 2 redirects") {
+		t.Fatalf("expected the redirects to be limited, got %v", err)
+	}
+
+	never := newClientSettingsGogetter(t, `{"redirects": "never"}`)
+	if _, status, err := tests.ExecuteRawRequest(never, fmt.Sprintf("GET %v/redirects/1", server.URL)); err != nil || status != http.StatusFound {
+		t.Fatalf("redirect followed despite the settings: %v, %v", status, err)
+	}
+	if _, status, err := tests.ExecuteRawRequest(never, fmt.Sprintf("GET %v/redirects/1\noption redirects follow", server.URL)); err != nil || status != http.StatusOK {
+		t.Fatalf("redirect not followed despite the option: %v, %v", status, err)
+	}
+}
+
+func TestShouldSendRequestsThroughProxy(t *testing.T) {
+	proxiedHosts := []string{}
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxiedHosts = append(proxiedHosts, r.URL.Host)
+		w.Write([]byte("proxied"))
+	}))
+	t.Cleanup(proxy.Close)
+
+	gogetter := newClientSettingsGogetter(t, fmt.Sprintf(`{"proxy": %q}`, proxy.URL))
+	if _, status, err := tests.ExecuteRawRequest(gogetter, "GET http://api.invalid/posts"); err != nil || status != http.StatusOK {
+		t.Fatalf("request not proxied: %v, %v", status, err)
+	}
+	if len(proxiedHosts) != 1 || proxiedHosts[0] != "api.invalid" {
+		t.Fatalf("unexpected proxied hosts %v", proxiedHosts)
+	}
+	if _, _, err := tests.ExecuteRawRequest(gogetter, "GET http://api.invalid/posts\noption proxy off"); err == nil {
+		t.Fatal("request proxied despite the option")
+	}
+
+	if _, err := app.ParseRequest("GET http://api.invalid\noption proxy ftp://proxy.local"); err == nil {
+		t.Fatal("unsupported proxy scheme accepted")
+	}
+	if _, err := app.NewGogetter(app.NewHttpClient(), app.WithClientSettings{Settings: strings.NewReader(`{"redirects": "sometimes"}`)}); err == nil {
+		t.Fatal("invalid redirects setting accepted")
+	}
+}
+
+// writeServerCertificate writes the certificate and key of the TLS server as
+// PEM files, the certificate being its own authority
+func writeServerCertificate(t *testing.T, server *httptest.Server) (string, string) {
+	certificate := server.TLS.Certificates[0]
+	key, err := x509.MarshalPKCS8PrivateKey(certificate.PrivateKey)
+	if err != nil {
+		t.Fatalf("key marshal failed: %v", err)
+	}
+	directory := t.TempDir()
+	certificateFilename := filepath.Join(directory, "cert.pem")
+	keyFilename := filepath.Join(directory, "key.pem")
+	err = os.WriteFile(certificateFilename, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate.Certificate[0]}), 0600)
+	if err == nil {
+		err = os.WriteFile(keyFilename, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: key}), 0600)
+	}
+	if err != nil {
+		t.Fatalf("certificate writing failed: %v", err)
+	}
+	return certificateFilename, keyFilename
+}
+
+func TestShouldConfigureTls(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) == 0 {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte("mutual"))
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequestClientCert}
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	server.StartTLS()
+	t.Cleanup(server.Close)
+	certificate, key := writeServerCertificate(t, server)
+	rawRequest := fmt.Sprintf("GET %v", server.URL)
+
+	if _, _, err := tests.ExecuteRawRequest(newClientSettingsGogetter(t, ""), rawRequest); err == nil {
+		t.Fatal("unknown authority trusted")
+	}
+	if _, status, err := tests.ExecuteRawRequest(newClientSettingsGogetter(t, ""), rawRequest+"\noption insecure on"); err != nil || status != http.StatusUnauthorized {
+		t.Fatalf("insecure request failed: %v, %v", status, err)
+	}
+	if _, status, err := tests.ExecuteRawRequest(newClientSettingsGogetter(t, `{"insecure": true}`), rawRequest+"\noption insecure off"); err == nil {
+		t.Fatalf("unknown authority trusted despite the option: %v", status)
+	}
+
+	trusting := newClientSettingsGogetter(t, fmt.Sprintf(`{"ca_bundle": %q}`, certificate))
+	if _, status, err := tests.ExecuteRawRequest(trusting, rawRequest); err != nil || status != http.StatusUnauthorized {
+		t.Fatalf("ca bundle not trusted: %v, %v", status, err)
+	}
+	trusting, err := trusting.OverrideClientSettings(app.ClientSettings{ClientCertificate: certificate, ClientKey: key})
+	if err != nil {
+		t.Fatalf("client settings override failed: %v", err)
+	}
+	if _, status, err := tests.ExecuteRawRequest(trusting, rawRequest); err != nil || status != http.StatusOK {
+		t.Fatalf("client certificate not sent: %v, %v", status, err)
+	}
+	if _, err := trusting.OverrideClientSettings(app.ClientSettings{ClientCertificate: certificate}); err == nil {
+		t.Fatal("client certificate without key accepted")
+	}
+}
+
+func TestShouldFormatClientOptions(t *testing.T) {
+	rawRequest := "GET https://api.com\noption timeout 1m30s\noption redirects 3\noption proxy socks5://localhost:1080\noption insecure off"
+	request, err := app.ParseRequest(rawRequest)
+	if err != nil {
+		t.Fatalf("request parsing failed: %v", err)
+	}
+	if *request.Options.Timeout != 90*time.Second || request.Options.Redirects != "3" || request.Options.Proxy != "socks5://localhost:1080" || *request.Options.Insecure {
+		t.Fatalf("unexpected options %+v", request.Options)
+	}
+	formatted, err := app.FormatRequest(request)
+	if err != nil || formatted != rawRequest {
+		t.Fatalf("unexpected formatted request %q, %v", formatted, err)
+	}
+}
@@ -0,0 +1,158 @@
+package tests_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ThomasFerro/gogetter/app"
+	"github.com/ThomasFerro/gogetter/storage"
+	"github.com/ThomasFerro/gogetter/tests"
+)
+
+func writeCollectionFile(t *testing.T, root string, path string, content string) {
+	filename := filepath.Join(root, filepath.FromSlash(path))
+	err := os.MkdirAll(filepath.Dir(filename), 0o755)
+	if err != nil {
+		t.Fatalf("collection folder creation failed: %v", err)
+	}
+	err = os.WriteFile(filename, []byte(content), 0o644)
+	if err != nil {
+		t.Fatalf("collection file writing failed: %v", err)
+	}
+}
+
+func TestShouldLoadSavedRequestsFromACollection(t *testing.T) {
+	root := t.TempDir()
+	writeCollectionFile(t, root, "get-pkg.gg", "GET https://pkg.go.dev\n")
+	writeCollectionFile(t, root, "posts/create.gg", "POST https://my-api.com/posts\n{\"key\":\"value\"}\n")
+	writeCollectionFile(t, root, "posts/README.md", "not a request")
+
+	gogetter, err := app.NewGogetter(tests.NewTestClient(), app.WithSavedRequestsCollection{Collection: storage.DirectoryCollection{Root: root}})
+	if err != nil {
+		t.Fatalf("new gogetter failed: %v", err)
+	}
+
+	savedRequests := gogetter.SavedRequests()
+	if len(savedRequests) != 2 ||
+		savedRequests[0].Path != "get-pkg.gg" ||
+		savedRequests[0].Method != "GET" ||
+		savedRequests[1].Path != "posts/create.gg" ||
+		savedRequests[1].Method != "POST" ||
+		savedRequests[1].Url != "https://my-api.com/posts" {
+		t.Fatalf("saved requests not loaded correctly: %v", savedRequests)
+	}
+}
+
+func TestShouldSaveRequestsInTheirOwnFile(t *testing.T) {
+	root := t.TempDir()
+	gogetter, err := app.NewGogetter(tests.NewTestClient(), app.WithSavedRequestsCollection{Collection: storage.DirectoryCollection{Root: root}})
+	if err != nil {
+		t.Fatalf("new gogetter failed: %v", err)
+	}
+
+	request, err := app.ParseRequest("POST https://my-api.com/posts name=value")
+	if err != nil {
+		t.Fatalf("request parsing failed: %v", err)
+	}
+	gogetter, err = gogetter.SaveRequest(request)
+	if err != nil {
+		t.Fatalf("request saving failed: %v", err)
+	}
+	gogetter, err = gogetter.AddSavedRequest(app.SavedRequest{Request: request}, "posts")
+	if err != nil {
+		t.Fatalf("request saving failed: %v", err)
+	}
+	gogetter, err = gogetter.SaveRequest(request)
+	if err != nil {
+		t.Fatalf("request saving failed: %v", err)
+	}
+
+	savedRequests := gogetter.SavedRequests()
+	if len(savedRequests) != 3 ||
+		savedRequests[0].Path != "post-my-api.com-posts.gg" ||
+		savedRequests[1].Path != "posts/post-my-api.com-posts.gg" ||
+		savedRequests[2].Path != "post-my-api.com-posts-2.gg" {
+		t.Fatalf("saved requests paths not generated correctly: %v", savedRequests)
+	}
+	content, err := os.ReadFile(filepath.Join(root, "posts", "post-my-api.com-posts.gg"))
+	if err != nil {
+		t.Fatalf("saved request file reading failed: %v", err)
+	}
+	if string(content) != "POST https://my-api.com/posts name=value\n" {
+		t.Fatalf("saved request not written correctly: %q", string(content))
+	}
+}
+
+func TestShouldRemoveSavedRequestFileFromTheCollection(t *testing.T) {
+	root := t.TempDir()
+	writeCollectionFile(t, root, "get-pkg.gg", "GET https://pkg.go.dev\n")
+	writeCollectionFile(t, root, "posts/create.gg", "POST https://my-api.com/posts\n")
+	gogetter, err := app.NewGogetter(tests.NewTestClient(), app.WithSavedRequestsCollection{Collection: storage.DirectoryCollection{Root: root}})
+	if err != nil {
+		t.Fatalf("new gogetter failed: %v", err)
+	}
+
+	gogetter, err = gogetter.RemoveSavedRequest(1)
+	if err != nil {
+		t.Fatalf("remove saved request failed: %v", err)
+	}
+
+	if len(gogetter.SavedRequests()) != 1 {
+		t.Fatalf("saved request not removed: %v", gogetter.SavedRequests())
+	}
+	if _, err := os.Stat(filepath.Join(root, "posts")); !os.IsNotExist(err) {
+		t.Fatalf("expected the empty folder to be removed: %v", err)
+	}
+	if _, err := os.Stat(root); err != nil {
+		t.Fatalf("expected the collection root to be kept: %v", err)
+	}
+}
+
+func TestShouldWorkWithoutCollectionFolderYet(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "missing")
+	gogetter, err := app.NewGogetter(tests.NewTestClient(), app.WithSavedRequestsCollection{Collection: storage.DirectoryCollection{Root: root}})
+	if err != nil {
+		t.Fatalf("new gogetter failed: %v", err)
+	}
+	if len(gogetter.SavedRequests()) != 0 {
+		t.Fatalf("expected no saved requests: %v", gogetter.SavedRequests())
+	}
+}
+
+func TestShouldKeepSavedRequestMetadataInCollectionFiles(t *testing.T) {
+	root := t.TempDir()
+	writeCollectionFile(t, root, "posts/create.gg", "# name: Create post\n# tags: posts\nPOST https://my-api.com/posts\n")
+	gogetter, err := app.NewGogetter(tests.NewTestClient(), app.WithSavedRequestsCollection{Collection: storage.DirectoryCollection{Root: root}})
+	if err != nil {
+		t.Fatalf("new gogetter failed: %v", err)
+	}
+	savedRequests := gogetter.SavedRequests()
+	if len(savedRequests) != 1 || savedRequests[0].Name != "Create post" || savedRequests[0].Tags[0] != "posts" {
+		t.Fatalf("saved request metadata not loaded correctly: %+v", savedRequests)
+	}
+
+	gogetter, err = gogetter.EditSavedRequest(0, app.SavedRequestMetadata{Name: "Create a post", Description: "Admin only"})
+	if err != nil {
+		t.Fatalf("saved request edition failed: %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(root, "posts", "create.gg"))
+	if err != nil {
+		t.Fatalf("saved request file reading failed: %v", err)
+	}
+	if string(content) != "# name: Create a post\n# description: Admin only\nPOST https://my-api.com/posts\n" {
+		t.Fatalf("saved request not written correctly: %q", string(content))
+	}
+
+	request, err := app.ParseRequest("GET https://pkg.go.dev")
+	if err != nil {
+		t.Fatalf("request parsing failed: %v", err)
+	}
+	gogetter, err = gogetter.AddSavedRequest(app.SavedRequest{Request: request, SavedRequestMetadata: app.SavedRequestMetadata{Name: "Go packages"}}, "")
+	if err != nil {
+		t.Fatalf("request saving failed: %v", err)
+	}
+	if gogetter.SavedRequests()[1].Path != "go-packages.gg" {
+		t.Fatalf("expected path to be derived from the name: %v", gogetter.SavedRequests()[1].Path)
+	}
+}
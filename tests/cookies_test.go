@@ -0,0 +1,147 @@
+package tests_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ThomasFerro/gogetter/app"
+	"github.com/ThomasFerro/gogetter/tests"
+)
+
+// newSessionServer sets a session cookie on /login before redirecting to
+// /profile, which is only served to the holders of the session
+func newSessionServer(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "s3ss10n", Path: "/", HttpOnly: true})
+		http.Redirect(w, r, "/profile", http.StatusFound)
+	})
+	mux.HandleFunc("/profile", func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie("session")
+		if err != nil || cookie.Value != "s3ss10n" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte("gopher"))
+	})
+	mux.HandleFunc("/preferences", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/theme", http.StatusFound)
+	})
+	mux.HandleFunc("/theme", func(w http.ResponseWriter, r *http.Request) {
+		sessions := []string{}
+		for _, cookie := range r.Cookies() {
+			if cookie.Name == "session" {
+				sessions = append(sessions, cookie.Value)
+			}
+		}
+		theme, err := r.Cookie("theme")
+		if err != nil || theme.Value != "dark" || len(sessions) != 1 || sessions[0] != "s3ss10n" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte("dark"))
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newCookiesGogetter(t *testing.T, cookies string, savingFunc func([]byte) error) app.Gogetter {
+	return tests.NewGogetter(t, &http.Client{CheckRedirect: app.CheckRedirect},
+		app.WithEnvironments{Environments: strings.NewReader(`{"dev": {}, "prod": {}}`), ActiveEnvironment: "dev"},
+		app.WithCookieJar{Cookies: strings.NewReader(cookies), CookiesSavingFunc: savingFunc},
+	).EnableCookies(true)
+}
+
+func TestShouldKeepCookiesSetDuringRedirects(t *testing.T) {
+	server := newSessionServer(t)
+	savedCookies := ""
+	gogetter := newCookiesGogetter(t, "", func(content []byte) error { savedCookies = string(content); return nil })
+
+	gogetter, status := tests.ExecuteForStatus(t, gogetter, fmt.Sprintf("GET %v/login", server.URL))
+	if status != http.StatusOK {
+		t.Fatalf("session cookie not sent to the redirect target: %v", status)
+	}
+	gogetter, status = tests.ExecuteForStatus(t, gogetter, fmt.Sprintf("GET %v/profile", server.URL))
+	if status != http.StatusOK {
+		t.Fatalf("session cookie not sent by the next request: %v", status)
+	}
+	cookies := gogetter.Cookies()
+	if len(cookies) != 1 || cookies[0].String() != "session=s3ss10n" || !cookies[0].HttpOnly || !strings.Contains(savedCookies, `"dev"`) {
+		t.Fatalf("session cookie not stored: %+v, saved: %v", cookies, savedCookies)
+	}
+
+	prod, err := gogetter.SelectEnvironment("prod")
+	if err != nil {
+		t.Fatalf("environment selection failed: %v", err)
+	}
+	if _, status := tests.ExecuteForStatus(t, prod, fmt.Sprintf("GET %v/profile", server.URL)); status != http.StatusUnauthorized {
+		t.Fatalf("cookie of dev sent in prod: %v", status)
+	}
+
+	reloaded := newCookiesGogetter(t, savedCookies, nil)
+	if _, status := tests.ExecuteForStatus(t, reloaded, fmt.Sprintf("GET %v/profile", server.URL)); status != http.StatusOK {
+		t.Fatalf("saved cookies not reloaded: %v", status)
+	}
+	if _, status := tests.ExecuteForStatus(t, reloaded.EnableCookies(false), fmt.Sprintf("GET %v/profile", server.URL)); status != http.StatusUnauthorized {
+		t.Fatalf("cookies sent while disabled: %v", status)
+	}
+}
+
+func TestShouldKeepExplicitCookiesThroughRedirects(t *testing.T) {
+	server := newSessionServer(t)
+	gogetter := newCookiesGogetter(t, "", nil)
+	gogetter, _ = tests.ExecuteForStatus(t, gogetter, fmt.Sprintf("GET %v/login", server.URL))
+
+	_, status := tests.ExecuteForStatus(t, gogetter, fmt.Sprintf(`GET %v/preferences Cookie=:"theme=dark; session=forged"`, server.URL))
+	if status != http.StatusOK {
+		t.Fatalf("explicit cookies not kept along the jar ones: %v", status)
+	}
+}
+
+func TestShouldSkipCookiesPerRequest(t *testing.T) {
+	server := newSessionServer(t)
+	gogetter := newCookiesGogetter(t, "", nil)
+
+	gogetter, status := tests.ExecuteForStatus(t, gogetter, fmt.Sprintf("GET %v/login\noption cookies off", server.URL))
+	if status != http.StatusUnauthorized || len(gogetter.Cookies()) != 0 {
+		t.Fatalf("cookies used despite the option: %v, %+v", status, gogetter.Cookies())
+	}
+
+	request, err := app.ParseRequest(fmt.Sprintf("GET %v/login\noption cookies off", server.URL))
+	if err != nil {
+		t.Fatalf("request parsing failed: %v", err)
+	}
+	formatted, err := app.FormatRequest(request)
+	if err != nil || !strings.Contains(formatted, "\noption cookies off") {
+		t.Fatalf("option not formatted: %q, %v", formatted, err)
+	}
+	if _, err := app.ParseRequest("GET https://api.com\noption cookies maybe"); err == nil {
+		t.Fatal("invalid option value accepted")
+	}
+}
+
+func TestShouldEditAndRemoveCookies(t *testing.T) {
+	gogetter := newCookiesGogetter(t, `{"dev": [{"name": "session", "value": "old", "domain": "api.com", "path": "/"}]}`, nil)
+	previous := gogetter.Cookies()[0]
+
+	edited := previous
+	edited.Value = "new"
+	edited.Path = "/v2"
+	gogetter, err := gogetter.SaveCookie(previous, edited)
+	if err != nil {
+		t.Fatalf("cookie edition failed: %v", err)
+	}
+	cookies := gogetter.Cookies()
+	if len(cookies) != 1 || cookies[0].Value != "new" || cookies[0].Path != "/v2" {
+		t.Fatalf("cookie not edited: %+v", cookies)
+	}
+
+	gogetter, err = gogetter.RemoveCookie(cookies[0])
+	if err != nil || len(gogetter.Cookies()) != 0 {
+		t.Fatalf("cookie not removed: %+v, %v", gogetter.Cookies(), err)
+	}
+}
@@ -0,0 +1,123 @@
+package tests_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ThomasFerro/gogetter/app"
+	"github.com/ThomasFerro/gogetter/tests"
+)
+
+func TestShouldImportSimpleCurlCommand(t *testing.T) {
+	request, err := app.ParseCurl("curl https://pkg.go.dev")
+	if err != nil {
+		t.Fatalf("curl parsing failed: %v", err)
+	}
+	if request.Method != "GET" || request.Url != "https://pkg.go.dev" || request.Raw != "GET https://pkg.go.dev" {
+		t.Fatalf("curl command not imported correctly: %+v", request)
+	}
+}
+
+func TestShouldImportCurlCommandFromDevtools(t *testing.T) {
+	command := `curl 'https://api.com/posts?page=1' \
+  -H 'accept: application/json' \
+  -H "Authorization: Bearer my token" \
+  -H 'content-type: application/json' \
+  --data-raw $'{"title":"it\'s here","tags":["a"]}' \
+  --compressed`
+	request, err := app.ParseCurl(command)
+	if err != nil {
+		t.Fatalf("curl parsing failed: %v", err)
+	}
+	expectedRaw := `POST https://api.com/posts?page=1
+Accept=:application/json Authorization=:"Bearer my token"
+{"title":"it's here","tags":["a"]}`
+	if request.Raw != expectedRaw {
+		t.Fatalf("unexpected raw request:\n%v", request.Raw)
+	}
+	if request.Method != "POST" ||
+		request.Headers["Authorization"] != "Bearer my token" ||
+		request.Headers["Accept"] != "application/json" ||
+		string(request.JsonBody) != `{"title":"it's here","tags":["a"]}` {
+		t.Fatalf("curl command not imported correctly: %+v", request)
+	}
+}
+
+func TestShouldImportCurlFormAndAuthentication(t *testing.T) {
+	request, err := app.ParseCurl(`curl -XPUT -u user:password -F name=gopher -F "description=a blue one" --url https://api.com/animals/1`)
+	if err != nil {
+		t.Fatalf("curl parsing failed: %v", err)
+	}
+	if request.Method != "PUT" ||
+		request.Url != "https://api.com/animals/1" ||
+		request.Auth == nil || request.Auth.String() != "auth basic user password" ||
+		request.MultipartBody["name"] != "gopher" ||
+		request.MultipartBody["description"] != "a blue one" {
+		t.Fatalf("curl command not imported correctly: %+v", request)
+	}
+}
+
+func TestShouldImportCurlDigestAuthentication(t *testing.T) {
+	request, err := app.ParseCurl(`curl --digest -u 'gopher:my secret' https://api.com/protected`)
+	if err != nil {
+		t.Fatalf("curl parsing failed: %v", err)
+	}
+	if request.Raw != "GET https://api.com/protected\nauth digest gopher \"my secret\"" || len(request.Headers) != 0 {
+		t.Fatalf("curl command not imported correctly: %+v", request)
+	}
+}
+
+func TestShouldImportCurlDataAsSearchParamsWithGet(t *testing.T) {
+	request, err := app.ParseCurl(`curl -G https://api.com/search -d q=go%20lang -d page=2`)
+	if err != nil {
+		t.Fatalf("curl parsing failed: %v", err)
+	}
+	if request.Method != "GET" || request.SearchParams["q"] != "go lang" || request.SearchParams["page"] != "2" {
+		t.Fatalf("curl command not imported correctly: %+v", request)
+	}
+}
+
+func TestShouldSendImportedCurlCommand(t *testing.T) {
+	gogetter := tests.NewTestSetup(
+		t,
+		tests.SubstitutedRequest{
+			Request: app.Request{
+				Method:   "POST",
+				Url:      "https://api.com/posts",
+				Headers:  app.Headers{"Content-Type": "application/json"},
+				JsonBody: `{"title":"hello"}`,
+			},
+			Response: "created", ResponseCode: 201,
+		},
+	)
+	request, err := app.ParseCurl(`curl -X POST https://api.com/posts -d '{"title":"hello"}'`)
+	if err != nil {
+		t.Fatalf("curl parsing failed: %v", err)
+	}
+	_, requestAndResponse, _, err := gogetter.Execute(context.Background(), request)
+	if err != nil {
+		t.Fatalf("request execution failed: %v", err)
+	}
+	if requestAndResponse.ResponseCode != 201 {
+		t.Fatalf("unexpected response code: %v", requestAndResponse.ResponseCode)
+	}
+}
+
+func TestShouldRejectInvalidCurlCommands(t *testing.T) {
+	for _, command := range []string{
+		"wget https://pkg.go.dev",
+		"curl -H 'Accept: text/html'",
+		"curl 'https://pkg.go.dev",
+		"curl https://api.com -F file=@picture.png",
+		"curl https://api.com -d @body.json",
+		`curl https://api.com -H 'Content-Type: application/xml' -d '<post id="1"><title>hello</title></post>'`,
+		"curl https://api.com -d 'plain text'",
+		"curl https://api.com -d name=gopher -d flag",
+		"curl https://api.com -T picture.png",
+		"curl https://api.com --aws-sigv4 aws:amz",
+	} {
+		if _, err := app.ParseCurl(command); err == nil {
+			t.Fatalf("expected %v to be rejected", command)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package tests_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ThomasFerro/gogetter/app"
+	"github.com/ThomasFerro/gogetter/helpers"
+	"github.com/ThomasFerro/gogetter/tests"
+)
+
+const environmentsFile = `{
+  "staging": { "BaseUrl": "https://staging.pkg.go.dev", "Id": "1" },
+  "dev": { "BaseUrl": "https://dev.pkg.go.dev", "Id": "2" }
+}`
+
+func TestShouldProvideEnvironments(t *testing.T) {
+	gogetter, err := app.NewGogetter(tests.NewTestClient(), app.WithEnvironments{Environments: strings.NewReader(environmentsFile)})
+	if err != nil {
+		t.Fatalf("new gogetter failed: %v", err)
+	}
+
+	environments := gogetter.Environments()
+	if len(environments) != 2 ||
+		environments[0].Name != "dev" ||
+		environments[0].Variables["BaseUrl"] != "https://dev.pkg.go.dev" ||
+		environments[1].Name != "staging" {
+		t.Fatalf("environments not filled correctly: %v", environments)
+	}
+	if _, ok := gogetter.ActiveEnvironment(); ok {
+		t.Fatalf("expected no active environment")
+	}
+}
+
+func TestShouldCycleThroughEnvironments(t *testing.T) {
+	gogetter, err := app.NewGogetter(tests.NewTestClient(), app.WithEnvironments{Environments: strings.NewReader(environmentsFile)})
+	if err != nil {
+		t.Fatalf("new gogetter failed: %v", err)
+	}
+
+	expectedEnvironments := []string{"dev", "staging", "", "dev"}
+	for _, expectedEnvironment := range expectedEnvironments {
+		gogetter = gogetter.NextEnvironment()
+		environment, _ := gogetter.ActiveEnvironment()
+		if environment.Name != expectedEnvironment {
+			t.Fatalf("expected active environment %q but got %q", expectedEnvironment, environment.Name)
+		}
+	}
+}
+
+func TestShouldRejectUnknownEnvironment(t *testing.T) {
+	_, err := app.NewGogetter(tests.NewTestClient(), app.WithEnvironments{Environments: strings.NewReader(environmentsFile), ActiveEnvironment: "prod"})
+	if err == nil {
+		t.Fatalf("expected unknown environment to be rejected")
+	}
+}
+
+func TestShouldMergeAdHocVariablesOverTheActiveEnvironment(t *testing.T) {
+	testClient := tests.NewTestClient(
+		tests.SubstitutedRequest{Request: app.Request{Method: "GET", Url: "https://staging.pkg.go.dev/42"}, Response: "ok"},
+	)
+	gogetter, err := app.NewGogetter(testClient, app.WithEnvironments{Environments: strings.NewReader(environmentsFile), ActiveEnvironment: "staging"})
+	if err != nil {
+		t.Fatalf("new gogetter failed: %v", err)
+	}
+
+	data, err := gogetter.TemplateData(map[string]any{"Id": "42"})
+	if err != nil {
+		t.Fatalf("template data merging failed: %v", err)
+	}
+	request, err := app.ParseRequest("GET {{.BaseUrl}}/{{.Id}}", app.TemplatedRequestOption{Data: data})
+	if err != nil {
+		t.Fatalf("request parsing failed: %v", err)
+	}
+	_, _, _, err = gogetter.Execute(context.Background(), request)
+	if err != nil {
+		t.Fatalf("request execution failed: %v", err)
+	}
+
+	_, err = gogetter.TemplateData([]any{"not", "an", "object"})
+	if err == nil {
+		t.Fatalf("expected non object variables to be rejected")
+	}
+}
+
+func TestShouldWorkWithoutEnvironmentsYet(t *testing.T) {
+	gogetter, err := app.NewGogetter(tests.NewTestClient(), app.WithEnvironments{Environments: helpers.EmptyReadCloser{}})
+	if err != nil {
+		t.Fatalf("new gogetter failed: %v", err)
+	}
+	data, err := gogetter.TemplateData(map[string]any{"Id": "42"})
+	if err != nil {
+		t.Fatalf("template data merging failed: %v", err)
+	}
+	if data.(map[string]any)["Id"] != "42" {
+		t.Fatalf("ad-hoc variables not kept: %v", data)
+	}
+}
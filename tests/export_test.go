@@ -0,0 +1,169 @@
+package tests_test
+
+import (
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"github.com/ThomasFerro/gogetter/app"
+)
+
+func TestShouldExportRequestAsCurl(t *testing.T) {
+	request, err := app.ParseRequest(`POST https://api.com/posts?page=1 X-Api-Key=:"it's secret" sort=?date {"title":"hello"}`)
+	if err != nil {
+		t.Fatalf("request parsing failed: %v", err)
+	}
+	exported, err := app.Export(request, app.CurlExportFormat)
+	if err != nil {
+		t.Fatalf("request export failed: %v", err)
+	}
+	expected := `curl \
+  -X POST \
+  'https://api.com/posts?page=1&sort=date' \
+  -H 'Content-Type: application/json' \
+  -H 'X-Api-Key: it'\''s secret' \
+  --data-raw '{"title":"hello"}'`
+	if exported != expected {
+		t.Fatalf("unexpected curl export:\n%v", exported)
+	}
+
+	imported, err := app.ParseCurl(exported)
+	if err != nil {
+		t.Fatalf("exported curl parsing failed: %v", err)
+	}
+	if imported.Method != "POST" ||
+		imported.Url != "https://api.com/posts?page=1&sort=date" ||
+		imported.Headers["X-Api-Key"] != "it's secret" ||
+		imported.JsonBody != request.JsonBody {
+		t.Fatalf("exported curl not equivalent: %+v", imported)
+	}
+}
+
+func TestShouldExportHeadRequestAsCurl(t *testing.T) {
+	request, err := app.ParseRequest(`HEAD https://api.com/posts`)
+	if err != nil {
+		t.Fatalf("request parsing failed: %v", err)
+	}
+	exported, err := app.Export(request, app.CurlExportFormat)
+	if err != nil {
+		t.Fatalf("request export failed: %v", err)
+	}
+	expected := `curl \
+  --head \
+  'https://api.com/posts'`
+	if exported != expected {
+		t.Fatalf("unexpected curl export:\n%v", exported)
+	}
+
+	imported, err := app.ParseCurl(exported)
+	if err != nil {
+		t.Fatalf("exported curl parsing failed: %v", err)
+	}
+	if imported.Method != "HEAD" || imported.Url != "https://api.com/posts" {
+		t.Fatalf("exported curl not equivalent: %+v", imported)
+	}
+}
+
+func TestShouldExportMultipartRequestAsCurl(t *testing.T) {
+	request, err := app.ParseRequest(`PUT https://api.com/animals/1 name=gopher description="a blue one"`)
+	if err != nil {
+		t.Fatalf("request parsing failed: %v", err)
+	}
+	exported, err := app.Export(request, app.CurlExportFormat)
+	if err != nil {
+		t.Fatalf("request export failed: %v", err)
+	}
+	if strings.Contains(exported, "Content-Type") {
+		t.Fatalf("expected the multipart content type to be left to curl:\n%v", exported)
+	}
+	imported, err := app.ParseCurl(exported)
+	if err != nil {
+		t.Fatalf("exported curl parsing failed: %v", err)
+	}
+	if imported.MultipartBody["name"] != "gopher" || imported.MultipartBody["description"] != "a blue one" {
+		t.Fatalf("exported curl not equivalent: %+v", imported)
+	}
+}
+
+func TestShouldExportRequestAsHttpie(t *testing.T) {
+	request, err := app.ParseRequest(`GET https://api.com/posts Accept=:application/json q=?"go lang"`)
+	if err != nil {
+		t.Fatalf("request parsing failed: %v", err)
+	}
+	exported, err := app.Export(request, app.HttpieExportFormat)
+	if err != nil {
+		t.Fatalf("request export failed: %v", err)
+	}
+	expected := `http \
+  GET \
+  'https://api.com/posts?q=go+lang' \
+  'Accept:application/json'`
+	if exported != expected {
+		t.Fatalf("unexpected HTTPie export:\n%v", exported)
+	}
+}
+
+func TestShouldExportGetRequestWithBody(t *testing.T) {
+	for _, testCase := range []struct {
+		rawRequest     string
+		format         app.ExportFormat
+		expectedExport string
+	}{
+		{`GET https://api.com/search {"query":"gopher"}`, app.CurlExportFormat, `curl \
+  -X GET \
+  'https://api.com/search' \
+  -H 'Content-Type: application/json' \
+  --data-raw '{"query":"gopher"}'`},
+		{`GET https://api.com/search query=gopher`, app.CurlExportFormat, `curl \
+  -X GET \
+  'https://api.com/search' \
+  --form-string 'query=gopher'`},
+		{`GET https://api.com/search query=@gopher`, app.HttpieExportFormat, `http \
+  --multipart \
+  GET \
+  'https://api.com/search' \
+  'query=\@gopher'`},
+	} {
+		request, err := app.ParseRequest(testCase.rawRequest)
+		if err != nil {
+			t.Fatalf("request parsing failed: %v", err)
+		}
+		exported, err := app.Export(request, testCase.format)
+		if err != nil {
+			t.Fatalf("request export failed: %v", err)
+		}
+		if exported != testCase.expectedExport {
+			t.Fatalf("unexpected %v export of %v:\n%v", testCase.format, testCase.rawRequest, exported)
+		}
+	}
+
+	request, err := app.ParseCurl(`curl -X GET https://api.com/search --data-raw '{"query":"gopher"}'`)
+	if err != nil || request.Method != "GET" || request.JsonBody != `{"query":"gopher"}` {
+		t.Fatalf("exported curl not equivalent: %+v, %v", request, err)
+	}
+}
+
+func TestShouldExportRequestAsGoCode(t *testing.T) {
+	for _, rawRequest := range []string{
+		`POST https://api.com/posts X-Api-Key=:key {"title":"a ` + "`" + `quoted` + "`" + ` title"}`,
+		`PUT https://api.com/animals/1 name=gopher`,
+		`DELETE https://api.com/animals/1`,
+	} {
+		request, err := app.ParseRequest(rawRequest)
+		if err != nil {
+			t.Fatalf("request parsing failed: %v", err)
+		}
+		exported, err := app.Export(request, app.GoExportFormat)
+		if err != nil {
+			t.Fatalf("request export failed: %v", err)
+		}
+		_, err = parser.ParseFile(token.NewFileSet(), "main.go", exported, parser.AllErrors)
+		if err != nil {
+			t.Fatalf("exported Go code is invalid: %v\n%v", err, exported)
+		}
+		if !strings.Contains(exported, request.Method) || !strings.Contains(exported, "https://api.com/") {
+			t.Fatalf("unexpected Go export:\n%v", exported)
+		}
+	}
+}
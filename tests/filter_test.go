@@ -0,0 +1,68 @@
+package tests_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ThomasFerro/gogetter/app"
+)
+
+func TestShouldMatchStatusClasses(t *testing.T) {
+	serverErrors, err := app.ParseStatusClass("5XX")
+	if err != nil {
+		t.Fatalf("status class parsing failed: %v", err)
+	}
+	notFound, err := app.ParseStatusClass("404")
+	if err != nil {
+		t.Fatalf("status class parsing failed: %v", err)
+	}
+	if !serverErrors.Matches(503) || serverErrors.Matches(404) || !notFound.Matches(404) || notFound.Matches(400) {
+		t.Fatalf("status classes not matching correctly")
+	}
+	for _, invalidClass := range []string{"5x", "abc", "5xxx"} {
+		if _, err := app.ParseStatusClass(invalidClass); err == nil {
+			t.Fatalf("expected %v to be rejected", invalidClass)
+		}
+	}
+}
+
+func TestShouldFilterOnRequestDetails(t *testing.T) {
+	request, err := app.ParseRequest(`POST https://pkg.go.dev X-Api-Key=:secret page=?2 {"name": "gopher"}`)
+	if err != nil {
+		t.Fatalf("request parsing failed: %v", err)
+	}
+	filterValue := app.SavedRequest{Request: request, SavedRequestMetadata: app.SavedRequestMetadata{Name: "Create gopher", Tags: []string{"animals"}}}.FilterValue()
+	for _, expected := range []string{"Create gopher", "#animals", "POST", "https://pkg.go.dev", "X-Api-Key=:secret", "page=?2", `"name": "gopher"`} {
+		if !strings.Contains(filterValue, expected) {
+			t.Fatalf("expected filter value to contain %v: %v", expected, filterValue)
+		}
+	}
+}
+
+func TestShouldApplyStructuredHistoryFilters(t *testing.T) {
+	request, err := app.ParseRequest("POST https://pkg.go.dev")
+	if err != nil {
+		t.Fatalf("request parsing failed: %v", err)
+	}
+	filterValue := app.RequestAndResponse{Request: request, ResponseCode: 502}.FilterValue()
+
+	filter := app.ParseHistoryFilter("status:5xx method:post pkg")
+	if len(filter.Statuses) != 1 || len(filter.Methods) != 1 || filter.Text != "pkg" {
+		t.Fatalf("history filter not parsed correctly: %+v", filter)
+	}
+	if !filter.MatchesStructuredFilters(filterValue) {
+		t.Fatalf("expected history entry to match %+v", filter)
+	}
+	if app.ParseHistoryFilter("status:4xx").MatchesStructuredFilters(filterValue) {
+		t.Fatalf("expected history entry not to match status:4xx")
+	}
+	if app.ParseHistoryFilter("method:GET").MatchesStructuredFilters(filterValue) {
+		t.Fatalf("expected history entry not to match method:GET")
+	}
+	if !app.ParseHistoryFilter("method:GET method:POST").MatchesStructuredFilters(filterValue) {
+		t.Fatalf("expected history entry to match any of the methods")
+	}
+	if app.ParseHistoryFilter("status:oops").Text != "status:oops" {
+		t.Fatalf("expected invalid structured filters to be matched as text")
+	}
+}
@@ -0,0 +1,76 @@
+package tests_test
+
+import (
+	"testing"
+
+	"github.com/ThomasFerro/gogetter/tui"
+)
+
+func TestShouldPrettifyJsonBody(t *testing.T) {
+	body, format := tui.PrettyBody(`{"name":"gopher","tags":["blue",1]}`, tui.DetectBodyFormat("application/problem+json"))
+	expected := `{
+  "name": "gopher",
+  "tags": [
+    "blue",
+    1
+  ]
+}`
+	if format != tui.JsonBodyFormat || body != expected {
+		t.Fatalf("unexpected pretty json %v:\n%v", format, body)
+	}
+}
+
+func TestShouldPrettifyNamespacedXmlBody(t *testing.T) {
+	soap := `<?xml version="1.0"?><soap:Envelope xmlns:soap="http://www.w3.org/2003/05/soap-envelope"><soap:Body><m:Price xmlns:m="https://shop.com/prices" m:currency="EUR">1 &lt; 2</m:Price><m:Empty/><!-- end --></soap:Body></soap:Envelope>`
+	body, format := tui.PrettyBody(soap, tui.DetectBodyFormat("application/soap+xml; charset=utf-8"))
+	expected := `<?xml version="1.0"?>
+<soap:Envelope xmlns:soap="http://www.w3.org/2003/05/soap-envelope">
+  <soap:Body>
+    <m:Price xmlns:m="https://shop.com/prices" m:currency="EUR">1 &lt; 2</m:Price>
+    <m:Empty/>
+    <!-- end -->
+  </soap:Body>
+</soap:Envelope>`
+	if format != tui.XmlBodyFormat || body != expected {
+		t.Fatalf("unexpected pretty xml %v:\n%v", format, body)
+	}
+}
+
+func TestShouldPrettifyHtmlBody(t *testing.T) {
+	body, format := tui.PrettyBody(`<!DOCTYPE html><html><body><p>Hello <b>gopher</b></p><br><pre>  kept
+  as is</pre></body></html>`, tui.DetectBodyFormat("text/html"))
+	expected := `<!DOCTYPE html>
+<html>
+  <body>
+    <p>
+      Hello
+      <b>
+        gopher
+      </b>
+    </p>
+    <br>
+    <pre>  kept
+  as is
+    </pre>
+  </body>
+</html>`
+	if format != tui.HtmlBodyFormat || body != expected {
+		t.Fatalf("unexpected pretty html %v:\n%v", format, body)
+	}
+}
+
+func TestShouldKeepInvalidBodiesRaw(t *testing.T) {
+	for _, invalidBody := range []struct {
+		body        string
+		contentType string
+	}{
+		{`{"name":`, "application/json"},
+		{`<a><b></a></b>`, "application/xml"},
+		{`<a>&undeclared;</a>`, "text/xml"},
+	} {
+		body, format := tui.PrettyBody(invalidBody.body, tui.DetectBodyFormat(invalidBody.contentType))
+		if format != tui.RawBodyFormat || body != invalidBody.body {
+			t.Fatalf("invalid %v body not kept raw: %v, %v", invalidBody.contentType, format, body)
+		}
+	}
+}
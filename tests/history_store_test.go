@@ -0,0 +1,162 @@
+package tests_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ThomasFerro/gogetter/app"
+	"github.com/ThomasFerro/gogetter/storage"
+	"github.com/ThomasFerro/gogetter/tests"
+)
+
+func countLines(t *testing.T, filename string) int {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("history file reading failed: %v", err)
+	}
+	return strings.Count(string(content), "\n")
+}
+
+func TestShouldAppendHistoryEntriesToTheStore(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "history.jsonl")
+	request, err := app.ParseRequest("GET https://pkg.go.dev")
+	if err != nil {
+		t.Fatalf("request parsing failed: %v", err)
+	}
+	testClient := tests.NewTestClient(
+		tests.SubstitutedRequest{Request: request, Response: "ok", ResponseCode: 200},
+	)
+
+	gogetter, err := app.NewGogetter(testClient, app.WithHistoryStore{Store: storage.NewJsonLinesHistory(filename, 10, 0)})
+	if err != nil {
+		t.Fatalf("new gogetter failed: %v", err)
+	}
+	for range 3 {
+		gogetter, _, _, err = gogetter.Execute(context.Background(), request)
+		if err != nil {
+			t.Fatalf("request execution failed: %v", err)
+		}
+	}
+	if countLines(t, filename) != 3 {
+		t.Fatalf("expected one line per entry")
+	}
+
+	gogetter, err = app.NewGogetter(testClient, app.WithHistoryStore{Store: storage.NewJsonLinesHistory(filename, 10, 0)})
+	if err != nil {
+		t.Fatalf("new gogetter failed: %v", err)
+	}
+	history := gogetter.History()
+	if len(history) != 3 || history[0].Url != "https://pkg.go.dev" || history[0].ResponseCode != 200 {
+		t.Fatalf("history not loaded correctly: %v", history)
+	}
+}
+
+func TestShouldCompactTheHistoryStoreAboveMaxEntries(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "history.jsonl")
+	store := storage.NewJsonLinesHistory(filename, 2, 0)
+	_, err := store.Load()
+	if err != nil {
+		t.Fatalf("history loading failed: %v", err)
+	}
+	for i := range 3 {
+		err = store.Append(app.HistoryEntryWritingDto{Request: fmt.Sprintf("GET https://pkg.go.dev/%v", i), ResponseCode: 200})
+		if err != nil {
+			t.Fatalf("history appending failed: %v", err)
+		}
+	}
+	if countLines(t, filename) != 3 {
+		t.Fatalf("expected compaction to wait for twice the max entries")
+	}
+
+	err = store.Append(app.HistoryEntryWritingDto{Request: "GET https://pkg.go.dev/3", ResponseCode: 200})
+	if err != nil {
+		t.Fatalf("history appending failed: %v", err)
+	}
+	entries, err := store.Load()
+	if err != nil {
+		t.Fatalf("history loading failed: %v", err)
+	}
+	if countLines(t, filename) != 2 ||
+		len(entries) != 2 ||
+		entries[0].Request != "GET https://pkg.go.dev/2" ||
+		entries[1].Request != "GET https://pkg.go.dev/3" {
+		t.Fatalf("history not compacted correctly: %v", entries)
+	}
+}
+
+func TestShouldDropHistoryEntriesOlderThanMaxAge(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "history.jsonl")
+	oldEntry := fmt.Sprintf(`{"Request":"GET https://pkg.go.dev/old","ResponseCode":200,"AppendedAt":%q}`, time.Now().Add(-48*time.Hour).Format(time.RFC3339))
+	recentEntry := fmt.Sprintf(`{"Request":"GET https://pkg.go.dev/recent","ResponseCode":200,"AppendedAt":%q}`, time.Now().Format(time.RFC3339))
+	err := os.WriteFile(filename, []byte(oldEntry+"\n"+recentEntry+"\n"), 0o644)
+	if err != nil {
+		t.Fatalf("history file writing failed: %v", err)
+	}
+
+	entries, err := storage.NewJsonLinesHistory(filename, 0, 24*time.Hour).Load()
+	if err != nil {
+		t.Fatalf("history loading failed: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Request != "GET https://pkg.go.dev/recent" || countLines(t, filename) != 1 {
+		t.Fatalf("old entries not dropped: %v", entries)
+	}
+}
+
+func TestShouldSkipPartiallyWrittenHistoryEntries(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "history.jsonl")
+	err := os.WriteFile(filename, []byte(`{"Request":"GET https://pkg.go.dev","ResponseCode":200}`+"\n"+`{"Request":"GET htt`), 0o644)
+	if err != nil {
+		t.Fatalf("history file writing failed: %v", err)
+	}
+
+	entries, err := storage.NewJsonLinesHistory(filename, 0, 0).Load()
+	if err != nil {
+		t.Fatalf("history loading failed: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Request != "GET https://pkg.go.dev" {
+		t.Fatalf("history not loaded correctly: %v", entries)
+	}
+}
+
+func TestShouldAppendAfterPartiallyWrittenHistoryEntries(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "history.jsonl")
+	err := os.WriteFile(filename, []byte(`{"Request":"GET https://pkg.go.dev","ResponseCode":200}`+"\n"+`{"Request":"GET htt`), 0o644)
+	if err != nil {
+		t.Fatalf("history file writing failed: %v", err)
+	}
+	store := storage.NewJsonLinesHistory(filename, 0, 0)
+
+	err = store.Append(app.HistoryEntryWritingDto{Request: "DELETE https://pkg.go.dev/1", ResponseCode: 204})
+	if err != nil {
+		t.Fatalf("history entry appending failed: %v", err)
+	}
+	entries, err := store.Load()
+	if err != nil {
+		t.Fatalf("history loading failed: %v", err)
+	}
+	if len(entries) != 2 || entries[1].Request != "DELETE https://pkg.go.dev/1" {
+		t.Fatalf("entry appended after a partially written one lost: %v", entries)
+	}
+}
+
+func TestShouldImportJsonHistory(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "history.jsonl")
+	store := storage.NewJsonLinesHistory(filename, 0, 0)
+	err := store.ImportJsonHistory(strings.NewReader(`[{"Request":"GET https://pkg.go.dev","ResponseCode":200},{"Request":"DELETE https://pkg.go.dev/1","ResponseCode":401}]`))
+	if err != nil {
+		t.Fatalf("history import failed: %v", err)
+	}
+
+	entries, err := store.Load()
+	if err != nil {
+		t.Fatalf("history loading failed: %v", err)
+	}
+	if len(entries) != 2 || entries[1].Request != "DELETE https://pkg.go.dev/1" || entries[1].ResponseCode != 401 {
+		t.Fatalf("history not imported correctly: %v", entries)
+	}
+}
@@ -1,6 +1,9 @@
 package tests_test
 
 import (
+	"bytes"
+	"context"
+	"io"
 	"os"
 	"strings"
 	"testing"
@@ -16,7 +19,7 @@ func TestShouldPutRequestsInLocalHistory(t *testing.T) {
 		tests.SubstitutedRequest{Request: app.Request{Method: "GET", Url: "https://pkg.go.dev"}, Response: "ok"},
 	)
 	var err error
-	gogetter, _, _, err = gogetter.Execute(app.Request{Method: "GET", Url: "https://pkg.go.dev"})
+	gogetter, _, _, err = gogetter.Execute(context.Background(), app.Request{Method: "GET", Url: "https://pkg.go.dev"})
 	if err != nil {
 		t.Fatalf("request execution failed: %v", err)
 	}
@@ -71,11 +74,11 @@ func TestShouldLoadAndPersistHistory(t *testing.T) {
 		t.Fatalf("history not initially filled correctly: %v", history)
 	}
 
-	gogetter, _, _, err = gogetter.Execute(firstRequest)
+	gogetter, _, _, err = gogetter.Execute(context.Background(), firstRequest)
 	if err != nil {
 		t.Fatalf("request execution failed: %v", err)
 	}
-	gogetter, _, _, err = gogetter.Execute(secondRequest)
+	gogetter, _, _, err = gogetter.Execute(context.Background(), secondRequest)
 	if err != nil {
 		t.Fatalf("request execution failed: %v", err)
 	}
@@ -128,7 +131,7 @@ func TestNoHistoryOption(t *testing.T) {
 		t.Fatalf("new gogetter failed: %v", err)
 	}
 
-	gogetter, _, _, err = gogetter.Execute(app.Request{Method: "GET", Url: "https://pkg.go.dev"})
+	gogetter, _, _, err = gogetter.Execute(context.Background(), app.Request{Method: "GET", Url: "https://pkg.go.dev"})
 	if err != nil {
 		t.Fatalf("request execution failed: %v", err)
 	}
@@ -138,3 +141,101 @@ func TestNoHistoryOption(t *testing.T) {
 		t.Fatalf("in memory history not filled correctly: %v", history)
 	}
 }
+
+func TestShouldRecordResponsesInHistory(t *testing.T) {
+	testClient := tests.NewTestClient(
+		tests.SubstitutedRequest{Request: app.Request{Method: "GET", Url: "https://pkg.go.dev"}, Response: "response body", ResponseCode: 200},
+	)
+	var writtenHistory []byte
+	historyWritingFunc := func(toWrite []byte) error {
+		writtenHistory = toWrite
+		return nil
+	}
+	gogetter, err := app.NewGogetter(testClient, app.WithHistory{
+		PreviousHistory:       helpers.EmptyReadCloser{},
+		HistoryWriter:         historyWritingFunc,
+		RecordResponses:       true,
+		ResponseBodySizeLimit: 8,
+	})
+	if err != nil {
+		t.Fatalf("new gogetter failed: %v", err)
+	}
+
+	request, err := app.ParseRequest("GET https://pkg.go.dev")
+	if err != nil {
+		t.Fatalf("request parsing failed: %v", err)
+	}
+	_, requestAndResponse, result, err := gogetter.Execute(context.Background(), request)
+	if err != nil {
+		t.Fatalf("request execution failed: %v", err)
+	}
+	defer result.Body.Close()
+	body, err := io.ReadAll(result.Body)
+	if err != nil {
+		t.Fatalf("result body read failed: %v", err)
+	}
+	if string(body) != "response body" {
+		t.Fatalf("expected the whole body to still be readable but got %v", string(body))
+	}
+	recordedResponse := requestAndResponse.Response
+	if recordedResponse == nil ||
+		recordedResponse.Body != "response" ||
+		!recordedResponse.BodyTruncated ||
+		recordedResponse.Timestamp.IsZero() {
+		t.Fatalf("response not recorded correctly: %+v", recordedResponse)
+	}
+
+	reloadedGogetter, err := app.NewGogetter(testClient, app.WithHistory{PreviousHistory: bytes.NewReader(writtenHistory)})
+	if err != nil {
+		t.Fatalf("new gogetter failed: %v", err)
+	}
+	history := reloadedGogetter.History()
+	if len(history) != 1 ||
+		history[0].Response == nil ||
+		history[0].Response.Body != "response" ||
+		!history[0].Response.BodyTruncated ||
+		!history[0].Response.Timestamp.Equal(recordedResponse.Timestamp) {
+		t.Fatalf("recorded response not reloaded correctly: %+v", history)
+	}
+}
+
+func TestShouldTruncateRecordedBodiesOnRuneBoundaries(t *testing.T) {
+	testClient := tests.NewTestClient(
+		tests.SubstitutedRequest{Request: app.Request{Method: "GET", Url: "https://pkg.go.dev"}, Response: "café crème", ResponseCode: 200},
+	)
+	gogetter, err := app.NewGogetter(testClient, app.WithHistory{
+		PreviousHistory: helpers.EmptyReadCloser{},
+		HistoryWriter:   func(toWrite []byte) error { return nil },
+		RecordResponses: true,
+	})
+	if err != nil {
+		t.Fatalf("new gogetter failed: %v", err)
+	}
+	request, err := app.ParseRequest("GET https://pkg.go.dev")
+	if err != nil {
+		t.Fatalf("request parsing failed: %v", err)
+	}
+
+	_, requestAndResponse, result, err := gogetter.LimitRecordedResponseBodies(4).Execute(context.Background(), request)
+	if err != nil {
+		t.Fatalf("request execution failed: %v", err)
+	}
+	result.Body.Close()
+	if recordedResponse := requestAndResponse.Response; recordedResponse.Body != "caf" || !recordedResponse.BodyTruncated {
+		t.Fatalf("body not truncated on a rune boundary: %q", recordedResponse.Body)
+	}
+}
+
+func TestShouldNotRecordResponsesByDefault(t *testing.T) {
+	gogetter := tests.NewTestSetup(
+		t,
+		tests.SubstitutedRequest{Request: app.Request{Method: "GET", Url: "https://pkg.go.dev"}, Response: "ok"},
+	)
+	_, requestAndResponse, _, err := gogetter.Execute(context.Background(), app.Request{Method: "GET", Url: "https://pkg.go.dev"})
+	if err != nil {
+		t.Fatalf("request execution failed: %v", err)
+	}
+	if requestAndResponse.Response != nil {
+		t.Fatalf("expected no recorded response: %+v", requestAndResponse.Response)
+	}
+}
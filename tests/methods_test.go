@@ -0,0 +1,73 @@
+package tests_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ThomasFerro/gogetter/app"
+	"github.com/ThomasFerro/gogetter/tests"
+)
+
+func TestShouldAcceptStandardMethods(t *testing.T) {
+	for _, method := range []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "CONNECT", "OPTIONS", "TRACE"} {
+		request, err := app.ParseRequest(method + " https://pkg.go.dev")
+		if err != nil {
+			t.Fatalf("request parsing failed for %v: %v", method, err)
+		}
+		if request.Method != method {
+			t.Fatalf("expected method %v but got %v", method, request.Method)
+		}
+	}
+}
+
+func TestShouldSendAPatchRequest(t *testing.T) {
+	gogetter := tests.NewTestSetup(
+		t,
+		tests.SubstitutedRequest{Request: app.Request{Method: "PATCH", Url: "https://pkg.go.dev/1", JsonBody: `{"name":"value"}`}, Response: "ok", ResponseCode: 200},
+	)
+	request, err := app.ParseRequest(`PATCH https://pkg.go.dev/1 {"name":"value"}`)
+	if err != nil {
+		t.Fatalf("request parsing failed: %v", err)
+	}
+	_, requestAndResponse, _, err := gogetter.Execute(context.Background(), request)
+	if err != nil {
+		t.Fatalf("request execution failed: %v", err)
+	}
+	if requestAndResponse.ResponseCode != 200 {
+		t.Fatalf("unexpected response code: %v", requestAndResponse.ResponseCode)
+	}
+}
+
+func TestShouldRejectExtensionMethodsByDefault(t *testing.T) {
+	_, err := app.ParseRequest("PROPFIND https://pkg.go.dev")
+	if err == nil {
+		t.Fatalf("expected extension method to be rejected")
+	}
+}
+
+func TestShouldAcceptExtensionMethodsWhenAllowed(t *testing.T) {
+	request, err := app.ParseRequest("PROPFIND https://pkg.go.dev", app.ExtensionMethodsOption{})
+	if err != nil {
+		t.Fatalf("request parsing failed: %v", err)
+	}
+	if request.Method != "PROPFIND" {
+		t.Fatalf("expected method PROPFIND but got %v", request.Method)
+	}
+
+	_, err = app.ParseRequest("GET(1) https://pkg.go.dev", app.ExtensionMethodsOption{})
+	if err == nil {
+		t.Fatalf("expected invalid method token to be rejected")
+	}
+}
+
+func TestShouldRestrictExtensionMethodsToTheProvidedOnes(t *testing.T) {
+	option := app.ExtensionMethodsOption{Methods: []string{"PURGE"}}
+	_, err := app.ParseRequest("PURGE https://pkg.go.dev", option)
+	if err != nil {
+		t.Fatalf("request parsing failed: %v", err)
+	}
+	_, err = app.ParseRequest("PROPFIND https://pkg.go.dev", option)
+	if err == nil {
+		t.Fatalf("expected PROPFIND to be rejected")
+	}
+}
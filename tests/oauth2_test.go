@@ -0,0 +1,213 @@
+package tests_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/ThomasFerro/gogetter/app"
+	"github.com/ThomasFerro/gogetter/tests"
+)
+
+// oauth2Server issues numbered tokens, expiring within the renewal leeway when
+// expiresIn is short, and serves /api to the holders of the last issued access token
+type oauth2Server struct {
+	*httptest.Server
+	grants      []url.Values
+	expiresIn   int
+	issued      int
+	validToken  string
+	codes       map[string]string
+	apiRequests int
+}
+
+func newOAuth2Server(t *testing.T, expiresIn int) *oauth2Server {
+	server := &oauth2Server{expiresIn: expiresIn, codes: map[string]string{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("token request parsing failed: %v", err)
+		}
+		server.grants = append(server.grants, r.PostForm)
+		clientId, clientSecret, ok := r.BasicAuth()
+		if r.PostForm.Get("grant_type") != "authorization_code" && (!ok || clientId != "gogetter" || clientSecret != "s3cret") {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":"invalid_client"}`))
+			return
+		}
+		if r.PostForm.Get("grant_type") == "authorization_code" {
+			verifier := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+			if server.codes[r.PostForm.Get("code")] != base64.RawURLEncoding.EncodeToString(verifier[:]) {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"error":"invalid_grant","error_description":"code verifier mismatch"}`))
+				return
+			}
+		}
+		server.issued++
+		server.validToken = fmt.Sprintf("access-%v", server.issued)
+		json.NewEncoder(w).Encode(map[string]any{
+			"access_token":  server.validToken,
+			"token_type":    "bearer",
+			"expires_in":    server.expiresIn,
+			"refresh_token": fmt.Sprintf("refresh-%v", server.issued),
+		})
+	})
+	mux.HandleFunc("/api", func(w http.ResponseWriter, r *http.Request) {
+		server.apiRequests++
+		if r.Header.Get("Authorization") != "Bearer "+server.validToken {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte("ok"))
+	})
+	server.Server = httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newOAuth2Gogetter(t *testing.T, server *oauth2Server, configurations string, options ...app.GogetterOption) app.Gogetter {
+	return tests.NewGogetter(t, server.Client(), append([]app.GogetterOption{
+		app.WithEnvironments{Environments: strings.NewReader(fmt.Sprintf(`{"dev": {"authUrl": %q}, "prod": {"authUrl": %q}}`, server.URL, server.URL)), ActiveEnvironment: "dev"},
+		app.WithSecrets{DotEnv: strings.NewReader("CLIENT_SECRET=s3cret")},
+		app.WithOAuth2{Configurations: strings.NewReader(configurations)},
+	}, options...)...)
+}
+
+func executeWithOAuth2(t *testing.T, gogetter app.Gogetter, server *oauth2Server) app.Gogetter {
+	request, err := app.ParseRequest(fmt.Sprintf("GET %v/api\nauth oauth2 api", server.URL))
+	if err != nil {
+		t.Fatalf("request parsing failed: %v", err)
+	}
+	gogetter, requestAndResponse, response, err := gogetter.Execute(context.Background(), request)
+	if err != nil {
+		t.Fatalf("request execution failed: %v", err)
+	}
+	response.Body.Close()
+	if requestAndResponse.ResponseCode != http.StatusOK {
+		t.Fatalf("request not authorized: %v", requestAndResponse.ResponseCode)
+	}
+	return gogetter
+}
+
+const clientCredentialsConfiguration = `{"api": {
+	"flow": "client_credentials",
+	"token_url": "{{.authUrl}}/token",
+	"client_id": "gogetter",
+	"client_secret": "{{secret \"CLIENT_SECRET\"}}",
+	"scope": "read"
+}}`
+
+func TestShouldCacheOAuth2TokensPerEnvironment(t *testing.T) {
+	server := newOAuth2Server(t, 3600)
+	savedTokens := ""
+	gogetter := newOAuth2Gogetter(t, server, clientCredentialsConfiguration, app.WithOAuth2{
+		Configurations:   strings.NewReader(clientCredentialsConfiguration),
+		TokensSavingFunc: func(content []byte) error { savedTokens = string(content); return nil },
+	})
+
+	gogetter = executeWithOAuth2(t, gogetter, server)
+	gogetter = executeWithOAuth2(t, gogetter, server)
+	if len(server.grants) != 1 || server.grants[0].Get("grant_type") != "client_credentials" || server.grants[0].Get("scope") != "read" {
+		t.Fatalf("expected one client credentials grant, got %v", server.grants)
+	}
+	token, ok := gogetter.OAuth2Token("api")
+	if !ok || token.AccessToken != "access-1" || token.Expired(token.AcquiredAt) || !strings.Contains(savedTokens, `"dev"`) {
+		t.Fatalf("token not cached: %+v, saved: %v", token, savedTokens)
+	}
+
+	gogetter, err := gogetter.SelectEnvironment("prod")
+	if err != nil {
+		t.Fatalf("environment selection failed: %v", err)
+	}
+	if _, ok := gogetter.OAuth2Token("api"); ok {
+		t.Fatal("token of dev used in prod")
+	}
+	executeWithOAuth2(t, gogetter, server)
+	if len(server.grants) != 2 {
+		t.Fatalf("expected a new grant for prod, got %v", server.grants)
+	}
+
+	reloaded := newOAuth2Gogetter(t, server, clientCredentialsConfiguration, app.WithOAuth2{
+		Configurations: strings.NewReader(clientCredentialsConfiguration),
+		Tokens:         strings.NewReader(savedTokens),
+	})
+	if token, ok := reloaded.OAuth2Token("api"); !ok || token.AccessToken != "access-1" {
+		t.Fatalf("saved tokens not reloaded: %+v", token)
+	}
+}
+
+func TestShouldRefreshExpiredOAuth2Tokens(t *testing.T) {
+	server := newOAuth2Server(t, 1)
+	gogetter := newOAuth2Gogetter(t, server, `{"api": {
+		"flow": "password",
+		"token_url": "{{.authUrl}}/token",
+		"client_id": "gogetter",
+		"client_secret": "{{secret \"CLIENT_SECRET\"}}",
+		"username": "gopher",
+		"password": "hunter2"
+	}}`)
+
+	gogetter = executeWithOAuth2(t, gogetter, server)
+	executeWithOAuth2(t, gogetter, server)
+	if len(server.grants) != 2 ||
+		server.grants[0].Get("grant_type") != "password" || server.grants[0].Get("username") != "gopher" || server.grants[0].Get("password") != "hunter2" ||
+		server.grants[1].Get("grant_type") != "refresh_token" || server.grants[1].Get("refresh_token") != "refresh-1" {
+		t.Fatalf("expected a password grant then a refresh, got %v", server.grants)
+	}
+}
+
+func TestShouldRenewRejectedOAuth2Tokens(t *testing.T) {
+	server := newOAuth2Server(t, 3600)
+	gogetter := newOAuth2Gogetter(t, server, clientCredentialsConfiguration)
+	gogetter = executeWithOAuth2(t, gogetter, server)
+
+	server.validToken = "revoked"
+	executeWithOAuth2(t, gogetter, server)
+	if server.apiRequests != 3 || len(server.grants) != 2 || server.grants[1].Get("grant_type") != "refresh_token" {
+		t.Fatalf("rejected token not renewed: %v requests, grants %v", server.apiRequests, server.grants)
+	}
+}
+
+func TestShouldAcquireOAuth2TokenWithPkce(t *testing.T) {
+	server := newOAuth2Server(t, 3600)
+	configuration := `{"api": {
+		"flow": "authorization_code",
+		"authorization_url": "{{.authUrl}}/authorize",
+		"token_url": "{{.authUrl}}/token",
+		"client_id": "public-client",
+		"redirect_url": "http://127.0.0.1:18910/callback",
+		"scope": "openid"
+	}}`
+	gogetter := newOAuth2Gogetter(t, server, configuration, app.WithOAuth2{
+		Configurations: strings.NewReader(configuration),
+		Authorize: func(authorizationUrl string) error {
+			// The browser follows the redirection of the authorization server
+			parsedUrl, err := url.Parse(authorizationUrl)
+			if err != nil {
+				return err
+			}
+			query := parsedUrl.Query()
+			if query.Get("code_challenge_method") != "S256" || query.Get("client_id") != "public-client" || query.Get("scope") != "openid" {
+				return fmt.Errorf("unexpected authorization url %v", authorizationUrl)
+			}
+			server.codes["the-code"] = query.Get("code_challenge")
+			response, err := http.Get(query.Get("redirect_uri") + "?code=the-code&state=" + query.Get("state"))
+			if err != nil {
+				return err
+			}
+			return response.Body.Close()
+		},
+	})
+
+	executeWithOAuth2(t, gogetter, server)
+	if len(server.grants) != 1 || server.grants[0].Get("grant_type") != "authorization_code" || server.grants[0].Get("client_id") != "public-client" {
+		t.Fatalf("expected an authorization code grant, got %v", server.grants)
+	}
+}
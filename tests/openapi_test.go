@@ -0,0 +1,205 @@
+package tests_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ThomasFerro/gogetter/app"
+	"github.com/ThomasFerro/gogetter/cli"
+	"github.com/ThomasFerro/gogetter/storage"
+	"github.com/ThomasFerro/gogetter/tests"
+)
+
+const openApiDocument = `openapi: 3.0.3
+info:
+  title: Pet Store
+  version: 1.0.0
+servers:
+  - url: https://{region}.petstore.com/v1/
+    variables:
+      region:
+        default: eu
+paths:
+  /pets/{pet-id}:
+    summary: A single pet
+    parameters:
+      - name: pet-id
+        in: path
+        required: true
+        schema:
+          type: string
+    put:
+      summary: Update a pet
+      description: Replaces the whole pet
+      tags: [pets, admin]
+      parameters:
+        - $ref: '#/components/parameters/ApiKey'
+        - name: dryRun
+          in: query
+          required: true
+          schema:
+            type: boolean
+        - name: verbose
+          in: query
+          schema:
+            type: boolean
+        - name: session
+          in: cookie
+          required: true
+      requestBody:
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/Pet'
+  /pets/{pet-id}/photo:
+    post:
+      operationId: uploadPhoto
+      requestBody:
+        content:
+          multipart/form-data:
+            schema:
+              type: object
+              properties:
+                caption:
+                  type: string
+                  example: Sleeping
+                file:
+                  type: string
+                  format: binary
+components:
+  parameters:
+    ApiKey:
+      name: X-Api-Key
+      in: header
+      required: true
+      example: secret
+  schemas:
+    Pet:
+      allOf:
+        - $ref: '#/components/schemas/NewPet'
+        - type: object
+          properties:
+            id:
+              type: integer
+    NewPet:
+      type: object
+      properties:
+        name:
+          type: string
+          example: Rex
+        tags:
+          type: array
+          items:
+            type: string
+            enum: [dog, cat]
+        owner:
+          $ref: '#/components/schemas/Owner'
+    Owner:
+      type: object
+      properties:
+        email:
+          type: string
+          format: email
+        pets:
+          type: array
+          items:
+            $ref: '#/components/schemas/NewPet'
+`
+
+func TestShouldGenerateSavedRequestsFromOpenApiDocument(t *testing.T) {
+	specification, err := app.ParseOpenApiSpecification(strings.NewReader(openApiDocument))
+	if err != nil {
+		t.Fatalf("openapi document parsing failed: %v", err)
+	}
+
+	if specification.Title != "Pet Store" || specification.Variables["baseUrl"] != "https://eu.petstore.com/v1" {
+		t.Fatalf("unexpected specification: %+v", specification)
+	}
+	savedRequests := specification.SavedRequests
+	if len(savedRequests) != 2 {
+		t.Fatalf("expected 2 requests but got %v", savedRequests)
+	}
+
+	updatePet := savedRequests[0]
+	if updatePet.Name != "Update a pet" ||
+		updatePet.Description != "Replaces the whole pet" ||
+		!slices.Equal(updatePet.Tags, []string{"pets", "admin"}) ||
+		updatePet.Folder != "pets" ||
+		updatePet.Method != "PUT" ||
+		updatePet.Url != "{{.baseUrl}}/pets/{{.pet_id}}" ||
+		updatePet.SearchParams["dryRun"] != "{{.dryRun}}" ||
+		len(updatePet.SearchParams) != 1 ||
+		updatePet.Headers["X-Api-Key"] != "secret" {
+		t.Fatalf("request not generated correctly: %+v", updatePet)
+	}
+	var body map[string]any
+	err = json.Unmarshal([]byte(updatePet.JsonBody), &body)
+	if err != nil {
+		t.Fatalf("generated body is not valid JSON: %v\n%v", err, updatePet.JsonBody)
+	}
+	owner, _ := body["owner"].(map[string]any)
+	if body["name"] != "Rex" || body["id"] != 0.0 || !slices.Equal(body["tags"].([]any), []any{"dog"}) || owner["email"] != "user@example.com" || len(owner["pets"].([]any)) != 0 {
+		t.Fatalf("unexpected generated body: %v", updatePet.JsonBody)
+	}
+
+	uploadPhoto := savedRequests[1]
+	if uploadPhoto.Name != "uploadPhoto" ||
+		uploadPhoto.Folder != "" ||
+		uploadPhoto.Method != "POST" ||
+		len(uploadPhoto.MultipartBody) != 1 ||
+		uploadPhoto.MultipartBody["caption"] != "Sleeping" {
+		t.Fatalf("request not generated correctly: %+v", uploadPhoto)
+	}
+
+	expectedUnsupported := []string{
+		`variable "pet-id" renamed "pet_id"`,
+		`PUT /pets/{pet-id}: cookie parameter "session" is not supported`,
+		`POST /pets/{pet-id}/photo: file form field "file" is not supported`,
+	}
+	if !slices.Equal(specification.Unsupported, expectedUnsupported) {
+		t.Fatalf("unexpected unsupported features report: %#v", specification.Unsupported)
+	}
+}
+
+func TestShouldRejectSwaggerDocuments(t *testing.T) {
+	_, err := app.ParseOpenApiSpecification(strings.NewReader(`{"swagger": "2.0", "paths": {}}`))
+	if err == nil {
+		t.Fatal("expected an error for a swagger 2 document")
+	}
+}
+
+func TestShouldImportOpenApiDocument(t *testing.T) {
+	directory := t.TempDir()
+	filename := writeImportedFile(t, directory, "openapi.json", `{
+		"openapi": "3.1.0",
+		"info": {"title": "Status"},
+		"servers": [{"url": "https://status.com"}],
+		"paths": {"/health": {"get": {"summary": "Health", "tags": ["monitoring"]}}}
+	}`)
+	gogetter, err := app.NewGogetter(
+		tests.NewTestClient(),
+		app.WithSavedRequestsCollection{Collection: storage.DirectoryCollection{Root: filepath.Join(directory, "requests")}},
+		app.WithEnvironments{Environments: strings.NewReader("")},
+	)
+	if err != nil {
+		t.Fatalf("new gogetter failed: %v", err)
+	}
+	stderr := &bytes.Buffer{}
+
+	gogetter, exitCode := cli.ImportOpenApi(gogetter, []string{filename}, &bytes.Buffer{}, stderr)
+	if exitCode != cli.SuccessExitCode {
+		t.Fatalf("expected success exit code but got %v: %v", exitCode, stderr.String())
+	}
+	savedRequests := gogetter.SavedRequests()
+	if len(savedRequests) != 1 || savedRequests[0].Path != "status/monitoring/health.gg" {
+		t.Fatalf("unexpected saved requests: %+v", savedRequests)
+	}
+	environments := gogetter.Environments()
+	if len(environments) != 1 || environments[0].Name != "Status" || environments[0].Variables["baseUrl"] != "https://status.com" {
+		t.Fatalf("base URL not saved in the environment: %v", environments)
+	}
+}
@@ -0,0 +1,203 @@
+package tests_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ThomasFerro/gogetter/app"
+	"github.com/ThomasFerro/gogetter/cli"
+	"github.com/ThomasFerro/gogetter/storage"
+	"github.com/ThomasFerro/gogetter/tests"
+)
+
+const postmanCollection = `{
+	"info": {
+		"name": "Blog API",
+		"schema": "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+	},
+	"auth": {"type": "bearer", "bearer": [{"key": "token", "value": "{{token}}", "type": "string"}]},
+	"variable": [{"key": "base-url", "value": "https://blog.com"}, {"key": "disabled", "value": "x", "disabled": true}],
+	"item": [
+		{
+			"name": "Posts",
+			"auth": {"type": "apikey", "apikey": [{"key": "key", "value": "api_key"}, {"key": "value", "value": "{{token}}"}, {"key": "in", "value": "query"}]},
+			"item": [
+				{
+					"name": "Create post",
+					"description": "Creates a draft",
+					"event": [{"listen": "test", "script": {"exec": ["pm.test('created')"]}}],
+					"request": {
+						"method": "POST",
+						"header": [
+							{"key": "Content-Type", "value": "application/json"},
+							{"key": "X-Disabled", "value": "1", "disabled": true}
+						],
+						"url": {"raw": "{{base-url}}/posts", "host": ["{{base-url}}"], "path": ["posts"]},
+						"body": {"mode": "raw", "raw": "{\"title\": \"{{ title }}\"}", "options": {"raw": {"language": "json"}}}
+					}
+				},
+				{
+					"name": "Upload cover",
+					"request": {
+						"method": "PUT",
+						"auth": {"type": "noauth"},
+						"url": "{{base-url}}/posts/1/cover?id={{$guid}}",
+						"body": {"mode": "formdata", "formdata": [
+							{"key": "alt", "value": "a cover", "type": "text"},
+							{"key": "file", "src": "cover.png", "type": "file"}
+						]}
+					}
+				}
+			]
+		},
+		{"name": "Health", "request": {"url": {
+			"protocol": "https", "host": ["blog", "com"], "port": "8443", "path": ["health"],
+			"query": [{"key": "verbose", "value": "true"}, {"key": "debug", "value": "1", "disabled": true}]
+		}}}
+	]
+}`
+
+const postmanEnvironment = `{
+	"name": "Production",
+	"values": [
+		{"key": "token", "value": "secret", "enabled": true},
+		{"key": "title", "value": "unused", "enabled": false}
+	]
+}`
+
+func TestShouldParsePostmanCollection(t *testing.T) {
+	collection, err := app.ParsePostmanCollection(strings.NewReader(postmanCollection))
+	if err != nil {
+		t.Fatalf("postman collection parsing failed: %v", err)
+	}
+
+	if collection.Name != "Blog API" || len(collection.Variables) != 1 || collection.Variables["base_url"] != "https://blog.com" {
+		t.Fatalf("collection variables not imported correctly: %v", collection.Variables)
+	}
+	savedRequests := collection.SavedRequests
+	if len(savedRequests) != 3 {
+		t.Fatalf("expected 3 requests but got %v", savedRequests)
+	}
+
+	createPost := savedRequests[0]
+	if createPost.Name != "Create post" ||
+		createPost.Description != "Creates a draft" ||
+		createPost.Folder != "Posts" ||
+		createPost.Method != "POST" ||
+		createPost.Url != "{{.base_url}}/posts" ||
+		createPost.Auth == nil || createPost.Auth.String() != "auth apikey query api_key {{.token}}" ||
+		createPost.Headers["Content-Type"] != "application/json" ||
+		createPost.Headers["X-Disabled"] != "" ||
+		!strings.Contains(createPost.Raw, "\n{\"title\": \"{{.title}}\"}\n") {
+		t.Fatalf("request not imported correctly: %+v", createPost)
+	}
+
+	uploadCover := savedRequests[1]
+	if uploadCover.Auth != nil ||
+		uploadCover.MultipartBody["alt"] != "a cover" ||
+		len(uploadCover.MultipartBody) != 1 {
+		t.Fatalf("request not imported correctly: %+v", uploadCover)
+	}
+	templatedUploadCover, err := app.ParseRequest(uploadCover.Raw, app.TemplatedRequestOption{Data: app.Variables{"base_url": "https://blog.com"}})
+	if err != nil {
+		t.Fatalf("imported request templating failed: %v", err)
+	}
+	if templatedUploadCover.Url != "https://blog.com/posts/1/cover?id={{$guid}}" {
+		t.Fatalf("dynamic variable not kept: %v", templatedUploadCover.Url)
+	}
+
+	health := savedRequests[2]
+	if health.Folder != "" || health.Method != "GET" || health.Url != "https://blog.com:8443/health" || len(health.SearchParams) != 1 || health.SearchParams["verbose"] != "true" || health.Auth == nil || health.Auth.String() != "auth bearer {{.token}}" {
+		t.Fatalf("request not imported correctly: %+v", health)
+	}
+
+	expectedUnsupported := []string{
+		`variable "base-url" renamed "base_url"`,
+		"Posts/Create post: test script is not supported",
+		"Posts/Upload cover: dynamic variable $guid is not supported",
+		`Posts/Upload cover: file form field "file" is not supported`,
+	}
+	if !slices.Equal(collection.Unsupported, expectedUnsupported) {
+		t.Fatalf("unexpected unsupported features report: %#v", collection.Unsupported)
+	}
+}
+
+func TestShouldRejectOtherPostmanCollectionSchemas(t *testing.T) {
+	_, err := app.ParsePostmanCollection(strings.NewReader(`{"info": {"schema": "https://schema.getpostman.com/json/collection/v1.0.0/collection.json"}}`))
+	if err == nil {
+		t.Fatal("expected an error for a v1 collection")
+	}
+}
+
+func TestShouldParsePostmanEnvironment(t *testing.T) {
+	environment, err := app.ParsePostmanEnvironment(strings.NewReader(postmanEnvironment))
+	if err != nil {
+		t.Fatalf("postman environment parsing failed: %v", err)
+	}
+	if environment.Name != "Production" || len(environment.Variables) != 1 || environment.Variables["token"] != "secret" {
+		t.Fatalf("environment not imported correctly: %+v", environment)
+	}
+}
+
+func writeImportedFile(t *testing.T, directory string, name string, content string) string {
+	filename := filepath.Join(directory, name)
+	err := os.WriteFile(filename, []byte(content), 0o644)
+	if err != nil {
+		t.Fatalf("imported file writing failed: %v", err)
+	}
+	return filename
+}
+
+func TestShouldImportPostmanCollectionAndEnvironments(t *testing.T) {
+	directory := t.TempDir()
+	collectionFilename := writeImportedFile(t, directory, "collection.json", postmanCollection)
+	environmentFilename := writeImportedFile(t, directory, "environment.json", postmanEnvironment)
+	var savedEnvironments []byte
+	gogetter, err := app.NewGogetter(
+		tests.NewTestClient(),
+		app.WithSavedRequestsCollection{Collection: storage.DirectoryCollection{Root: filepath.Join(directory, "requests")}},
+		app.WithEnvironments{
+			Environments:           strings.NewReader(`{"Production": {"token": "expired", "region": "eu"}}`),
+			EnvironmentsSavingFunc: func(content []byte) error { savedEnvironments = content; return nil },
+		},
+	)
+	if err != nil {
+		t.Fatalf("new gogetter failed: %v", err)
+	}
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+
+	gogetter, exitCode := cli.ImportPostman(gogetter, []string{"-environment", environmentFilename, collectionFilename}, stdout, stderr)
+	if exitCode != cli.SuccessExitCode {
+		t.Fatalf("expected success exit code but got %v: %v", exitCode, stderr.String())
+	}
+
+	paths := []string{}
+	for _, savedRequest := range gogetter.SavedRequests() {
+		paths = append(paths, savedRequest.Path)
+	}
+	if !slices.Equal(paths, []string{"blog-api/posts/create-post.gg", "blog-api/posts/upload-cover.gg", "blog-api/health.gg"}) {
+		t.Fatalf("unexpected saved requests paths: %v", paths)
+	}
+	if _, err := os.Stat(filepath.Join(directory, "requests", "blog-api", "posts", "create-post.gg")); err != nil {
+		t.Fatalf("saved request file not written: %v", err)
+	}
+
+	var environments app.EnvironmentsReadingDto
+	err = json.Unmarshal(savedEnvironments, &environments)
+	if err != nil {
+		t.Fatalf("saved environments unmarshal failed: %v", err)
+	}
+	production := environments["Production"]
+	if len(environments) != 1 || production["token"] != "secret" || production["base_url"] != "https://blog.com" || production["region"] != "eu" {
+		t.Fatalf("environments not saved correctly: %v", environments)
+	}
+	if !strings.Contains(stderr.String(), "not imported: Posts/Create post: test script is not supported") {
+		t.Fatalf("unsupported features not reported: %v", stderr.String())
+	}
+}
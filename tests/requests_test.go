@@ -1,6 +1,7 @@
 package tests_test
 
 import (
+	"context"
 	"io"
 	"net/http"
 	"testing"
@@ -16,7 +17,7 @@ func TestShouldSendSimpleRequest(t *testing.T) {
 	)
 	var result *http.Response
 	var err error
-	gogetter, _, result, err = gogetter.Execute(app.Request{Method: "GET", Url: "https://pkg.go.dev"})
+	gogetter, _, result, err = gogetter.Execute(context.Background(), app.Request{Method: "GET", Url: "https://pkg.go.dev"})
 	if err != nil {
 		t.Fatalf("request execution failed: %v", err)
 	}
@@ -53,7 +54,7 @@ Accept=:text/html x-api-key=:myApiKey`
 	if err != nil {
 		t.Fatalf("request parsing failed: %v", err)
 	}
-	gogetter, _, result, err = gogetter.Execute(request)
+	gogetter, _, result, err = gogetter.Execute(context.Background(), request)
 	if err != nil {
 		t.Fatalf("request execution failed: %v", err)
 	}
@@ -87,7 +88,7 @@ orderBy=?name tag=?"standard library" a=?b`
 	if err != nil {
 		t.Fatalf("request parsing failed: %v", err)
 	}
-	gogetter, _, result, err = gogetter.Execute(request)
+	gogetter, _, result, err = gogetter.Execute(context.Background(), request)
 	if err != nil {
 		t.Fatalf("request execution failed: %v", err)
 	}
@@ -139,7 +140,7 @@ func TestShouldSendARequestWithJsonObjectBody(t *testing.T) {
 	if err != nil {
 		t.Fatalf("request parsing failed: %v", err)
 	}
-	gogetter, _, result, err = gogetter.Execute(request)
+	gogetter, _, result, err = gogetter.Execute(context.Background(), request)
 	if err != nil {
 		t.Fatalf("request execution failed: %v", err)
 	}
@@ -191,7 +192,7 @@ func TestShouldSendARequestWithJsonArrayBody(t *testing.T) {
 	if err != nil {
 		t.Fatalf("request parsing failed: %v", err)
 	}
-	gogetter, _, result, err = gogetter.Execute(request)
+	gogetter, _, result, err = gogetter.Execute(context.Background(), request)
 	if err != nil {
 		t.Fatalf("request execution failed: %v", err)
 	}
@@ -233,7 +234,7 @@ key=value secondKey="second value"`
 	if err != nil {
 		t.Fatalf("request parsing failed: %v", err)
 	}
-	gogetter, _, result, err = gogetter.Execute(request)
+	gogetter, _, result, err = gogetter.Execute(context.Background(), request)
 	if err != nil {
 		t.Fatalf("request execution failed: %v", err)
 	}
@@ -0,0 +1,102 @@
+package tests_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ThomasFerro/gogetter/app"
+	"github.com/ThomasFerro/gogetter/cli"
+	"github.com/ThomasFerro/gogetter/tests"
+)
+
+func writeRequestFile(t *testing.T, content string) string {
+	filename := filepath.Join(t.TempDir(), "request.gg")
+	err := os.WriteFile(filename, []byte(content), 0o644)
+	if err != nil {
+		t.Fatalf("request file writing failed: %v", err)
+	}
+	return filename
+}
+
+func TestShouldRunRequestFileHeadlessly(t *testing.T) {
+	gogetter := tests.NewTestSetup(
+		t,
+		tests.SubstitutedRequest{Request: app.Request{Method: "GET", Url: "https://pkg.go.dev/123"}, Response: "ok", ResponseCode: 200},
+	)
+	filename := writeRequestFile(t, "GET https://pkg.go.dev/{{.Id}}")
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+
+	gogetter, exitCode := cli.Run(gogetter, []string{"-variables", `{"Id":"123"}`, filename}, nil, stdout, stderr)
+	if exitCode != cli.SuccessExitCode {
+		t.Fatalf("expected success exit code but got %v: %v", exitCode, stderr.String())
+	}
+	if !strings.HasPrefix(stdout.String(), "HTTP/1.1 200") || !strings.HasSuffix(stdout.String(), "\n\nok") {
+		t.Fatalf("unexpected output: %q", stdout.String())
+	}
+	if len(gogetter.History()) != 1 {
+		t.Fatalf("history not filled correctly: %v", gogetter.History())
+	}
+}
+
+func TestShouldRunRequestFromStdin(t *testing.T) {
+	gogetter := tests.NewTestSetup(
+		t,
+		tests.SubstitutedRequest{Request: app.Request{Method: "GET", Url: "https://pkg.go.dev"}, Response: "ok", ResponseCode: 200},
+	)
+	stdout := &bytes.Buffer{}
+
+	_, exitCode := cli.Run(gogetter, []string{"-"}, strings.NewReader("GET https://pkg.go.dev"), stdout, &bytes.Buffer{})
+	if exitCode != cli.SuccessExitCode {
+		t.Fatalf("expected success exit code but got %v", exitCode)
+	}
+}
+
+func TestShouldFailOnConfiguredStatusClasses(t *testing.T) {
+	gogetter := tests.NewTestSetup(
+		t,
+		tests.SubstitutedRequest{Request: app.Request{Method: "GET", Url: "https://pkg.go.dev"}, Response: "not found", ResponseCode: 404},
+	)
+	filename := writeRequestFile(t, "GET https://pkg.go.dev")
+
+	_, exitCode := cli.Run(gogetter, []string{filename}, nil, &bytes.Buffer{}, &bytes.Buffer{})
+	if exitCode != cli.StatusFailureExitCode {
+		t.Fatalf("expected status failure exit code but got %v", exitCode)
+	}
+
+	_, exitCode = cli.Run(gogetter, []string{"-fail-on", "5xx", filename}, nil, &bytes.Buffer{}, &bytes.Buffer{})
+	if exitCode != cli.SuccessExitCode {
+		t.Fatalf("expected success exit code but got %v", exitCode)
+	}
+
+	_, exitCode = cli.Run(gogetter, []string{"-fail-on", "404", filename}, nil, &bytes.Buffer{}, &bytes.Buffer{})
+	if exitCode != cli.StatusFailureExitCode {
+		t.Fatalf("expected status failure exit code but got %v", exitCode)
+	}
+}
+
+func TestShouldFailOnTransportError(t *testing.T) {
+	gogetter := tests.NewTestSetup(t)
+	filename := writeRequestFile(t, "GET https://pkg.go.dev")
+
+	_, exitCode := cli.Run(gogetter, []string{filename}, nil, &bytes.Buffer{}, &bytes.Buffer{})
+	if exitCode != cli.ErrorExitCode {
+		t.Fatalf("expected error exit code but got %v", exitCode)
+	}
+}
+
+func TestShouldRejectInvalidRunUsage(t *testing.T) {
+	gogetter := tests.NewTestSetup(t)
+
+	_, exitCode := cli.Run(gogetter, []string{}, nil, &bytes.Buffer{}, &bytes.Buffer{})
+	if exitCode != cli.UsageExitCode {
+		t.Fatalf("expected usage exit code but got %v", exitCode)
+	}
+	_, exitCode = cli.Run(gogetter, []string{"-fail-on", "5x", "file"}, nil, &bytes.Buffer{}, &bytes.Buffer{})
+	if exitCode != cli.UsageExitCode {
+		t.Fatalf("expected usage exit code but got %v", exitCode)
+	}
+}
@@ -1,6 +1,7 @@
 package tests_test
 
 import (
+	"context"
 	"os"
 	"strings"
 	"testing"
@@ -90,7 +91,7 @@ func TestShouldWorkWithoutSavedRequestOption(t *testing.T) {
 		t.Fatalf("new gogetter failed: %v", err)
 	}
 
-	_, _, _, err = gogetter.Execute(app.Request{Method: "GET", Url: "https://pkg.go.dev"})
+	_, _, _, err = gogetter.Execute(context.Background(), app.Request{Method: "GET", Url: "https://pkg.go.dev"})
 	if err != nil {
 		t.Fatalf("request execution failed: %v", err)
 	}
@@ -154,3 +155,57 @@ func TestRemoveSavedRequest(t *testing.T) {
 		t.Fatalf("saved requests not wrote correctly: %v", string(actualSavedRequests))
 	}
 }
+
+func TestShouldEditSavedRequestMetadata(t *testing.T) {
+	testClient := tests.NewTestClient()
+	initialSavedRequests := strings.NewReader(`["GET https://pkg.go.dev"]`)
+	var writtenSavedRequests []byte
+	requestsSavingFunc := func(toWrite []byte) error {
+		writtenSavedRequests = toWrite
+		return nil
+	}
+	gogetter, err := app.NewGogetter(testClient, app.WithSavedRequests{InitialSavedRequests: initialSavedRequests, RequestsSavingFunc: requestsSavingFunc})
+	if err != nil {
+		t.Fatalf("new gogetter failed: %v", err)
+	}
+
+	gogetter, err = gogetter.EditSavedRequest(0, app.SavedRequestMetadata{
+		Name:        "Go packages",
+		Description: "Home page\nof the packages",
+		Tags:        []string{"go", "docs"},
+	})
+	if err != nil {
+		t.Fatalf("saved request edition failed: %v", err)
+	}
+
+	expectedWrite := `["# name: Go packages\n# description: Home page of the packages\n# tags: go, docs\nGET https://pkg.go.dev"]`
+	if string(writtenSavedRequests) != expectedWrite {
+		t.Fatalf("saved requests not wrote correctly: %v", string(writtenSavedRequests))
+	}
+
+	gogetter, err = app.NewGogetter(testClient, app.WithSavedRequests{InitialSavedRequests: strings.NewReader(string(writtenSavedRequests))})
+	if err != nil {
+		t.Fatalf("new gogetter failed: %v", err)
+	}
+	savedRequests := gogetter.SavedRequests()
+	if len(savedRequests) != 1 ||
+		savedRequests[0].Name != "Go packages" ||
+		savedRequests[0].Description != "Home page of the packages" ||
+		len(savedRequests[0].Tags) != 2 ||
+		savedRequests[0].Tags[1] != "docs" ||
+		savedRequests[0].Raw != "GET https://pkg.go.dev" ||
+		savedRequests[0].Url != "https://pkg.go.dev" {
+		t.Fatalf("saved request metadata not loaded correctly: %+v", savedRequests)
+	}
+}
+
+func TestShouldRejectInvalidSavedRequestEditionIndex(t *testing.T) {
+	gogetter, err := app.NewGogetter(tests.NewTestClient())
+	if err != nil {
+		t.Fatalf("new gogetter failed: %v", err)
+	}
+	_, err = gogetter.EditSavedRequest(0, app.SavedRequestMetadata{Name: "name"})
+	if err == nil {
+		t.Fatalf("expected invalid index to be rejected")
+	}
+}
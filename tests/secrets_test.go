@@ -0,0 +1,115 @@
+package tests_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ThomasFerro/gogetter/app"
+	"github.com/ThomasFerro/gogetter/tests"
+)
+
+func TestShouldParseDotEnvFile(t *testing.T) {
+	values, err := app.ParseDotEnv(strings.NewReader(`# API credentials
+API_KEY=abc123 # inline comment
+export TOKEN = "multi\nline"
+
+RAW='single # quoted'
+EMPTY=
+`))
+	if err != nil {
+		t.Fatalf(".env parsing failed: %v", err)
+	}
+	if len(values) != 4 || values["API_KEY"] != "abc123" || values["TOKEN"] != "multi\nline" || values["RAW"] != "single # quoted" || values["EMPTY"] != "" {
+		t.Fatalf("unexpected values: %#v", values)
+	}
+
+	_, err = app.ParseDotEnv(strings.NewReader("API_KEY=abc\nnot a declaration"))
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Fatalf("expected an error for the second line but got %v", err)
+	}
+}
+
+func TestShouldRedactSecretsFromHistory(t *testing.T) {
+	t.Setenv("GOGETTER_TEST_PASSWORD", "hunter2")
+	writtenHistory := ""
+	gogetter, err := app.NewGogetter(
+		tests.NewTestClient(tests.SubstitutedRequest{
+			Request:  app.Request{Method: "POST", Url: "https://api.com/login?key=abc123", Headers: app.Headers{"X-Password": "hunter2"}},
+			Response: `{"echo":"abc123"}`, ResponseCode: 200,
+		}),
+		app.WithSecrets{DotEnv: strings.NewReader("API_KEY=abc123")},
+		app.WithHistory{PreviousHistory: strings.NewReader("[]"), RecordResponses: true, HistoryWriter: func(toWrite []byte) error {
+			writtenHistory = string(toWrite)
+			return nil
+		}},
+	)
+	if err != nil {
+		t.Fatalf("new gogetter failed: %v", err)
+	}
+	rawRequest := `POST https://api.com/login?key={{secret "API_KEY"}} X-Password=:{{"GOGETTER_TEST_PASSWORD"|secret}}`
+	templateOption := app.TemplatedRequestOption{Functions: gogetter.TemplateFunctions()}
+	request, err := app.ParseRequest(rawRequest, templateOption)
+	if err != nil {
+		t.Fatalf("request parsing failed: %v", err)
+	}
+
+	gogetter, _, _, err = gogetter.Execute(context.Background(), request)
+	if err != nil {
+		t.Fatalf("request execution failed: %v", err)
+	}
+	if strings.Contains(writtenHistory, "abc123") || strings.Contains(writtenHistory, "hunter2") {
+		t.Fatalf("secrets written in the history: %v", writtenHistory)
+	}
+	historyEntry := gogetter.History()[0]
+	if historyEntry.Url != "https://api.com/login?key={{`API_KEY`|secret}}" ||
+		historyEntry.Headers["X-Password"] != "{{`GOGETTER_TEST_PASSWORD`|secret}}" ||
+		historyEntry.Response.Body != "{\"echo\":\"{{`API_KEY`|secret}}\"}" {
+		t.Fatalf("secrets not redacted: %+v %+v", historyEntry.Request, historyEntry.Response)
+	}
+
+	replayedRequest, err := app.ParseRequest(historyEntry.Raw, templateOption)
+	if err != nil {
+		t.Fatalf("redacted request parsing failed: %v", err)
+	}
+	if replayedRequest.Url != request.Url || replayedRequest.Headers["X-Password"] != "hunter2" {
+		t.Fatalf("redacted request not replayable: %+v", replayedRequest)
+	}
+
+	_, err = app.ParseRequest(`GET https://api.com?key={{secret "GOGETTER_TEST_MISSING"}}`, templateOption)
+	if err == nil {
+		t.Fatal("expected an error for a missing secret")
+	}
+}
+
+func TestShouldOnlyRedactTheSecretsReadByTheRequest(t *testing.T) {
+	t.Setenv("GOGETTER_TEST_TOKEN", "t0k3n-from-env")
+	gogetter, err := app.NewGogetter(
+		tests.NewTestClient(tests.SubstitutedRequest{
+			Request:  app.Request{Method: "GET", Url: "https://api.com/dev?debug=true", Headers: app.Headers{"Authorization": "t0k3n-from-env"}},
+			Response: `{"stage":"dev","debug":"true","other":"unread-secret"}`, ResponseCode: 200,
+		}),
+		app.WithSecrets{DotEnv: strings.NewReader("STAGE=dev\nDEBUG=true\nOTHER=unread-secret")},
+		app.WithHistory{PreviousHistory: strings.NewReader("[]"), RecordResponses: true, HistoryWriter: func(toWrite []byte) error { return nil }},
+	)
+	if err != nil {
+		t.Fatalf("new gogetter failed: %v", err)
+	}
+	templateOption := app.TemplatedRequestOption{Functions: gogetter.TemplateFunctions()}
+	request, err := app.ParseRequest(`GET https://api.com/{{secret "STAGE"}}?debug={{secret "DEBUG"}} Authorization=:{{env "GOGETTER_TEST_TOKEN"}}`, templateOption)
+	if err != nil {
+		t.Fatalf("request parsing failed: %v", err)
+	}
+
+	gogetter, _, _, err = gogetter.Execute(context.Background(), request)
+	if err != nil {
+		t.Fatalf("request execution failed: %v", err)
+	}
+	historyEntry := gogetter.History()[0]
+	if historyEntry.Url != "https://api.com/dev?debug=true" || historyEntry.Headers["Authorization"] != "{{`GOGETTER_TEST_TOKEN`|env}}" {
+		t.Fatalf("unexpected redaction of the request: %+v", historyEntry.Request)
+	}
+	if historyEntry.Response.Body != `{"stage":"dev","debug":"true","other":"unread-secret"}` {
+		t.Fatalf("unexpected redaction of the response: %v", historyEntry.Response.Body)
+	}
+}
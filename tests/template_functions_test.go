@@ -0,0 +1,85 @@
+package tests_test
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/ThomasFerro/gogetter/app"
+	"github.com/ThomasFerro/gogetter/tests"
+)
+
+func templated(t *testing.T, input string, option app.TemplatedRequestOption) string {
+	templatedInput, err := option.Apply(input)
+	if err != nil {
+		t.Fatalf("templating of %q failed: %v", input, err)
+	}
+	return templatedInput
+}
+
+func TestShouldProvideDefaultTemplateFunctions(t *testing.T) {
+	t.Setenv("GOGETTER_TEST_TOKEN", "from-env")
+	option := app.TemplatedRequestOption{Data: map[string]any{"secret": "key", "payload": "message", "page": nil}}
+
+	for input, expected := range map[string]string{
+		`{{base64 "user:password"}}`:             "dXNlcjpwYXNzd29yZA==",
+		`{{base64Decode "dXNlcjpwYXNzd29yZA=="}}`: "user:password",
+		`{{base64Url "?>?"}}`:                    "Pz4_",
+		`{{urlEncode "a b&c"}}`:                  "a+b%26c",
+		`{{urlPathEncode "a b/c"}}`:              "a%20b%2Fc",
+		`{{sha256 "abc"}}`:                       "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad",
+		`{{hmacSha256 .secret .payload}}`:        "6e9ef29b75fffc5b7abae527d58fdadb2fe42e7219011976917343065f58ed4a",
+		`{{hmacSha256Base64 .secret .payload}}`:  "bp7ym3X//Ft6uuUn1Y/a2y/kLnIZARl2kXNDBl9Y7Uo=",
+		`{{env "GOGETTER_TEST_TOKEN"}}`:          "from-env",
+		`{{"Go" | upper}} {{lower "Go"}}`:        "GO go",
+		`{{json .payload}}`:                      `"message"`,
+		`{{.page | default 1}}`:                  "1",
+		`{{.payload | default "none"}}`:          "message",
+		`{{randomString 12 | len}}`:              "12",
+	} {
+		if actual := templated(t, input, option); actual != expected {
+			t.Fatalf("expected %q to be templated as %q but got %q", input, expected, actual)
+		}
+	}
+
+	uuid := templated(t, "{{uuid}}", option)
+	if !regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`).MatchString(uuid) || uuid == templated(t, "{{uuid}}", option) {
+		t.Fatalf("invalid uuid: %v", uuid)
+	}
+	if timestamp := templated(t, "{{timestamp}}", option); !regexp.MustCompile(`^[0-9]{10}$`).MatchString(timestamp) {
+		t.Fatalf("invalid timestamp: %v", timestamp)
+	}
+	if date := templated(t, `{{date "2006-01-02"}} {{isoTimestamp}}`, option); !regexp.MustCompile(`^\d{4}-\d{2}-\d{2} \d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}Z$`).MatchString(date) {
+		t.Fatalf("invalid dates: %v", date)
+	}
+	for range 20 {
+		if value := templated(t, "{{randomInt 3 5}}", option); value != "3" && value != "4" {
+			t.Fatalf("random integer out of range: %v", value)
+		}
+	}
+	if _, err := option.Apply("{{randomInt 5 5}}"); err == nil {
+		t.Fatal("expected an error for an empty range")
+	}
+}
+
+func TestShouldAddCustomTemplateFunctions(t *testing.T) {
+	option := app.TemplatedRequestOption{Functions: template.FuncMap{
+		"tenant": func() string { return "acme" },
+		"upper":  func(text string) string { return "overridden " + text },
+	}}
+	if actual := templated(t, "{{tenant}} {{upper `a`}} {{lower `B`}}", option); actual != "acme overridden a b" {
+		t.Fatalf("custom functions not applied: %q", actual)
+	}
+
+	gogetter := tests.NewGogetter(t,
+		tests.NewTestClient(tests.SubstitutedRequest{Request: app.Request{Method: "GET", Url: "https://acme.api.com"}, Response: "ok", ResponseCode: 200}),
+		app.WithTemplateFunctions{Functions: template.FuncMap{"tenant": func() string { return "acme" }}},
+		app.WithSavedRequests{InitialSavedRequests: strings.NewReader(`["GET https://{{tenant}}.api.com"]`)},
+	)
+	_, report := gogetter.RunWorkflow(context.Background(), app.Workflow{Steps: gogetter.SavedRequests()})
+	if report.Passed() != 1 {
+		t.Fatalf("custom functions not available to workflows:\n%v", report)
+	}
+}
@@ -1,6 +1,7 @@
 package tests_test
 
 import (
+	"context"
 	"io"
 	"net/http"
 	"testing"
@@ -27,7 +28,7 @@ func TestShouldSendTemplatedRequest(t *testing.T) {
 	if err != nil {
 		t.Fatalf("request parsing failed: %v", err)
 	}
-	gogetter, _, result, err = gogetter.Execute(request)
+	gogetter, _, result, err = gogetter.Execute(context.Background(), request)
 	if err != nil {
 		t.Fatalf("request execution failed: %v", err)
 	}
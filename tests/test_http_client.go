@@ -1,6 +1,7 @@
 package tests
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"log/slog"
@@ -15,8 +16,9 @@ import (
 
 type SubstitutedRequest struct {
 	app.Request
-	Response     string
-	ResponseCode int
+	Response        string
+	ResponseCode    int
+	ResponseHeaders http.Header
 }
 
 func (s SubstitutedRequest) Apply(t TestHttpClient) TestHttpClient {
@@ -28,6 +30,7 @@ func (s SubstitutedRequest) ToHttpResponse() *http.Response {
 	return &http.Response{
 		Status:     "200 OK",
 		StatusCode: s.ResponseCode,
+		Header:     s.ResponseHeaders,
 		Body:       io.NopCloser(strings.NewReader(s.Response)),
 	}
 }
@@ -156,15 +159,43 @@ func NewTestClient(options ...TestClientOption) TestHttpClient {
 	return testClient
 }
 
-func NewTestSetup(t *testing.T, options ...TestClientOption) app.Gogetter {
-	testClient := NewTestClient(options...)
-	gogetter, err := app.NewGogetter(
-		testClient,
-		app.WithHistory{
-			PreviousHistory: strings.NewReader("[]"),
-			HistoryWriter:   func(toWrite []byte) error { return nil }})
+// DiscardedHistory starts from an empty history and drops the new entries
+func DiscardedHistory() app.WithHistory {
+	return app.WithHistory{
+		PreviousHistory: strings.NewReader("[]"),
+		HistoryWriter:   func(toWrite []byte) error { return nil }}
+}
+
+func NewGogetter(t *testing.T, client app.HttpClient, options ...app.GogetterOption) app.Gogetter {
+	gogetter, err := app.NewGogetter(client, append([]app.GogetterOption{DiscardedHistory()}, options...)...)
 	if err != nil {
 		t.Fatalf("new gogetter failed: %v", err)
 	}
 	return gogetter
 }
+
+func NewTestSetup(t *testing.T, options ...TestClientOption) app.Gogetter {
+	return NewGogetter(t, NewTestClient(options...))
+}
+
+// ExecuteRawRequest parses and executes the request, closing its response
+func ExecuteRawRequest(gogetter app.Gogetter, rawRequest string) (app.Gogetter, int, error) {
+	request, err := app.ParseRequest(rawRequest)
+	if err != nil {
+		return gogetter, 0, err
+	}
+	gogetter, requestAndResponse, response, err := gogetter.Execute(context.Background(), request)
+	if err != nil {
+		return gogetter, 0, err
+	}
+	response.Body.Close()
+	return gogetter, requestAndResponse.ResponseCode, nil
+}
+
+func ExecuteForStatus(t *testing.T, gogetter app.Gogetter, rawRequest string) (app.Gogetter, int) {
+	gogetter, status, err := ExecuteRawRequest(gogetter, rawRequest)
+	if err != nil {
+		t.Fatalf("request execution failed: %v", err)
+	}
+	return gogetter, status
+}
@@ -0,0 +1,50 @@
+package tests_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ThomasFerro/gogetter/app"
+	"github.com/ThomasFerro/gogetter/tests"
+)
+
+func TestShouldMeasureRequestTiming(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+	gogetter, err := app.NewGogetter(server.Client())
+	if err != nil {
+		t.Fatalf("new gogetter failed: %v", err)
+	}
+
+	_, requestAndResponse, response, err := gogetter.Execute(context.Background(), app.Request{Method: "GET", Url: server.URL})
+	if err != nil {
+		t.Fatalf("request execution failed: %v", err)
+	}
+	defer response.Body.Close()
+
+	timing := requestAndResponse.Timing
+	if timing.Total <= 0 || timing.Connect <= 0 || timing.TimeToFirstByte <= 0 {
+		t.Fatalf("timing not measured correctly: %+v", timing)
+	}
+	if timing.TimeToFirstByte > timing.Total {
+		t.Fatalf("time to first byte cannot exceed total: %+v", timing)
+	}
+}
+
+func TestShouldMeasureTotalTimingWithAnyClient(t *testing.T) {
+	gogetter := tests.NewTestSetup(
+		t,
+		tests.SubstitutedRequest{Request: app.Request{Method: "GET", Url: "https://pkg.go.dev"}, Response: "ok"},
+	)
+	_, requestAndResponse, _, err := gogetter.Execute(context.Background(), app.Request{Method: "GET", Url: "https://pkg.go.dev"})
+	if err != nil {
+		t.Fatalf("request execution failed: %v", err)
+	}
+	if requestAndResponse.Timing.Total <= 0 {
+		t.Fatalf("total timing not measured: %+v", requestAndResponse.Timing)
+	}
+}
@@ -0,0 +1,99 @@
+package tests_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ThomasFerro/gogetter/app"
+	"github.com/ThomasFerro/gogetter/cli"
+	"github.com/ThomasFerro/gogetter/tests"
+)
+
+var workflowClient = tests.NewTestClient(
+	tests.SubstitutedRequest{Request: app.Request{Method: "POST", Url: "https://api.com/login"}, Response: `{"token":"abc"}`, ResponseCode: 200},
+	tests.SubstitutedRequest{Request: app.Request{Method: "GET", Url: "https://api.com/users/abc"}, Response: "[]", ResponseCode: 200},
+	tests.SubstitutedRequest{Request: app.Request{Method: "GET", Url: "https://api.com/missing"}, Response: "not found", ResponseCode: 404},
+)
+
+func TestShouldRunWorkflowWithAccumulatedVariables(t *testing.T) {
+	gogetter := tests.NewGogetter(t, workflowClient, app.WithSavedRequests{InitialSavedRequests: strings.NewReader(`[
+		"# name: Login\nPOST https://{{.host}}/login\ncapture token json $.token",
+		"# name: Missing\nGET https://{{.host}}/missing",
+		"# name: Users\nGET https://{{.host}}/users/{{.token}}"
+	]`)})
+	steps := []string{}
+
+	gogetter, report := gogetter.RunWorkflow(context.Background(), app.Workflow{
+		WorkflowSettings: app.WorkflowSettings{Delay: 10 * time.Millisecond, FailingStatusClasses: []app.StatusClass{"5xx"}},
+		Steps:            gogetter.SavedRequests(),
+		AdHocVariables:   map[string]any{"host": "api.com"},
+		OnStep:           func(step app.WorkflowStepResult) { steps = append(steps, step.Title()) },
+	})
+	if report.Passed() != 3 || report.Failed() != 0 || report.Duration < 20*time.Millisecond {
+		t.Fatalf("unexpected report:\n%v", report)
+	}
+	if strings.Join(steps, ",") != "Login,Missing,Users" {
+		t.Fatalf("unexpected reported steps: %v", steps)
+	}
+	if len(gogetter.History()) != 3 || gogetter.CapturedVariables()["token"] != "abc" {
+		t.Fatalf("workflow executions not kept: %v", gogetter.History())
+	}
+}
+
+func TestShouldStopWorkflowOnFailure(t *testing.T) {
+	gogetter := tests.NewGogetter(t, workflowClient, app.WithSavedRequests{InitialSavedRequests: strings.NewReader(`[
+		"# name: Login\nPOST https://api.com/login",
+		"# name: Missing\nGET https://api.com/missing",
+		"# name: Users\nGET https://api.com/users/abc"
+	]`)})
+	workflow := app.Workflow{
+		WorkflowSettings: app.WorkflowSettings{StopOnFailure: true, FailingStatusClasses: []app.StatusClass{"4xx", "5xx"}},
+		Steps:            gogetter.SavedRequests(),
+	}
+
+	_, report := gogetter.RunWorkflow(context.Background(), workflow)
+	if report.Passed() != 1 || report.Failed() != 1 || report.Skipped() != 1 {
+		t.Fatalf("unexpected report:\n%v", report)
+	}
+	if report.Summary() != "1 passed, 1 failed, 1 skipped" ||
+		report.Steps[1].Failures[0] != "failing status: 404" ||
+		report.Steps[2].String() != "SKIP Users" {
+		t.Fatalf("unexpected report:\n%v", report)
+	}
+
+	workflow.StopOnFailure = false
+	_, report = gogetter.RunWorkflow(context.Background(), workflow)
+	if report.Passed() != 2 || report.Failed() != 1 || report.Skipped() != 0 {
+		t.Fatalf("unexpected report:\n%v", report)
+	}
+}
+
+func TestShouldRunWorkflowCommandInGivenOrder(t *testing.T) {
+	gogetter := tests.NewGogetter(t, workflowClient, app.WithSavedRequests{InitialSavedRequests: strings.NewReader(`[
+		"# name: Users\nGET https://api.com/users/{{.token}}",
+		"# name: Login\nPOST https://api.com/login\ncapture token json $.token"
+	]`)})
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+
+	_, exitCode := cli.Workflow(gogetter, []string{"-delay", "1ms", "Login", "Users"}, stdout, stderr)
+	if exitCode != cli.SuccessExitCode {
+		t.Fatalf("expected success exit code but got %v: %v%v", exitCode, stdout.String(), stderr.String())
+	}
+	if !strings.HasPrefix(stdout.String(), "PASS Login") || !strings.Contains(stdout.String(), "2 passed, 0 failed in ") {
+		t.Fatalf("unexpected output:\n%v", stdout.String())
+	}
+
+	_, exitCode = cli.Workflow(gogetter, []string{"Users", "Login"}, stdout, stderr)
+	if exitCode != cli.TestFailureExitCode {
+		t.Fatalf("expected test failure exit code but got %v", exitCode)
+	}
+
+	_, exitCode = cli.Workflow(gogetter, []string{"Unknown"}, stdout, stderr)
+	if exitCode != cli.UsageExitCode {
+		t.Fatalf("expected usage exit code but got %v", exitCode)
+	}
+}
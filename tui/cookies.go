@@ -0,0 +1,158 @@
+package tui
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/ThomasFerro/gogetter/app"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type cookiesItemDelegate struct{}
+
+func (d cookiesItemDelegate) Height() int                             { return 2 }
+func (d cookiesItemDelegate) Spacing() int                            { return 0 }
+func (d cookiesItemDelegate) Update(_ tea.Msg, _ *list.Model) tea.Cmd { return nil }
+func (d cookiesItemDelegate) Render(w io.Writer, m list.Model, index int, listItem list.Item) {
+	item, ok := listItem.(cookieItem)
+	if !ok {
+		return
+	}
+	cookie := item.Cookie
+
+	secondaryElements := []string{cookie.Domain + cookie.Path}
+	if cookie.Expires.IsZero() {
+		secondaryElements = append(secondaryElements, "session")
+	} else {
+		secondaryElements = append(secondaryElements, "expires "+cookie.Expires.Local().Format(time.DateTime))
+	}
+	if cookie.Secure {
+		secondaryElements = append(secondaryElements, "secure")
+	}
+	if cookie.HttpOnly {
+		secondaryElements = append(secondaryElements, "http only")
+	}
+	str := fmt.Sprintf("%d. %s", index+1, cookie)
+
+	fn := savedRequestsItemStyle.Render
+	if index == m.Index() {
+		fn = func(s ...string) string {
+			return savedRequestsSelectedItemStyle.Render("> " + strings.Join(s, " "))
+		}
+	}
+	fmt.Fprint(w, fn(str)+"\n"+savedRequestsSecondaryItemStyle.Render(strings.Join(secondaryElements, " · ")))
+}
+
+type cookieItem struct {
+	app.Cookie
+}
+
+func (c cookieItem) FilterValue() string {
+	return fmt.Sprintf("%v %v%v", c.Name, c.Domain, c.Path)
+}
+
+func mapCookies(cookies []app.Cookie) []list.Item {
+	items := []list.Item{}
+	for _, cookie := range cookies {
+		items = append(items, cookieItem{Cookie: cookie})
+	}
+	return items
+}
+
+func newCookiesList(cookies []app.Cookie) list.Model {
+	l := list.New(mapCookies(cookies), cookiesItemDelegate{}, 0, bottomListHeight)
+	l.SetShowStatusBar(false)
+	l.SetFilteringEnabled(true)
+	l.SetShowHelp(false)
+	l.Title = "Cookies"
+	l.SetShowTitle(true)
+	return l
+}
+
+const (
+	nameCookieInput = iota
+	valueCookieInput
+	domainCookieInput
+	pathCookieInput
+	expiresCookieInput
+)
+
+// cookieEditor is the dialog editing a cookie of the jar, the secure and
+// http only flags being kept
+type cookieEditor struct {
+	previous app.Cookie
+	inputs   []textinput.Model
+	focused  int
+}
+
+func newCookieEditor(cookie app.Cookie) cookieEditor {
+	newInput := func(prompt string, value string) textinput.Model {
+		input := textinput.New()
+		input.Prompt = prompt
+		input.SetValue(value)
+		return input
+	}
+	expires := newInput("Expires: ", "")
+	expires.Placeholder = "YYYY-MM-DD hh:mm:ss, empty for a session cookie"
+	if !cookie.Expires.IsZero() {
+		expires.SetValue(cookie.Expires.Local().Format(time.DateTime))
+	}
+	editor := cookieEditor{
+		previous: cookie,
+		inputs: []textinput.Model{
+			newInput("Name: ", cookie.Name),
+			newInput("Value: ", cookie.Value),
+			newInput("Domain: ", cookie.Domain),
+			newInput("Path: ", cookie.Path),
+			expires,
+		},
+	}
+	editor.inputs[nameCookieInput].Focus()
+	return editor
+}
+
+func (e cookieEditor) cookie() (app.Cookie, error) {
+	cookie := e.previous
+	cookie.Name = strings.TrimSpace(e.inputs[nameCookieInput].Value())
+	cookie.Value = e.inputs[valueCookieInput].Value()
+	cookie.Domain = strings.TrimSpace(e.inputs[domainCookieInput].Value())
+	cookie.Path = strings.TrimSpace(e.inputs[pathCookieInput].Value())
+	cookie.Expires = time.Time{}
+	if expires := strings.TrimSpace(e.inputs[expiresCookieInput].Value()); expires != "" {
+		var err error
+		cookie.Expires, err = time.ParseInLocation(time.DateTime, expires, time.Local)
+		if err != nil {
+			return app.Cookie{}, fmt.Errorf("invalid expiry %q, expected YYYY-MM-DD hh:mm:ss", expires)
+		}
+	}
+	return cookie, nil
+}
+
+func (e cookieEditor) focus(next bool) (cookieEditor, tea.Cmd) {
+	e.inputs[e.focused].Blur()
+	if next {
+		e.focused = (e.focused + 1) % len(e.inputs)
+	} else {
+		e.focused = (e.focused + len(e.inputs) - 1) % len(e.inputs)
+	}
+	return e, e.inputs[e.focused].Focus()
+}
+
+func (e cookieEditor) Update(msg tea.Msg) (cookieEditor, tea.Cmd) {
+	var cmd tea.Cmd
+	e.inputs[e.focused], cmd = e.inputs[e.focused].Update(msg)
+	return e, cmd
+}
+
+func (e cookieEditor) View(width int) string {
+	views := []string{"Edit cookie (enter to save, esc to cancel)"}
+	for _, input := range e.inputs {
+		input.Width = max(width-len(input.Prompt)-6, 1)
+		views = append(views, input.View())
+	}
+	return savedRequestEditorStyle.Width(max(width-2, 1)).Render(strings.Join(views, "\n"))
+}
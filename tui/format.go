@@ -0,0 +1,335 @@
+package tui
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"mime"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	jsonKeyStyle         = lipgloss.NewStyle().Foreground(lipgloss.Color("75"))
+	jsonStringStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("114"))
+	jsonNumberStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("215"))
+	jsonLiteralStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("170"))
+	jsonPunctuationStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("245"))
+	markupTagStyle       = lipgloss.NewStyle().Foreground(lipgloss.Color("75"))
+	markupCommentStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+)
+
+// BodyFormat is the format the response body is indented and highlighted in
+type BodyFormat int
+
+const (
+	RawBodyFormat BodyFormat = iota
+	JsonBodyFormat
+	XmlBodyFormat
+	HtmlBodyFormat
+)
+
+// DetectBodyFormat reads the format of the body from its content type
+func DetectBodyFormat(contentType string) BodyFormat {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return RawBodyFormat
+	}
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		return JsonBodyFormat
+	case mediaType == "text/html" || mediaType == "application/xhtml+xml":
+		return HtmlBodyFormat
+	case mediaType == "application/xml" || mediaType == "text/xml" || strings.HasSuffix(mediaType, "+xml"):
+		return XmlBodyFormat
+	}
+	return RawBodyFormat
+}
+
+// PrettyBody indents the body according to its format, any invalid payload is
+// returned untouched as a raw body
+func PrettyBody(body string, format BodyFormat) (string, BodyFormat) {
+	var pretty string
+	var err error
+	switch format {
+	case JsonBodyFormat:
+		pretty, err = prettyJson(body)
+	case XmlBodyFormat:
+		pretty, err = prettyXml(body)
+	case HtmlBodyFormat:
+		pretty = prettyHtml(body)
+	default:
+		return body, RawBodyFormat
+	}
+	if err != nil {
+		return body, RawBodyFormat
+	}
+	return pretty, format
+}
+
+func highlightBody(body string, format BodyFormat) string {
+	switch format {
+	case JsonBodyFormat:
+		return highlightJson(body)
+	case XmlBodyFormat, HtmlBodyFormat:
+		return highlightMarkup(body)
+	}
+	return body
+}
+
+func prettyJson(body string) (string, error) {
+	buffer := &bytes.Buffer{}
+	err := json.Indent(buffer, []byte(body), "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return buffer.String(), nil
+}
+
+func xmlName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+	return name.Space + ":" + name.Local
+}
+
+var (
+	xmlTextEscaper      = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+	xmlAttributeEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;")
+)
+
+// checkXml reads the whole document, which fails on the mismatched elements
+// and undeclared entities RawToken accepts
+func checkXml(body string) error {
+	decoder := xml.NewDecoder(strings.NewReader(body))
+	for {
+		_, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// prettyXml writes the elements as they are named in the body, namespace
+// prefixes included, which xml.Encoder would rewrite as a different document.
+// The elements holding text or nothing are kept on one line.
+func prettyXml(body string) (string, error) {
+	err := checkXml(body)
+	if err != nil {
+		return "", err
+	}
+	decoder := xml.NewDecoder(strings.NewReader(body))
+	buffer := &bytes.Buffer{}
+	depth := 0
+	// inline is set after a start element or a text, an end element following
+	// them closing the element on the same line
+	inline := false
+	// empty is set right after a start element, an end element following it
+	// closing the element as <element/>
+	empty := false
+	newLine := func() {
+		if buffer.Len() > 0 {
+			buffer.WriteString("\n")
+		}
+		buffer.WriteString(strings.Repeat("  ", depth))
+	}
+	for {
+		token, err := decoder.RawToken()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		switch token := token.(type) {
+		case xml.StartElement:
+			newLine()
+			buffer.WriteString("<" + xmlName(token.Name))
+			for _, attribute := range token.Attr {
+				buffer.WriteString(" " + xmlName(attribute.Name) + `="` + xmlAttributeEscaper.Replace(attribute.Value) + `"`)
+			}
+			buffer.WriteString(">")
+			depth++
+			inline, empty = true, true
+			continue
+		case xml.EndElement:
+			depth--
+			switch {
+			case empty:
+				buffer.Truncate(buffer.Len() - len(">"))
+				buffer.WriteString("/>")
+			case inline:
+				buffer.WriteString("</" + xmlName(token.Name) + ">")
+			default:
+				newLine()
+				buffer.WriteString("</" + xmlName(token.Name) + ">")
+			}
+			inline = false
+		case xml.CharData:
+			text := strings.TrimSpace(string(token))
+			if text == "" {
+				continue
+			}
+			if !inline {
+				newLine()
+			}
+			buffer.WriteString(xmlTextEscaper.Replace(text))
+			inline = true
+		case xml.Comment:
+			newLine()
+			buffer.WriteString("<!--" + string(token) + "-->")
+			inline = false
+		case xml.ProcInst:
+			newLine()
+			buffer.WriteString(strings.TrimSpace("<?" + token.Target + " " + string(token.Inst)))
+			buffer.WriteString("?>")
+			inline = false
+		case xml.Directive:
+			newLine()
+			buffer.WriteString("<!" + string(token) + ">")
+			inline = false
+		}
+		empty = false
+	}
+	return buffer.String(), nil
+}
+
+var (
+	htmlTokenRegexp   = regexp.MustCompile(`(?s)<!--.*?-->|<[^>]*>|[^<]+`)
+	htmlTagNameRegexp = regexp.MustCompile(`^</?\s*([a-zA-Z0-9-]+)`)
+	htmlVoidElements  = []string{"area", "base", "br", "col", "embed", "hr", "img", "input", "link", "meta", "source", "track", "wbr"}
+	htmlRawElements   = []string{"script", "style", "pre", "textarea"}
+)
+
+func htmlTagName(tag string) string {
+	match := htmlTagNameRegexp.FindStringSubmatch(tag)
+	if match == nil {
+		return ""
+	}
+	return strings.ToLower(match[1])
+}
+
+// prettyHtml puts every tag on its own line and indents the nested ones, the
+// content of raw elements such as script or pre is kept as is
+func prettyHtml(body string) string {
+	builder := strings.Builder{}
+	depth := 0
+	writeLine := func(line string) {
+		builder.WriteString(strings.Repeat("  ", depth))
+		builder.WriteString(line)
+		builder.WriteString("\n")
+	}
+
+	position := 0
+	for position < len(body) {
+		location := htmlTokenRegexp.FindStringIndex(body[position:])
+		if location == nil {
+			break
+		}
+		token := body[position+location[0] : position+location[1]]
+		position += location[1]
+
+		tagName := htmlTagName(token)
+		switch {
+		case !strings.HasPrefix(token, "<"):
+			text := strings.TrimSpace(token)
+			if text != "" {
+				writeLine(text)
+			}
+		case strings.HasPrefix(token, "</"):
+			depth = max(depth-1, 0)
+			writeLine(token)
+		case strings.HasPrefix(token, "<!") || strings.HasPrefix(token, "<?") || strings.HasSuffix(token, "/>") || slices.Contains(htmlVoidElements, tagName):
+			writeLine(token)
+		case slices.Contains(htmlRawElements, tagName):
+			closingTag := "</" + tagName
+			end := strings.Index(strings.ToLower(body[position:]), closingTag)
+			if end == -1 {
+				end = len(body) - position
+			}
+			writeLine(token + body[position:position+end])
+			position += end
+			depth++
+		default:
+			writeLine(token)
+			depth++
+		}
+	}
+	return strings.TrimSuffix(builder.String(), "\n")
+}
+
+// renderLines renders each line on its own so that lipgloss does not pad them
+// to the width of the longest one
+func renderLines(style lipgloss.Style, text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = style.Render(line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func highlightJson(body string) string {
+	builder := strings.Builder{}
+	position := 0
+	for position < len(body) {
+		char := body[position]
+		switch {
+		case char == '"':
+			end := position + 1
+			for end < len(body) && body[end] != '"' {
+				if body[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			end = min(end+1, len(body))
+			literal := body[position:end]
+			rest := strings.TrimLeft(body[end:], " \t\r\n")
+			if strings.HasPrefix(rest, ":") {
+				builder.WriteString(renderLines(jsonKeyStyle, literal))
+			} else {
+				builder.WriteString(renderLines(jsonStringStyle, literal))
+			}
+			position = end
+		case char == '-' || (char >= '0' && char <= '9'):
+			end := position + 1
+			for end < len(body) && strings.IndexByte("0123456789.eE+-", body[end]) != -1 {
+				end++
+			}
+			builder.WriteString(jsonNumberStyle.Render(body[position:end]))
+			position = end
+		case strings.HasPrefix(body[position:], "true") || strings.HasPrefix(body[position:], "null"):
+			builder.WriteString(jsonLiteralStyle.Render(body[position : position+4]))
+			position += 4
+		case strings.HasPrefix(body[position:], "false"):
+			builder.WriteString(jsonLiteralStyle.Render(body[position : position+5]))
+			position += 5
+		case strings.IndexByte("{}[],:", char) != -1:
+			builder.WriteString(jsonPunctuationStyle.Render(string(char)))
+			position++
+		default:
+			builder.WriteByte(char)
+			position++
+		}
+	}
+	return builder.String()
+}
+
+var markupTokenRegexp = regexp.MustCompile(`(?s)<!--.*?-->|<[^>]*>`)
+
+func highlightMarkup(body string) string {
+	return markupTokenRegexp.ReplaceAllStringFunc(body, func(token string) string {
+		if strings.HasPrefix(token, "<!--") {
+			return renderLines(markupCommentStyle, token)
+		}
+		return renderLines(markupTagStyle, token)
+	})
+}
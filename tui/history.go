@@ -47,10 +47,38 @@ func mapHistory(history app.History) []list.Item {
 	return items
 }
 
+// historyFilter applies the structured filters (e.g. status:5xx) before
+// fuzzy matching the rest of the term
+func historyFilter(term string, targets []string) []list.Rank {
+	filter := app.ParseHistoryFilter(term)
+	candidates := []string{}
+	candidateIndexes := []int{}
+	for index, target := range targets {
+		if filter.MatchesStructuredFilters(target) {
+			candidates = append(candidates, target)
+			candidateIndexes = append(candidateIndexes, index)
+		}
+	}
+
+	if filter.Text == "" {
+		ranks := make([]list.Rank, len(candidateIndexes))
+		for i, index := range candidateIndexes {
+			ranks[i] = list.Rank{Index: index}
+		}
+		return ranks
+	}
+	ranks := list.DefaultFilter(filter.Text, candidates)
+	for i := range ranks {
+		ranks[i].Index = candidateIndexes[ranks[i].Index]
+	}
+	return ranks
+}
+
 func newHistoryList(history app.History) list.Model {
 	l := list.New(mapHistory(history), historyItemDelegate{}, 0, bottomListHeight)
 	l.SetShowStatusBar(false)
-	l.SetFilteringEnabled(false)
+	l.SetFilteringEnabled(true)
+	l.Filter = historyFilter
 	l.SetShowHelp(false)
 	l.Title = "History"
 	l.SetShowTitle(true)
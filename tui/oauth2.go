@@ -0,0 +1,62 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ThomasFerro/gogetter/app"
+)
+
+// abbreviate keeps the start of the token, enough to tell tokens apart
+func abbreviate(token string) string {
+	if len(token) <= 16 {
+		return token
+	}
+	return token[:16] + "…"
+}
+
+// renderOAuth2Tokens describes the token cached for each OAuth2 configuration
+// in the active environment
+func renderOAuth2Tokens(gogetter app.Gogetter, now time.Time) string {
+	names := gogetter.OAuth2ConfigurationNames()
+	if len(names) == 0 {
+		return "No OAuth2 configuration"
+	}
+	environment := "no environment"
+	if activeEnvironment, ok := gogetter.ActiveEnvironment(); ok {
+		environment = activeEnvironment.Name
+	}
+	builder := strings.Builder{}
+	fmt.Fprintf(&builder, "OAuth2 tokens (%v)\n", environment)
+	for _, name := range names {
+		token, ok := gogetter.OAuth2Token(name)
+		builder.WriteString("\n")
+		if !ok {
+			fmt.Fprintf(&builder, "%v: no token yet\n", name)
+			continue
+		}
+		switch {
+		case token.AccessToken == "":
+			fmt.Fprintf(&builder, "%v: rejected, renewed on the next request\n", name)
+		case token.ExpiresAt.IsZero():
+			fmt.Fprintf(&builder, "%v: valid, without expiry\n", name)
+		case token.Expired(now):
+			fmt.Fprintf(&builder, "%v: expired at %v, renewed on the next request\n", name, token.ExpiresAt.Local().Format(time.DateTime))
+		default:
+			fmt.Fprintf(&builder, "%v: valid until %v (in %v)\n", name, token.ExpiresAt.Local().Format(time.DateTime), token.ExpiresAt.Sub(now).Round(time.Second))
+		}
+		fmt.Fprintf(&builder, "  Acquired at: %v\n", token.AcquiredAt.Local().Format(time.DateTime))
+		if token.AccessToken != "" {
+			fmt.Fprintf(&builder, "  Access token: %v\n", abbreviate(token.AccessToken))
+		}
+		if token.TokenType != "" {
+			fmt.Fprintf(&builder, "  Type: %v\n", token.TokenType)
+		}
+		if token.Scope != "" {
+			fmt.Fprintf(&builder, "  Scope: %v\n", token.Scope)
+		}
+		fmt.Fprintf(&builder, "  Refresh token: %v\n", token.RefreshToken != "")
+	}
+	return builder.String()
+}
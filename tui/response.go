@@ -0,0 +1,121 @@
+package tui
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ThomasFerro/gogetter/app"
+)
+
+type responseView struct {
+	method        string
+	proto         string
+	status        string
+	headers       http.Header
+	contentLength int64
+	timing        app.Timing
+	body          string
+	bodyTruncated bool
+	recordedAt    time.Time
+	assertions    []app.AssertionResult
+	captures      []app.CaptureResult
+}
+
+func newResponseView(requestAndResponse app.RequestAndResponse, resp *http.Response) (responseView, error) {
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return responseView{}, err
+	}
+	contentLength := resp.ContentLength
+	if contentLength < 0 || requestAndResponse.Method != http.MethodHead {
+		contentLength = int64(len(body))
+	}
+	return responseView{
+		method:        requestAndResponse.Method,
+		proto:         resp.Proto,
+		status:        resp.Status,
+		headers:       resp.Header,
+		contentLength: contentLength,
+		timing:        requestAndResponse.Timing,
+		body:          string(body),
+		assertions:    requestAndResponse.Assertions,
+		captures:      requestAndResponse.Captures,
+	}, nil
+}
+
+type responseRenderingOptions struct {
+	collapseHeaders bool
+	rawBody         bool
+	highlight       bool
+}
+
+func newRecordedResponseView(requestAndResponse app.RequestAndResponse) responseView {
+	recordedResponse := requestAndResponse.Response
+	return responseView{
+		method:        requestAndResponse.Method,
+		proto:         recordedResponse.Proto,
+		status:        recordedResponse.Status,
+		headers:       recordedResponse.Headers,
+		contentLength: int64(len(recordedResponse.Body)),
+		timing:        requestAndResponse.Timing,
+		body:          recordedResponse.Body,
+		bodyTruncated: recordedResponse.BodyTruncated,
+		recordedAt:    recordedResponse.Timestamp,
+	}
+}
+
+// render formats the response, HEAD responses have no body so their headers
+// are always displayed
+func (r responseView) render(options responseRenderingOptions) string {
+	builder := strings.Builder{}
+	fmt.Fprintf(&builder, "%v %v\n", r.proto, r.status)
+	fmt.Fprintf(&builder, "Content length: %v bytes\n", r.contentLength)
+	fmt.Fprintf(&builder, "Time: %v\n", r.timing)
+	if !r.recordedAt.IsZero() {
+		fmt.Fprintf(&builder, "Recorded at: %v\n", r.recordedAt.Format(time.DateTime))
+	}
+	for _, assertion := range r.assertions {
+		fmt.Fprintln(&builder, assertion)
+	}
+	for _, capture := range r.captures {
+		fmt.Fprintln(&builder, capture)
+	}
+	builder.WriteString("\n")
+
+	if options.collapseHeaders && r.method != http.MethodHead {
+		fmt.Fprintf(&builder, "Headers (%v) collapsed\n", len(r.headers))
+	} else {
+		headerKeys := make([]string, 0, len(r.headers))
+		for header := range r.headers {
+			headerKeys = append(headerKeys, header)
+		}
+		slices.Sort(headerKeys)
+		for _, header := range headerKeys {
+			for _, value := range r.headers[header] {
+				fmt.Fprintf(&builder, "%v: %v\n", header, value)
+			}
+		}
+	}
+
+	if r.method == http.MethodHead {
+		return builder.String()
+	}
+	builder.WriteString("\n")
+	if options.rawBody {
+		builder.WriteString(r.body)
+		return builder.String()
+	}
+	body, format := PrettyBody(r.body, DetectBodyFormat(r.headers.Get("Content-Type")))
+	if options.highlight {
+		body = highlightBody(body, format)
+	}
+	builder.WriteString(body)
+	if r.bodyTruncated {
+		builder.WriteString("\n[body truncated]")
+	}
+	return builder.String()
+}
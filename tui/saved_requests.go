@@ -7,27 +7,43 @@ import (
 
 	"github.com/ThomasFerro/gogetter/app"
 	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
 
 var (
-	savedRequestsItemStyle         = lipgloss.NewStyle().PaddingLeft(4)
-	savedRequestsSelectedItemStyle = lipgloss.NewStyle().PaddingLeft(2).Foreground(lipgloss.Color("170"))
+	savedRequestsItemStyle          = lipgloss.NewStyle().PaddingLeft(4)
+	savedRequestsSelectedItemStyle  = lipgloss.NewStyle().PaddingLeft(2).Foreground(lipgloss.Color("170"))
+	savedRequestsSecondaryItemStyle = lipgloss.NewStyle().PaddingLeft(7).Foreground(lipgloss.Color("240"))
+	savedRequestsTagStyle           = lipgloss.NewStyle().Foreground(lipgloss.Color("99"))
 )
 
 type savedRequestsItemDelegate struct{}
 
-func (d savedRequestsItemDelegate) Height() int                             { return 1 }
+func (d savedRequestsItemDelegate) Height() int                             { return 2 }
 func (d savedRequestsItemDelegate) Spacing() int                            { return 0 }
 func (d savedRequestsItemDelegate) Update(_ tea.Msg, _ *list.Model) tea.Cmd { return nil }
 func (d savedRequestsItemDelegate) Render(w io.Writer, m list.Model, index int, listItem list.Item) {
-	request, ok := listItem.(app.Request)
+	item, ok := listItem.(savedRequestItem)
 	if !ok {
 		return
 	}
+	savedRequest := item.SavedRequest
 
-	str := fmt.Sprintf("%d. %s", index+1, request)
+	title := savedRequest.Name
+	secondaryElements := []string{savedRequest.Request.String()}
+	if title == "" {
+		title = savedRequest.Request.String()
+		secondaryElements = []string{}
+	}
+	if savedRequest.Path != "" {
+		secondaryElements = append(secondaryElements, savedRequest.Path)
+	}
+	if savedRequest.Description != "" {
+		secondaryElements = append(secondaryElements, savedRequest.Description)
+	}
+	str := fmt.Sprintf("%d. %s", index+1, title)
 
 	fn := savedRequestsItemStyle.Render
 	if index == m.Index() {
@@ -36,13 +52,24 @@ func (d savedRequestsItemDelegate) Render(w io.Writer, m list.Model, index int,
 		}
 	}
 
-	fmt.Fprint(w, fn(str))
+	tags := ""
+	for _, tag := range savedRequest.Tags {
+		tags += " " + savedRequestsTagStyle.Render("#"+tag)
+	}
+	fmt.Fprint(w, fn(str)+tags+"\n"+savedRequestsSecondaryItemStyle.Render(strings.Join(secondaryElements, " · ")))
+}
+
+// savedRequestItem keeps the index of the saved request as the list displays
+// the most recent saved request first and can be filtered
+type savedRequestItem struct {
+	app.SavedRequest
+	index int
 }
 
 func mapSavedRequests(savedRequests app.SavedRequests) []list.Item {
 	items := []list.Item{}
 	for i := len(savedRequests) - 1; i >= 0; i-- {
-		items = append(items, savedRequests[i])
+		items = append(items, savedRequestItem{SavedRequest: savedRequests[i], index: i})
 	}
 	return items
 }
@@ -50,9 +77,81 @@ func mapSavedRequests(savedRequests app.SavedRequests) []list.Item {
 func newSavedRequestsList(savedRequests app.SavedRequests) list.Model {
 	l := list.New(mapSavedRequests(savedRequests), savedRequestsItemDelegate{}, 0, bottomListHeight)
 	l.SetShowStatusBar(false)
-	l.SetFilteringEnabled(false)
+	l.SetFilteringEnabled(true)
 	l.SetShowHelp(false)
 	l.Title = "Saved requests"
 	l.SetShowTitle(true)
 	return l
 }
+
+const (
+	nameEditorInput = iota
+	descriptionEditorInput
+	tagsEditorInput
+)
+
+var savedRequestEditorStyle = lipgloss.NewStyle().
+	Border(lipgloss.RoundedBorder()).
+	BorderForeground(lipgloss.Color("238")).
+	Padding(0, 1)
+
+// savedRequestEditor is the dialog editing the name, description and tags of
+// a saved request
+type savedRequestEditor struct {
+	index   int
+	inputs  []textinput.Model
+	focused int
+}
+
+func newSavedRequestEditor(index int, savedRequest app.SavedRequest) savedRequestEditor {
+	name := textinput.New()
+	name.Prompt = "Name: "
+	name.SetValue(savedRequest.Name)
+	description := textinput.New()
+	description.Prompt = "Description: "
+	description.SetValue(savedRequest.Description)
+	tags := textinput.New()
+	tags.Prompt = "Tags: "
+	tags.Placeholder = "comma separated"
+	tags.SetValue(strings.Join(savedRequest.Tags, ", "))
+
+	editor := savedRequestEditor{
+		index:  index,
+		inputs: []textinput.Model{name, description, tags},
+	}
+	editor.inputs[nameEditorInput].Focus()
+	return editor
+}
+
+func (e savedRequestEditor) metadata() app.SavedRequestMetadata {
+	return app.SavedRequestMetadata{
+		Name:        strings.TrimSpace(e.inputs[nameEditorInput].Value()),
+		Description: strings.TrimSpace(e.inputs[descriptionEditorInput].Value()),
+		Tags:        app.ParseTags(e.inputs[tagsEditorInput].Value()),
+	}
+}
+
+func (e savedRequestEditor) focus(next bool) (savedRequestEditor, tea.Cmd) {
+	e.inputs[e.focused].Blur()
+	if next {
+		e.focused = (e.focused + 1) % len(e.inputs)
+	} else {
+		e.focused = (e.focused + len(e.inputs) - 1) % len(e.inputs)
+	}
+	return e, e.inputs[e.focused].Focus()
+}
+
+func (e savedRequestEditor) Update(msg tea.Msg) (savedRequestEditor, tea.Cmd) {
+	var cmd tea.Cmd
+	e.inputs[e.focused], cmd = e.inputs[e.focused].Update(msg)
+	return e, cmd
+}
+
+func (e savedRequestEditor) View(width int) string {
+	views := []string{"Edit saved request (enter to save, esc to cancel)"}
+	for _, input := range e.inputs {
+		input.Width = max(width-len(input.Prompt)-6, 1)
+		views = append(views, input.View())
+	}
+	return savedRequestEditorStyle.Width(max(width-2, 1)).Render(strings.Join(views, "\n"))
+}
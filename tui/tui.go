@@ -1,16 +1,22 @@
 package tui
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"net/http"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/ThomasFerro/gogetter/app"
+	"github.com/atotto/clipboard"
 	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
@@ -23,7 +29,7 @@ const (
 var Gogetter app.Gogetter
 
 type keymap = struct {
-	next, prev, execute, save, remove, toggleHistory, toggleSavedRequests, quit, enter key.Binding
+	next, prev, execute, save, remove, toggleHistory, toggleSavedRequests, toggleHeaders, toggleRawBody, switchEnvironment, openResponse, edit, importCurl, export, runWorkflow, oauth2Tokens, toggleCookies, editCookie, removeCookie, cancel, quit, enter key.Binding
 }
 
 type focusedArea int
@@ -40,25 +46,39 @@ type bottomList int
 const (
 	HistoryBottomList bottomList = iota
 	SavedRequestsBottomList
+	CookiesBottomList
 )
 
 type model struct {
-	width             int
-	height            int
-	keymap            keymap
-	help              help.Model
-	requestTextarea   textarea.Model
-	variablesTextarea textarea.Model
-	responseTextarea  textarea.Model
-	history           list.Model
-	savedRequests     list.Model
-	focusedArea       focusedArea
-	ongoingRequest    bool
-	displayBottomList bool
-	bottomList        bottomList
+	width              int
+	height             int
+	keymap             keymap
+	help               help.Model
+	requestTextarea    textarea.Model
+	variablesTextarea  textarea.Model
+	responseTextarea   textarea.Model
+	history            list.Model
+	savedRequests      list.Model
+	cookies            list.Model
+	focusedArea        focusedArea
+	ongoingRequest     bool
+	displayBottomList  bool
+	bottomList         bottomList
+	parsingOptions     []app.RequestParsingOption
+	workflowSettings   app.WorkflowSettings
+	lastResponse       *responseView
+	collapseHeaders    bool
+	rawBody            bool
+	savedRequestEditor *savedRequestEditor
+	cookieEditor       *cookieEditor
+	// cancelRequest aborts the ongoing request or workflow
+	cancelRequest context.CancelFunc
+	// highlightedResponse is displayed instead of the response textarea
+	// while it is not focused, as the textarea cannot render colors
+	highlightedResponse string
 }
 
-func NewModel(gogetter app.Gogetter) model {
+func NewModel(gogetter app.Gogetter, workflowSettings app.WorkflowSettings, parsingOptions ...app.RequestParsingOption) model {
 	Gogetter = gogetter
 	requestTextarea := newTextarea()
 	requestTextarea.Placeholder = "Type your request"
@@ -66,6 +86,7 @@ func NewModel(gogetter app.Gogetter) model {
 	variablesTextarea.Placeholder = "Add variables as a JSON object if needed"
 	history := newHistoryList(gogetter.History())
 	savedRequests := newSavedRequestsList(gogetter.SavedRequests())
+	cookies := newCookiesList(gogetter.Cookies())
 	m := model{
 		requestTextarea:   requestTextarea,
 		variablesTextarea: variablesTextarea,
@@ -92,10 +113,62 @@ func NewModel(gogetter app.Gogetter) model {
 				key.WithKeys("alt+r"),
 				key.WithHelp("alt+r", "toggle saved requests"),
 			),
+			toggleHeaders: key.NewBinding(
+				key.WithKeys("alt+e"),
+				key.WithHelp("alt+e", "toggle response headers"),
+			),
+			toggleRawBody: key.NewBinding(
+				key.WithKeys("alt+p"),
+				key.WithHelp("alt+p", "toggle raw/pretty body"),
+			),
+			switchEnvironment: key.NewBinding(
+				key.WithKeys("alt+n"),
+				key.WithHelp("alt+n", "switch environment"),
+			),
+			openResponse: key.NewBinding(
+				key.WithKeys("alt+o"),
+				key.WithHelp("alt+o", "open recorded response"),
+			),
+			edit: key.NewBinding(
+				key.WithKeys("alt+m"),
+				key.WithHelp("alt+m", "edit saved request"),
+			),
+			importCurl: key.NewBinding(
+				key.WithKeys("alt+i"),
+				key.WithHelp("alt+i", "import curl"),
+			),
+			export: key.NewBinding(
+				key.WithKeys("alt+x"),
+				key.WithHelp("alt+x", "export request"),
+			),
+			runWorkflow: key.NewBinding(
+				key.WithKeys("alt+w"),
+				key.WithHelp("alt+w", "run listed requests as a workflow"),
+			),
+			oauth2Tokens: key.NewBinding(
+				key.WithKeys("alt+a"),
+				key.WithHelp("alt+a", "show OAuth2 tokens"),
+			),
+			toggleCookies: key.NewBinding(
+				key.WithKeys("alt+k"),
+				key.WithHelp("alt+k", "toggle cookies"),
+			),
+			editCookie: key.NewBinding(
+				key.WithKeys("alt+m"),
+				key.WithHelp("alt+m", "edit cookie"),
+			),
+			removeCookie: key.NewBinding(
+				key.WithKeys("alt+d"),
+				key.WithHelp("alt+d", "remove cookie"),
+			),
 			execute: key.NewBinding(
 				key.WithKeys("alt+enter"),
 				key.WithHelp("alt+enter", "execute"),
 			),
+			cancel: key.NewBinding(
+				key.WithKeys("alt+c"),
+				key.WithHelp("alt+c", "cancel request"),
+			),
 			enter: key.NewBinding(
 				key.WithKeys("enter"),
 				key.WithHelp("enter", "enter"),
@@ -112,6 +185,9 @@ func NewModel(gogetter app.Gogetter) model {
 		displayBottomList: false,
 		history:           history,
 		savedRequests:     savedRequests,
+		cookies:           cookies,
+		parsingOptions:    parsingOptions,
+		workflowSettings:  workflowSettings,
 	}
 
 	m.requestTextarea.Focus()
@@ -121,12 +197,20 @@ func NewModel(gogetter app.Gogetter) model {
 
 type newRequestMsg struct{}
 
+// responseMsg and workflowMsg carry the gogetter the request or workflow
+// ended with, only Update replacing the global one
 type responseMsg struct {
-	responseBody       string
+	gogetter           app.Gogetter
+	response           *responseView
 	requestAndResponse app.RequestAndResponse
 	err                error
 }
 
+type workflowMsg struct {
+	gogetter app.Gogetter
+	report   app.WorkflowReport
+}
+
 func (m model) newRequest() (model, []tea.Cmd) {
 	return m, []tea.Cmd{func() tea.Msg {
 		return newRequestMsg{}
@@ -134,47 +218,153 @@ func (m model) newRequest() (model, []tea.Cmd) {
 }
 
 func (m model) currentRequest() (app.Request, error) {
-	request, err := app.ParseRequest(m.requestTextarea.Value())
+	request, err := app.ParseRequest(m.requestTextarea.Value(), m.parsingOptions...)
 	return request, err
 }
 
-func (m model) currentTemplatedRequest() (app.Request, error) {
+func (m model) adHocVariables() (any, error) {
 	var data any = nil
 	variables := m.variablesTextarea.Value()
 	if variables != "" {
 		err := json.Unmarshal([]byte(variables), &data)
 		if err != nil {
-			return app.Request{}, fmt.Errorf("variable parsing error: %w", err)
+			return nil, fmt.Errorf("variable parsing error: %w", err)
 		}
 	}
-	request, err := app.ParseRequest(m.requestTextarea.Value(), app.TemplatedRequestOption{Data: data})
+	return data, nil
+}
+
+func (m model) currentTemplatedRequest() (app.Request, error) {
+	data, err := m.adHocVariables()
+	if err != nil {
+		return app.Request{}, err
+	}
+	data, err = Gogetter.TemplateData(data)
+	if err != nil {
+		return app.Request{}, err
+	}
+	options := append([]app.RequestParsingOption{app.TemplatedRequestOption{Data: data, Functions: Gogetter.TemplateFunctions()}}, m.parsingOptions...)
+	request, err := app.ParseRequest(m.requestTextarea.Value(), options...)
 	return request, err
 }
 
+// importCurl converts the curl command typed in the request area, or the one
+// in the clipboard otherwise
+func (m model) importCurl() (app.Request, error) {
+	command := m.requestTextarea.Value()
+	if !strings.HasPrefix(strings.TrimSpace(command), "curl") {
+		var err error
+		command, err = clipboard.ReadAll()
+		if err != nil {
+			return app.Request{}, fmt.Errorf("clipboard reading error: %w", err)
+		}
+	}
+	return app.ParseCurl(command)
+}
+
+// exportRequest exports the selected history entry or saved request when the
+// bottom list is focused, the templated request being edited otherwise
+func (m model) exportRequest() (string, error) {
+	var request app.Request
+	var err error
+	switch {
+	case m.focusedArea == BottomListArea && m.bottomList == HistoryBottomList:
+		selectedHistoryEntry, ok := m.history.SelectedItem().(app.RequestAndResponse)
+		if !ok {
+			return "", errors.New("no history entry selected")
+		}
+		request = selectedHistoryEntry.Request
+	case m.focusedArea == BottomListArea && m.bottomList == SavedRequestsBottomList:
+		selectedSavedRequest, ok := m.savedRequests.SelectedItem().(savedRequestItem)
+		if !ok {
+			return "", errors.New("no saved request selected")
+		}
+		request = selectedSavedRequest.Request
+	default:
+		request, err = m.currentTemplatedRequest()
+		if err != nil {
+			return "", err
+		}
+	}
+
+	exports := []string{}
+	for _, exportFormat := range app.ExportFormats {
+		exported, err := app.Export(request, exportFormat)
+		if err != nil {
+			return "", err
+		}
+		exports = append(exports, fmt.Sprintf("# %v\n%v", exportFormat, strings.TrimSuffix(exported, "\n")))
+	}
+	return strings.Join(exports, "\n\n"), nil
+}
+
 func (m model) executeRequest() (model, []tea.Cmd) {
 	if m.ongoingRequest {
 		return m, []tea.Cmd{}
 	}
 	m.ongoingRequest = true
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancelRequest = cancel
+	gogetter := Gogetter
 	return m, []tea.Cmd{func() tea.Msg {
 		request, err := m.currentTemplatedRequest()
 		if err != nil {
-			return responseMsg{err: err, requestAndResponse: app.RequestAndResponse{}, responseBody: ""}
+			return responseMsg{gogetter: gogetter, err: err, requestAndResponse: app.RequestAndResponse{}}
 		}
 		var resp *http.Response
 		var requestAndResponse app.RequestAndResponse
-		Gogetter, requestAndResponse, resp, err = Gogetter.Execute(request)
-		var response []byte
+		gogetter, requestAndResponse, resp, err = gogetter.Execute(ctx, request)
+		if resp == nil {
+			return responseMsg{gogetter: gogetter, err: err, requestAndResponse: requestAndResponse}
+		}
 
-		if resp != nil {
-			defer resp.Body.Close()
-			response, err = io.ReadAll(resp.Body)
-			if err != nil {
-				return responseMsg{err: err, requestAndResponse: requestAndResponse, responseBody: ""}
-			}
+		defer resp.Body.Close()
+		response, readErr := newResponseView(requestAndResponse, resp)
+		if readErr != nil {
+			return responseMsg{gogetter: gogetter, err: readErr, requestAndResponse: requestAndResponse}
+		}
+		return responseMsg{gogetter: gogetter, err: err, requestAndResponse: requestAndResponse, response: &response}
+	}}
+}
+
+// runWorkflow executes the saved requests listed, e.g. the filtered ones, in
+// the order they were saved
+func (m model) runWorkflow() (model, []tea.Cmd) {
+	if m.ongoingRequest {
+		return m, []tea.Cmd{}
+	}
+	adHocVariables, err := m.adHocVariables()
+	if err != nil {
+		m.responseTextarea.SetValue(fmt.Sprintf("workflow error: %s", err))
+		return m, []tea.Cmd{}
+	}
+	items := []savedRequestItem{}
+	for _, listItem := range m.savedRequests.VisibleItems() {
+		if item, ok := listItem.(savedRequestItem); ok {
+			items = append(items, item)
 		}
+	}
+	slices.SortFunc(items, func(a, b savedRequestItem) int { return a.index - b.index })
+	steps := app.SavedRequests{}
+	for _, item := range items {
+		steps = append(steps, item.SavedRequest)
+	}
 
-		return responseMsg{err: err, requestAndResponse: requestAndResponse, responseBody: string(response)}
+	m.ongoingRequest = true
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancelRequest = cancel
+	m.lastResponse = nil
+	m.highlightedResponse = ""
+	m.responseTextarea.SetValue(fmt.Sprintf("Running a workflow of %v requests...", len(steps)))
+	gogetter := Gogetter
+	return m, []tea.Cmd{func() tea.Msg {
+		gogetter, report := gogetter.RunWorkflow(ctx, app.Workflow{
+			WorkflowSettings: m.workflowSettings,
+			Steps:            steps,
+			AdHocVariables:   adHocVariables,
+			ParsingOptions:   m.parsingOptions,
+		})
+		return workflowMsg{gogetter: gogetter, report: report}
 	}}
 }
 
@@ -248,51 +438,112 @@ func (m model) FocusNextTab() (model, []tea.Cmd) {
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 
+	if keyMsg, ok := msg.(tea.KeyMsg); ok && m.savedRequestEditor != nil {
+		return m.updateSavedRequestEditor(keyMsg)
+	}
+	if keyMsg, ok := msg.(tea.KeyMsg); ok && m.cookieEditor != nil {
+		return m.updateCookieEditor(keyMsg)
+	}
+	if keyMsg, ok := msg.(tea.KeyMsg); ok && m.bottomListHandlesFiltering(keyMsg) {
+		return m.updateBottomList(msg)
+	}
+
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch {
 		case key.Matches(msg, m.keymap.quit):
 			return m, tea.Quit
+		case key.Matches(msg, m.keymap.cancel):
+			if !m.ongoingRequest || m.cancelRequest == nil {
+				break
+			}
+			m.cancelRequest()
+			m.responseTextarea.SetValue("Cancelling...")
+			m.highlightedResponse = ""
+			return m, nil
 		case key.Matches(msg, m.keymap.toggleHistory):
-			if m.displayBottomList {
-				m.displayBottomList = m.bottomList != HistoryBottomList
-			} else {
-				m.displayBottomList = true
+			return m.toggleBottomList(HistoryBottomList)
+
+		case key.Matches(msg, m.keymap.toggleSavedRequests):
+			return m.toggleBottomList(SavedRequestsBottomList)
+
+		case key.Matches(msg, m.keymap.toggleCookies):
+			return m.toggleBottomList(CookiesBottomList)
+
+		case key.Matches(msg, m.keymap.toggleHeaders):
+			m.collapseHeaders = !m.collapseHeaders
+			if m.lastResponse != nil && !m.ongoingRequest {
+				m.displayResponse(m.lastResponse)
 			}
+			return m, nil
 
-			m.bottomList = HistoryBottomList
-			if m.displayBottomList {
-				m.focusedArea = BottomListArea
-				m.responseTextarea.Blur()
-				m.requestTextarea.Blur()
-				m.variablesTextarea.Blur()
+		case key.Matches(msg, m.keymap.toggleRawBody):
+			m.rawBody = !m.rawBody
+			if m.lastResponse != nil && !m.ongoingRequest {
+				m.displayResponse(m.lastResponse)
+			}
+			return m, nil
+
+		// The keys changing the gogetter are ignored during a request, which
+		// replaces it with the one it was executed with once done
+		case key.Matches(msg, m.keymap.switchEnvironment):
+			if m.ongoingRequest {
+				break
+			}
+			Gogetter = Gogetter.NextEnvironment()
+			return m, m.cookies.SetItems(mapCookies(Gogetter.Cookies()))
+
+		case key.Matches(msg, m.keymap.openResponse):
+			if m.focusedArea != BottomListArea || m.bottomList != HistoryBottomList || m.ongoingRequest {
+				break
+			}
+			selectedHistoryEntry, ok := m.history.SelectedItem().(app.RequestAndResponse)
+			if !ok {
 				return m, nil
 			}
-			if m.focusedArea == BottomListArea {
-				m.focusedArea = RequestArea
-				return m, m.requestTextarea.Focus()
+			if selectedHistoryEntry.Response == nil {
+				m.lastResponse = nil
+				m.highlightedResponse = ""
+				m.responseTextarea.SetValue("No response recorded for this history entry")
+				if selectedHistoryEntry.Cancelled {
+					m.responseTextarea.SetValue("Request cancelled before its response")
+				}
+				return m, nil
 			}
+			response := newRecordedResponseView(selectedHistoryEntry)
+			m.lastResponse = &response
+			m.displayResponse(&response)
 			return m, nil
 
-		case key.Matches(msg, m.keymap.toggleSavedRequests):
-			if m.displayBottomList {
-				m.displayBottomList = m.bottomList != SavedRequestsBottomList
-			} else {
-				m.displayBottomList = true
+		case key.Matches(msg, m.keymap.importCurl):
+			request, err := m.importCurl()
+			if err != nil {
+				m.responseTextarea.SetValue(fmt.Sprintf("curl import error: %s", err))
+				return m, nil
 			}
+			m.requestTextarea.SetValue(request.Raw)
+			return m, nil
 
-			m.bottomList = SavedRequestsBottomList
-			if m.displayBottomList {
-				m.focusedArea = BottomListArea
-				m.responseTextarea.Blur()
-				m.requestTextarea.Blur()
-				m.variablesTextarea.Blur()
-				return m, nil
+		case key.Matches(msg, m.keymap.export):
+			if m.ongoingRequest {
+				break
 			}
-			if m.focusedArea == BottomListArea {
-				m.focusedArea = RequestArea
-				return m, m.requestTextarea.Focus()
+			exports, err := m.exportRequest()
+			if err != nil {
+				exports = fmt.Sprintf("request export error: %s", err)
 			}
+			m.lastResponse = nil
+			m.highlightedResponse = ""
+			m.responseTextarea.SetValue(exports)
+			return m, nil
+
+		case key.Matches(msg, m.keymap.oauth2Tokens):
+			if m.ongoingRequest {
+				break
+			}
+			m.lastResponse = nil
+			m.highlightedResponse = ""
+			m.responseTextarea.SetValue(renderOAuth2Tokens(Gogetter, time.Now()))
 			return m, nil
 
 		case key.Matches(msg, m.keymap.execute):
@@ -301,31 +552,81 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			cmds = append(cmds, executeRequestCommands...)
 
 		case key.Matches(msg, m.keymap.save):
+			if m.ongoingRequest {
+				break
+			}
 			request, err := m.currentRequest()
 			if err != nil {
 				m.responseTextarea.SetValue(fmt.Sprintf("request saving error: %s", err))
+				return m, nil
 			}
 			Gogetter, err = Gogetter.SaveRequest(request)
 			if err != nil {
 				m.responseTextarea.SetValue(fmt.Sprintf("request saving error: %s", err))
 			}
-			newItems := append([]list.Item{request}, m.savedRequests.Items()...)
-			cmds = append(cmds, m.savedRequests.SetItems(newItems))
+			return m, m.savedRequests.SetItems(mapSavedRequests(Gogetter.SavedRequests()))
 
-			return m, nil
+		case m.focusedArea == BottomListArea && m.bottomList == CookiesBottomList && key.Matches(msg, m.keymap.removeCookie):
+			if m.ongoingRequest {
+				break
+			}
+			selectedCookie, ok := m.cookies.SelectedItem().(cookieItem)
+			if !ok {
+				return m, nil
+			}
+			var err error
+			Gogetter, err = Gogetter.RemoveCookie(selectedCookie.Cookie)
+			if err != nil {
+				m.responseTextarea.SetValue(fmt.Sprintf("remove cookie error: %s", err))
+			}
+			return m, m.cookies.SetItems(mapCookies(Gogetter.Cookies()))
+
+		case m.focusedArea == BottomListArea && m.bottomList == CookiesBottomList && key.Matches(msg, m.keymap.editCookie):
+			if m.ongoingRequest {
+				break
+			}
+			selectedCookie, ok := m.cookies.SelectedItem().(cookieItem)
+			if !ok {
+				return m, nil
+			}
+			editor := newCookieEditor(selectedCookie.Cookie)
+			m.cookieEditor = &editor
+			return m, textinput.Blink
 
 		case key.Matches(msg, m.keymap.remove):
-			itemToRemove := m.savedRequests.Index()
+			if m.focusedArea != BottomListArea || m.bottomList != SavedRequestsBottomList || m.ongoingRequest {
+				break
+			}
+			selectedSavedRequest, ok := m.savedRequests.SelectedItem().(savedRequestItem)
+			if !ok {
+				return m, nil
+			}
 			var err error
-			Gogetter, err = Gogetter.RemoveSavedRequest(itemToRemove)
+			Gogetter, err = Gogetter.RemoveSavedRequest(selectedSavedRequest.index)
 			if err != nil {
 				m.responseTextarea.SetValue(fmt.Sprintf("remove saved request error: %s", err))
 			}
-			previousItems := m.savedRequests.Items()
-			newItems := append(previousItems[:itemToRemove], previousItems[itemToRemove+1:]...)
-			cmds = append(cmds, m.savedRequests.SetItems(newItems))
+			return m, m.savedRequests.SetItems(mapSavedRequests(Gogetter.SavedRequests()))
 
-			return m, nil
+		case key.Matches(msg, m.keymap.runWorkflow):
+			if m.focusedArea != BottomListArea || m.bottomList != SavedRequestsBottomList {
+				break
+			}
+			var workflowCommands []tea.Cmd
+			m, workflowCommands = m.runWorkflow()
+			cmds = append(cmds, workflowCommands...)
+
+		case key.Matches(msg, m.keymap.edit):
+			if m.focusedArea != BottomListArea || m.bottomList != SavedRequestsBottomList || m.ongoingRequest {
+				break
+			}
+			selectedSavedRequest, ok := m.savedRequests.SelectedItem().(savedRequestItem)
+			if !ok {
+				return m, nil
+			}
+			editor := newSavedRequestEditor(selectedSavedRequest.index, selectedSavedRequest.SavedRequest)
+			m.savedRequestEditor = &editor
+			return m, textinput.Blink
 
 		case key.Matches(msg, m.keymap.next):
 			var focusCmds []tea.Cmd
@@ -348,7 +649,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 
 			if m.bottomList == SavedRequestsBottomList {
-				selectedSavedRequest, ok := m.savedRequests.SelectedItem().(app.Request)
+				selectedSavedRequest, ok := m.savedRequests.SelectedItem().(savedRequestItem)
 				if !ok {
 					return m, nil
 				}
@@ -360,25 +661,41 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.WindowSizeMsg:
 		m.height = msg.Height
 		m.width = msg.Width
+		m.help.Width = msg.Width
 	case newRequestMsg:
 		m.responseTextarea.SetValue("Pending request...")
+		m.highlightedResponse = ""
 
 		var executeRequestCommands []tea.Cmd
 		m, executeRequestCommands = m.executeRequest()
 		cmds = append(cmds, executeRequestCommands...)
 	case responseMsg:
-		responseTextareaValue := ""
 		response := responseMsg(msg)
+		Gogetter = response.gogetter
+		m.lastResponse = response.response
 		if response.err != nil {
-			responseTextareaValue = fmt.Sprintf("%v\n", response.err.Error())
+			responseTextareaValue := fmt.Sprintf("%v\n", response.err.Error())
+			if response.response != nil {
+				responseTextareaValue += response.response.render(m.responseRenderingOptions(false))
+			}
+			m.responseTextarea.SetValue(responseTextareaValue)
+			m.highlightedResponse = ""
+		} else if response.response != nil {
+			m.displayResponse(response.response)
 		}
-		responseTextareaValue = fmt.Sprintf("%v%v", responseTextareaValue, response.responseBody)
-		m.responseTextarea.SetValue(responseTextareaValue)
 		m.ongoingRequest = false
-		if response.err == nil {
-			newItems := append([]list.Item{response.requestAndResponse}, m.history.Items()...)
-			cmds = append(cmds, m.history.SetItems(newItems))
+		m.cancelRequest()
+		if response.err == nil || errors.Is(response.err, app.ErrRequestCancelled) {
+			cmds = append(cmds, m.history.SetItems(mapHistory(Gogetter.History())))
 		}
+		cmds = append(cmds, m.cookies.SetItems(mapCookies(Gogetter.Cookies())))
+	case workflowMsg:
+		Gogetter = msg.gogetter
+		m.responseTextarea.SetValue(msg.report.String())
+		m.ongoingRequest = false
+		m.cancelRequest()
+		cmds = append(cmds, m.history.SetItems(mapHistory(Gogetter.History())))
+		cmds = append(cmds, m.cookies.SetItems(mapCookies(Gogetter.Cookies())))
 	}
 
 	m.sizeInputs()
@@ -392,19 +709,160 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		cmds = append(cmds, cmd)
 	}
 	if m.focusedArea == BottomListArea {
-		if m.bottomList == HistoryBottomList {
-			newModel, cmd := m.history.Update(msg)
-			m.history = newModel
-			cmds = append(cmds, cmd)
+		newModel, cmd := m.updateBottomList(msg)
+		m = newModel.(model)
+		cmds = append(cmds, cmd)
+	}
+
+	return m, tea.Batch(cmds...)
+}
+
+// toggleBottomList displays the bottom list and focuses it, or hides it when
+// it is already displayed
+func (m model) toggleBottomList(bottomList bottomList) (tea.Model, tea.Cmd) {
+	if m.displayBottomList {
+		m.displayBottomList = m.bottomList != bottomList
+	} else {
+		m.displayBottomList = true
+	}
+
+	m.bottomList = bottomList
+	if m.displayBottomList {
+		m.focusedArea = BottomListArea
+		m.responseTextarea.Blur()
+		m.requestTextarea.Blur()
+		m.variablesTextarea.Blur()
+		return m, nil
+	}
+	if m.focusedArea == BottomListArea {
+		m.focusedArea = RequestArea
+		return m, m.requestTextarea.Focus()
+	}
+	return m, nil
+}
+
+func (m model) focusedBottomList() list.Model {
+	if m.bottomList == SavedRequestsBottomList {
+		return m.savedRequests
+	}
+	if m.bottomList == CookiesBottomList {
+		return m.cookies
+	}
+	return m.history
+}
+
+// bottomListHandlesFiltering tells whether the key is meant for the filter of
+// the focused bottom list, typing a filter or clearing it with esc
+func (m model) bottomListHandlesFiltering(msg tea.KeyMsg) bool {
+	if m.focusedArea != BottomListArea || !m.displayBottomList {
+		return false
+	}
+	bottomList := m.focusedBottomList()
+	switch bottomList.FilterState() {
+	case list.Filtering:
+		return true
+	case list.FilterApplied:
+		return key.Matches(msg, bottomList.KeyMap.ClearFilter)
+	}
+	return false
+}
+
+func (m model) updateBottomList(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	if m.bottomList == HistoryBottomList {
+		m.history, cmd = m.history.Update(msg)
+	}
+	if m.bottomList == SavedRequestsBottomList {
+		m.savedRequests, cmd = m.savedRequests.Update(msg)
+	}
+	if m.bottomList == CookiesBottomList {
+		m.cookies, cmd = m.cookies.Update(msg)
+	}
+	return m, cmd
+}
+
+func (m model) updateSavedRequestEditor(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	editor := *m.savedRequestEditor
+	var cmd tea.Cmd
+	switch {
+	case msg.Type == tea.KeyEsc:
+		m.savedRequestEditor = nil
+		return m, nil
+	case key.Matches(msg, m.keymap.enter):
+		var err error
+		m.savedRequestEditor = nil
+		Gogetter, err = Gogetter.EditSavedRequest(editor.index, editor.metadata())
+		if err != nil {
+			m.responseTextarea.SetValue(fmt.Sprintf("saved request edition error: %s", err))
 		}
-		if m.bottomList == SavedRequestsBottomList {
-			newModel, cmd := m.savedRequests.Update(msg)
-			m.savedRequests = newModel
-			cmds = append(cmds, cmd)
+		return m, m.savedRequests.SetItems(mapSavedRequests(Gogetter.SavedRequests()))
+	case key.Matches(msg, m.keymap.next):
+		editor, cmd = editor.focus(true)
+	case key.Matches(msg, m.keymap.prev):
+		editor, cmd = editor.focus(false)
+	default:
+		editor, cmd = editor.Update(msg)
+	}
+	m.savedRequestEditor = &editor
+	return m, cmd
+}
+
+func (m model) updateCookieEditor(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	editor := *m.cookieEditor
+	var cmd tea.Cmd
+	switch {
+	case msg.Type == tea.KeyEsc:
+		m.cookieEditor = nil
+		return m, nil
+	case key.Matches(msg, m.keymap.enter):
+		cookie, err := editor.cookie()
+		if err == nil {
+			Gogetter, err = Gogetter.SaveCookie(editor.previous, cookie)
+		}
+		if err != nil {
+			m.responseTextarea.SetValue(fmt.Sprintf("cookie edition error: %s", err))
+			return m, nil
 		}
+		m.cookieEditor = nil
+		return m, m.cookies.SetItems(mapCookies(Gogetter.Cookies()))
+	case key.Matches(msg, m.keymap.next):
+		editor, cmd = editor.focus(true)
+	case key.Matches(msg, m.keymap.prev):
+		editor, cmd = editor.focus(false)
+	default:
+		editor, cmd = editor.Update(msg)
 	}
+	m.cookieEditor = &editor
+	return m, cmd
+}
 
-	return m, tea.Batch(cmds...)
+func (m model) responseRenderingOptions(highlight bool) responseRenderingOptions {
+	return responseRenderingOptions{
+		collapseHeaders: m.collapseHeaders,
+		rawBody:         m.rawBody,
+		highlight:       highlight,
+	}
+}
+
+func (m *model) displayResponse(response *responseView) {
+	m.responseTextarea.SetValue(response.render(m.responseRenderingOptions(false)))
+	m.highlightedResponse = ""
+	if !m.rawBody {
+		m.highlightedResponse = response.render(m.responseRenderingOptions(true))
+	}
+}
+
+func (m model) responseView() string {
+	if m.focusedArea == ResponseArea || m.highlightedResponse == "" {
+		return m.responseTextarea.View()
+	}
+	width := m.responseTextarea.Width()
+	height := m.responseTextarea.Height()
+	return blurredBorderStyle.
+		Width(width).
+		Height(height).
+		MaxHeight(height + 2).
+		Render(m.highlightedResponse)
 }
 
 func (m *model) sizeInputs() {
@@ -413,6 +871,7 @@ func (m *model) sizeInputs() {
 		height -= bottomListHeight
 		m.history.SetWidth(m.width)
 		m.savedRequests.SetWidth(m.width)
+		m.cookies.SetWidth(m.width)
 	}
 	m.requestTextarea.SetWidth(m.width / 2)
 	m.requestTextarea.SetHeight(height/2 - 1)
@@ -428,34 +887,77 @@ func (m model) View() string {
 		m.keymap.prev,
 		m.keymap.toggleHistory,
 		m.keymap.toggleSavedRequests,
+		m.keymap.toggleCookies,
+		m.keymap.switchEnvironment,
+	}
+	if len(Gogetter.OAuth2ConfigurationNames()) > 0 {
+		displayedBindingHelps = append(displayedBindingHelps, m.keymap.oauth2Tokens)
+	}
+	if m.ongoingRequest {
+		displayedBindingHelps = append([]key.Binding{m.keymap.cancel}, displayedBindingHelps...)
 	}
 	if m.focusedArea == RequestArea || m.focusedArea == ResponseArea || m.focusedArea == VariablesArea {
 		displayedBindingHelps = append([]key.Binding{
 			m.keymap.execute,
 			m.keymap.save,
+			m.keymap.toggleHeaders,
+			m.keymap.toggleRawBody,
+			m.keymap.importCurl,
+			m.keymap.export,
+		}, displayedBindingHelps...)
+	}
+	if m.focusedArea == BottomListArea && m.bottomList == HistoryBottomList {
+		displayedBindingHelps = append([]key.Binding{
+			m.keymap.openResponse,
+			m.keymap.export,
 		}, displayedBindingHelps...)
 	}
 	if m.focusedArea == BottomListArea && m.bottomList == SavedRequestsBottomList {
 		displayedBindingHelps = append([]key.Binding{
+			m.keymap.edit,
 			m.keymap.remove,
+			m.keymap.export,
+			m.keymap.runWorkflow,
 		}, displayedBindingHelps...)
 
 	}
+	if m.focusedArea == BottomListArea && m.bottomList == CookiesBottomList {
+		displayedBindingHelps = append([]key.Binding{
+			m.keymap.editCookie,
+			m.keymap.removeCookie,
+		}, displayedBindingHelps...)
+	}
 	help := m.help.ShortHelpView(displayedBindingHelps)
+	if capturedVariables := Gogetter.CapturedVariables(); len(capturedVariables) > 0 {
+		help = fmt.Sprintf("[%v captured] %v", len(capturedVariables), help)
+	}
+	if Gogetter.CookiesEnabled() {
+		help = fmt.Sprintf("[cookies] %v", help)
+	}
+	if environment, ok := Gogetter.ActiveEnvironment(); ok {
+		help = fmt.Sprintf("[%v] %v", environment.Name, help)
+	}
 
 	var views []string
 	requestView := lipgloss.JoinVertical(lipgloss.Left, m.requestTextarea.View(), m.variablesTextarea.View())
 	views = append(views, requestView)
-	views = append(views, m.responseTextarea.View())
+	views = append(views, m.responseView())
 
 	view := lipgloss.JoinHorizontal(lipgloss.Top, views...) + "\n\n"
 	if m.displayBottomList {
 		if m.bottomList == HistoryBottomList {
 			view += m.history.View() + "\n\n"
 		}
-		if m.bottomList == SavedRequestsBottomList {
+		if m.bottomList == SavedRequestsBottomList && m.savedRequestEditor != nil {
+			view += m.savedRequestEditor.View(m.width) + "\n\n"
+		} else if m.bottomList == SavedRequestsBottomList {
 			view += m.savedRequests.View() + "\n\n"
 		}
+		if m.bottomList == CookiesBottomList && m.cookieEditor != nil {
+			view += m.cookieEditor.View(m.width) + "\n\n"
+		} else if m.bottomList == CookiesBottomList {
+			view += m.cookies.View() + "\n\n"
+		}
 	}
 
 	return view + help